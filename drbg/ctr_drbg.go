@@ -0,0 +1,153 @@
+package drbg
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+
+	"github.com/t1anchen/gogmlib/sm4"
+)
+
+const (
+	ctrKeyLen   = sm4.KeySizeInByte
+	ctrBlockLen = sm4.BlockSizeInByte
+	// ctrSeedLen 基于 SM4 的 CTR_DRBG 的 seedlen，256 比特
+	ctrSeedLen = ctrKeyLen + ctrBlockLen
+	// ctrEntropyLen 安全强度 128 比特
+	ctrEntropyLen = 128 / 8
+	ctrNonceLen   = ctrEntropyLen / 2
+)
+
+// CTRDRBG 基于 SM4 的 CTR_DRBG，使用派生函数
+type CTRDRBG struct {
+	source
+	block         cipher.Block
+	v             [ctrBlockLen]byte
+	reseedCounter uint64
+}
+
+// NewCTRDRBG 实例化基于 SM4 的 CTR_DRBG，opts 可以为 nil
+func NewCTRDRBG(opts *Options) (*CTRDRBG, error) {
+	d := &CTRDRBG{source: newSource(opts)}
+	seed, err := d.seedMaterial(opts, ctrEntropyLen, ctrNonceLen)
+	if err != nil {
+		return nil, err
+	}
+	d.block, _ = sm4.NewCipher(make([]byte, ctrKeyLen))
+	d.update(df(seed, ctrSeedLen))
+	d.reseedCounter = 1
+	return d, nil
+}
+
+// update CTR_DRBG_Update
+func (d *CTRDRBG) update(provided []byte) {
+	var temp [ctrSeedLen]byte
+	for off := 0; off < ctrSeedLen; off += ctrBlockLen {
+		addMod(d.v[:], []byte{0x01})
+		d.block.Encrypt(temp[off:], d.v[:])
+	}
+	for i := range provided {
+		temp[i] ^= provided[i]
+	}
+	d.block, _ = sm4.NewCipher(temp[:ctrKeyLen])
+	copy(d.v[:], temp[ctrKeyLen:])
+}
+
+// Reseed 重播种
+func (d *CTRDRBG) Reseed(additional []byte) error {
+	entropy, err := d.getEntropy(ctrEntropyLen)
+	if err != nil {
+		return err
+	}
+	d.update(df(append(entropy, additional...), ctrSeedLen))
+	d.reseedCounter = 1
+	return nil
+}
+
+// Generate 输出随机数
+func (d *CTRDRBG) Generate(out, additional []byte) error {
+	if len(out) > MaxBytesPerRequest {
+		return ErrRequestTooLarge
+	}
+	if d.predictionResistance {
+		if err := d.Reseed(additional); err != nil {
+			return err
+		}
+		additional = nil
+	} else if d.reseedCounter > d.reseedInterval {
+		return ErrReseedRequired
+	}
+
+	var provided []byte
+	if len(additional) > 0 {
+		provided = df(additional, ctrSeedLen)
+		d.update(provided)
+	}
+
+	var block [ctrBlockLen]byte
+	for off := 0; off < len(out); off += ctrBlockLen {
+		addMod(d.v[:], []byte{0x01})
+		d.block.Encrypt(block[:], d.v[:])
+		copy(out[off:], block[:])
+	}
+
+	d.update(provided)
+	d.reseedCounter++
+	return nil
+}
+
+// Read 实现 io.Reader 接口，达到重播种间隔时自动重播种
+func (d *CTRDRBG) Read(p []byte) (int, error) {
+	return readAll(d, p)
+}
+
+// ReseedCounter 当前重播种计数器
+func (d *CTRDRBG) ReseedCounter() uint64 {
+	return d.reseedCounter
+}
+
+// df 基于 SM4 的分组密码派生函数 Block_Cipher_df
+func df(input []byte, outLen int) []byte {
+	s := make([]byte, 8, 8+len(input)+1+ctrBlockLen)
+	binary.BigEndian.PutUint32(s[0:], uint32(len(input)))
+	binary.BigEndian.PutUint32(s[4:], uint32(outLen))
+	s = append(s, input...)
+	s = append(s, 0x80)
+	for len(s)%ctrBlockLen != 0 {
+		s = append(s, 0x00)
+	}
+
+	var key [ctrKeyLen]byte
+	for i := range key {
+		key[i] = byte(i)
+	}
+	block, _ := sm4.NewCipher(key[:])
+
+	temp := make([]byte, 0, ctrSeedLen)
+	var iv [ctrBlockLen]byte
+	for i := uint32(0); len(temp) < ctrSeedLen; i++ {
+		binary.BigEndian.PutUint32(iv[:], i)
+		temp = append(temp, bcc(block, iv[:], s)...)
+	}
+
+	block, _ = sm4.NewCipher(temp[:ctrKeyLen])
+	x := temp[ctrKeyLen:ctrSeedLen]
+	out := make([]byte, 0, outLen+ctrBlockLen)
+	for len(out) < outLen {
+		block.Encrypt(x, x)
+		out = append(out, x...)
+	}
+	return out[:outLen]
+}
+
+// bcc 以 CBC-MAC 方式压缩 iv || data
+func bcc(block cipher.Block, iv, data []byte) []byte {
+	chain := make([]byte, ctrBlockLen)
+	block.Encrypt(chain, iv)
+	for off := 0; off < len(data); off += ctrBlockLen {
+		for i := 0; i < ctrBlockLen; i++ {
+			chain[i] ^= data[off+i]
+		}
+		block.Encrypt(chain, chain)
+	}
+	return chain
+}
@@ -0,0 +1,60 @@
+package drbg
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+var ctrVectors = []drbgVector{
+	{
+		false,
+		"99a609ff2109f8bc600167db1af9c6f8b1d63b636a05c9e4425e98c4f827bfcd340c6b5839421c86c1341ad546ead3c2eecd4009b1f65e4e680d76943da5fcb4",
+		"e0a2f8da9f6e2e14b8e4b257423f42c4cdb7aec05a59da68b182d8c1cbd2db7dc25b3971b52ee8f81e31bc96310d3883ff04aecdea506323d75b29dc88588704",
+		"c9a2b1d7c7407e9fb1a1d7ef8b08ff744422244bb02fa329b1d0f4af000f223ccbcfa767ae3a7f8ae2128e059f92c9e4746828de2959975d66f5cb51613cde9e",
+	},
+	{
+		true,
+		"d40424c740d3115f01a032ff9b7f56dec367c715f3bc434a44862408f9550692c939d22dacb858f5924fa4cc0f3c04605e2452f4392d5dc870739959f5f3f68e",
+		"21426235102b76e594e3f896137f0faff2fec0a65c40fdf25294d5d3a9478b45f1a674fd464cc7b611f0c8a9902f03ab83ca11c73f61efd717ca471d6fcbb541",
+		"",
+	},
+}
+
+func TestCTRDRBGVectors(t *testing.T) {
+	for _, v := range ctrVectors {
+		// 熵源每次都返回同样的熵输入
+		entropy := bytes.Repeat(seq(0, ctrEntropyLen), 3)
+		d, err := NewCTRDRBG(&Options{
+			Entropy:              bytes.NewReader(entropy),
+			Nonce:                seq(0x20, ctrNonceLen),
+			Personalization:      seq(0x40, 32),
+			PredictionResistance: v.predictionResistance,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkVector(t, fmt.Sprintf("TestCTRDRBGVectors(pr=%v)", v.predictionResistance), d, v)
+	}
+}
+
+func TestCTRDRBGRead(t *testing.T) {
+	d, err := NewCTRDRBG(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := make([]byte, 100)
+	b := make([]byte, 100)
+	if _, err := d.Read(a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a, b) {
+		t.Error("TestCTRDRBGRead 失败: 两次输出相同")
+	}
+	if err := d.Generate(make([]byte, MaxBytesPerRequest+1), nil); err != ErrRequestTooLarge {
+		t.Errorf("TestCTRDRBGRead 失败: err = %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package drbg
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// -----------------------------------------------------------------------------
+// GM/T 0105-2021 软件随机数发生器设计指南 附录 C 随机数发生器
+// -----------------------------------------------------------------------------
+
+const (
+	// DefaultReseedInterval 默认重播种间隔，达到后必须重播种才能继续输出
+	DefaultReseedInterval = 1 << 20
+
+	// MaxBytesPerRequest 单次 Generate 允许输出的最大字节数
+	MaxBytesPerRequest = 1 << 16
+)
+
+var (
+	ErrReseedRequired      = errors.New("drbg: reseed required")
+	ErrRequestTooLarge     = errors.New("drbg: requested too many bytes")
+	ErrInsufficientEntropy = errors.New("drbg: insufficient entropy")
+)
+
+// Options 实例化参数
+type Options struct {
+	// Entropy 熵源，为 nil 时使用 crypto/rand.Reader
+	Entropy io.Reader
+	// Nonce 为 nil 时从熵源读取
+	Nonce []byte
+	// Personalization 个性化字符串
+	Personalization []byte
+	// PredictionResistance 启用时每次输出前都从熵源重播种
+	PredictionResistance bool
+	// ReseedInterval 为 0 时使用 DefaultReseedInterval
+	ReseedInterval uint64
+}
+
+// DRBG 确定性随机比特发生器
+type DRBG interface {
+	io.Reader
+	// Reseed 从熵源重播种
+	Reseed(additional []byte) error
+	// Generate 输出 len(out) 字节的随机数
+	Generate(out, additional []byte) error
+	// ReseedCounter 自上次（重）播种以来的输出次数加一
+	ReseedCounter() uint64
+}
+
+type source struct {
+	entropy              io.Reader
+	predictionResistance bool
+	reseedInterval       uint64
+}
+
+func newSource(opts *Options) source {
+	s := source{
+		entropy:        rand.Reader,
+		reseedInterval: DefaultReseedInterval,
+	}
+	if opts == nil {
+		return s
+	}
+	if opts.Entropy != nil {
+		s.entropy = opts.Entropy
+	}
+	if opts.ReseedInterval != 0 {
+		s.reseedInterval = opts.ReseedInterval
+	}
+	s.predictionResistance = opts.PredictionResistance
+	return s
+}
+
+func (s *source) getEntropy(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(s.entropy, buf); err != nil {
+		return nil, ErrInsufficientEntropy
+	}
+	return buf, nil
+}
+
+// seedMaterial 按 entropy || nonce || personalization 组织实例化输入
+func (s *source) seedMaterial(opts *Options, entropyLen, nonceLen int) ([]byte, error) {
+	entropy, err := s.getEntropy(entropyLen)
+	if err != nil {
+		return nil, err
+	}
+	var nonce, personalization []byte
+	if opts != nil {
+		nonce = opts.Nonce
+		personalization = opts.Personalization
+	}
+	if nonce == nil {
+		if nonce, err = s.getEntropy(nonceLen); err != nil {
+			return nil, err
+		}
+	}
+	seed := make([]byte, 0, len(entropy)+len(nonce)+len(personalization))
+	seed = append(seed, entropy...)
+	seed = append(seed, nonce...)
+	return append(seed, personalization...), nil
+}
+
+// readAll 将 io.Reader 的读取拆分成不超过 MaxBytesPerRequest 的 Generate 调用
+func readAll(d DRBG, p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		chunk := len(p) - n
+		if chunk > MaxBytesPerRequest {
+			chunk = MaxBytesPerRequest
+		}
+		err := d.Generate(p[n:n+chunk], nil)
+		if err == ErrReseedRequired {
+			if err = d.Reseed(nil); err == nil {
+				err = d.Generate(p[n:n+chunk], nil)
+			}
+		}
+		if err != nil {
+			return n, err
+		}
+		n += chunk
+	}
+	return n, nil
+}
+
+// addMod 计算 v = (v + x) mod 2^(8*len(v))，x 按大端右对齐
+func addMod(v, x []byte) {
+	carry := uint16(0)
+	j := len(x) - 1
+	for i := len(v) - 1; i >= 0; i-- {
+		sum := uint16(v[i]) + carry
+		if j >= 0 {
+			sum += uint16(x[j])
+			j--
+		}
+		v[i] = byte(sum)
+		carry = sum >> 8
+	}
+}
+
+func uint64ToBytes(n uint64) []byte {
+	var b [8]byte
+	for i := 7; i >= 0; i-- {
+		b[i] = byte(n)
+		n >>= 8
+	}
+	return b[:]
+}
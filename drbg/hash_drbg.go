@@ -0,0 +1,126 @@
+package drbg
+
+import (
+	"encoding/binary"
+	"hash"
+
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+const (
+	// hashSeedLen 基于 SM3 的 Hash_DRBG 的 seedlen，440 比特
+	hashSeedLen = 440 / 8
+	// hashEntropyLen 安全强度 256 比特
+	hashEntropyLen = 256 / 8
+	hashNonceLen   = hashEntropyLen / 2
+)
+
+// HashDRBG 基于 SM3 的 Hash_DRBG
+type HashDRBG struct {
+	source
+	h             hash.Hash
+	v             [hashSeedLen]byte
+	c             [hashSeedLen]byte
+	reseedCounter uint64
+}
+
+// NewHashDRBG 实例化基于 SM3 的 Hash_DRBG，opts 可以为 nil
+func NewHashDRBG(opts *Options) (*HashDRBG, error) {
+	d := &HashDRBG{source: newSource(opts), h: sm3.New()}
+	seed, err := d.seedMaterial(opts, hashEntropyLen, hashNonceLen)
+	if err != nil {
+		return nil, err
+	}
+	d.setSeed(d.df(hashSeedLen, seed))
+	return d, nil
+}
+
+// df Hash_df 派生函数
+func (d *HashDRBG) df(outLen int, inputs ...[]byte) []byte {
+	var prefix [5]byte
+	binary.BigEndian.PutUint32(prefix[1:], uint32(outLen*8))
+	out := make([]byte, 0, outLen+d.h.Size())
+	for counter := byte(1); len(out) < outLen; counter++ {
+		prefix[0] = counter
+		d.h.Reset()
+		d.h.Write(prefix[:])
+		for _, in := range inputs {
+			d.h.Write(in)
+		}
+		out = d.h.Sum(out)
+	}
+	return out[:outLen]
+}
+
+func (d *HashDRBG) setSeed(seed []byte) {
+	copy(d.v[:], seed)
+	copy(d.c[:], d.df(hashSeedLen, []byte{0x00}, d.v[:]))
+	d.reseedCounter = 1
+}
+
+// Reseed 重播种
+func (d *HashDRBG) Reseed(additional []byte) error {
+	entropy, err := d.getEntropy(hashEntropyLen)
+	if err != nil {
+		return err
+	}
+	d.setSeed(d.df(hashSeedLen, []byte{0x01}, d.v[:], entropy, additional))
+	return nil
+}
+
+// Generate 输出随机数
+func (d *HashDRBG) Generate(out, additional []byte) error {
+	if len(out) > MaxBytesPerRequest {
+		return ErrRequestTooLarge
+	}
+	if d.predictionResistance {
+		if err := d.Reseed(additional); err != nil {
+			return err
+		}
+		additional = nil
+	} else if d.reseedCounter > d.reseedInterval {
+		return ErrReseedRequired
+	}
+
+	if len(additional) > 0 {
+		d.h.Reset()
+		d.h.Write([]byte{0x02})
+		d.h.Write(d.v[:])
+		d.h.Write(additional)
+		addMod(d.v[:], d.h.Sum(nil))
+	}
+
+	d.hashgen(out)
+
+	d.h.Reset()
+	d.h.Write([]byte{0x03})
+	d.h.Write(d.v[:])
+	addMod(d.v[:], d.h.Sum(nil))
+	addMod(d.v[:], d.c[:])
+	addMod(d.v[:], uint64ToBytes(d.reseedCounter))
+	d.reseedCounter++
+	return nil
+}
+
+func (d *HashDRBG) hashgen(out []byte) {
+	var data [hashSeedLen]byte
+	copy(data[:], d.v[:])
+	var block []byte
+	for off := 0; off < len(out); off += len(block) {
+		d.h.Reset()
+		d.h.Write(data[:])
+		block = d.h.Sum(block[:0])
+		copy(out[off:], block)
+		addMod(data[:], []byte{0x01})
+	}
+}
+
+// Read 实现 io.Reader 接口，达到重播种间隔时自动重播种
+func (d *HashDRBG) Read(p []byte) (int, error) {
+	return readAll(d, p)
+}
+
+// ReseedCounter 当前重播种计数器
+func (d *HashDRBG) ReseedCounter() uint64 {
+	return d.reseedCounter
+}
@@ -0,0 +1,296 @@
+package hbs
+
+import (
+	"encoding/asn1"
+	"encoding/binary"
+)
+
+// -----------------------------------------------------------------------------
+// ASN.1 DER 编码
+//
+// RFC 中的签名是定长拼接的字节串，这里按字段展开为 ASN.1 结构，便于和
+// sm2.Signature 一样嵌入到其他 DER 结构中：
+//
+//	LMSSignature ::= SEQUENCE {
+//	    q       INTEGER,
+//	    otsType INTEGER,
+//	    c       OCTET STRING,
+//	    y       SEQUENCE OF OCTET STRING,
+//	    lmsType INTEGER,
+//	    path    SEQUENCE OF OCTET STRING }
+//
+//	HSSSignature ::= SEQUENCE {
+//	    signedPublicKeys SEQUENCE OF SEQUENCE {
+//	        signature LMSSignature,
+//	        publicKey OCTET STRING },
+//	    signature LMSSignature }
+//
+//	XMSSSignature ::= SEQUENCE {
+//	    idx  INTEGER,
+//	    r    OCTET STRING,
+//	    wots SEQUENCE OF OCTET STRING,
+//	    auth SEQUENCE OF OCTET STRING }
+// -----------------------------------------------------------------------------
+
+// LMSSignature LMS 签名的 ASN.1 结构
+type LMSSignature struct {
+	Q       int64
+	OTSType int64
+	C       []byte
+	Y       [][]byte
+	LMSType int64
+	Path    [][]byte
+}
+
+// HSSSignedPublicKey 上层签名和被签名的下层公钥
+type HSSSignedPublicKey struct {
+	Signature LMSSignature
+	PublicKey []byte
+}
+
+// HSSSignature HSS 签名的 ASN.1 结构
+type HSSSignature struct {
+	SignedPublicKeys []HSSSignedPublicKey
+	Signature        LMSSignature
+}
+
+// XMSSSignature XMSS 签名的 ASN.1 结构
+type XMSSSignature struct {
+	Index int64
+	R     []byte
+	WOTS  [][]byte
+	Auth  [][]byte
+}
+
+func splitNodes(data []byte) [][]byte {
+	out := make([][]byte, len(data)/n)
+	for i := range out {
+		out[i] = data[i*n : (i+1)*n]
+	}
+	return out
+}
+
+func joinNodes(nodes [][]byte) ([]byte, bool) {
+	out := make([]byte, 0, len(nodes)*n)
+	for _, node := range nodes {
+		if len(node) != n {
+			return nil, false
+		}
+		out = append(out, node...)
+	}
+	return out, true
+}
+
+// parseLMSSignature 把 5.4 编码的签名展开为 ASN.1 结构
+func parseLMSSignature(sig []byte) (LMSSignature, error) {
+	size, ok := lmsSignatureSize(sig)
+	if !ok || size != len(sig) {
+		return LMSSignature{}, ErrInvalidSignature
+	}
+	otsEnd := 4 + lmotsSignatureSize(LMOTSType(binary.BigEndian.Uint32(sig[4:])))
+	return LMSSignature{
+		Q:       int64(binary.BigEndian.Uint32(sig)),
+		OTSType: int64(binary.BigEndian.Uint32(sig[4:])),
+		C:       sig[8 : 8+n],
+		Y:       splitNodes(sig[8+n : otsEnd]),
+		LMSType: int64(binary.BigEndian.Uint32(sig[otsEnd:])),
+		Path:    splitNodes(sig[otsEnd+4:]),
+	}, nil
+}
+
+// bytes 还原为 5.4 编码
+func (s *LMSSignature) bytes() ([]byte, error) {
+	if len(s.C) != n || s.Q < 0 || s.Q > 0xffffffff ||
+		s.OTSType < 0 || s.OTSType > 0xffffffff || s.LMSType < 0 || s.LMSType > 0xffffffff {
+		return nil, ErrInvalidSignature
+	}
+	y, ok := joinNodes(s.Y)
+	if !ok {
+		return nil, ErrInvalidSignature
+	}
+	path, ok := joinNodes(s.Path)
+	if !ok {
+		return nil, ErrInvalidSignature
+	}
+	out := u32str(uint32(s.Q))
+	out = append(out, u32str(uint32(s.OTSType))...)
+	out = append(out, s.C...)
+	out = append(out, y...)
+	out = append(out, u32str(uint32(s.LMSType))...)
+	out = append(out, path...)
+	if size, ok := lmsSignatureSize(out); !ok || size != len(out) {
+		return nil, ErrInvalidSignature
+	}
+	return out, nil
+}
+
+// MarshalLMSSignatureToASN1DER 把 5.4 编码的 LMS 签名转为 DER
+func MarshalLMSSignatureToASN1DER(sig []byte) ([]byte, error) {
+	s, err := parseLMSSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(s)
+}
+
+// UnmarshalLMSSignatureFromASN1DER 把 DER 编码的 LMS 签名还原为 5.4 编码
+func UnmarshalLMSSignatureFromASN1DER(der []byte) ([]byte, error) {
+	var s LMSSignature
+	rest, err := asn1.Unmarshal(der, &s)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, ErrInvalidSignature
+	}
+	return s.bytes()
+}
+
+// MarshalHSSSignatureToASN1DER 把 6.2 编码的 HSS 签名转为 DER
+func MarshalHSSSignatureToASN1DER(sig []byte) ([]byte, error) {
+	if len(sig) < 4 {
+		return nil, ErrInvalidSignature
+	}
+	sigs, pubs, ok := splitHSSSignature(sig, int(binary.BigEndian.Uint32(sig))+1)
+	if !ok {
+		return nil, ErrInvalidSignature
+	}
+	var s HSSSignature
+	for i, pub := range pubs {
+		ls, err := parseLMSSignature(sigs[i])
+		if err != nil {
+			return nil, err
+		}
+		s.SignedPublicKeys = append(s.SignedPublicKeys, HSSSignedPublicKey{Signature: ls, PublicKey: pub})
+	}
+	last, err := parseLMSSignature(sigs[len(sigs)-1])
+	if err != nil {
+		return nil, err
+	}
+	s.Signature = last
+	return asn1.Marshal(s)
+}
+
+// UnmarshalHSSSignatureFromASN1DER 把 DER 编码的 HSS 签名还原为 6.2 编码
+func UnmarshalHSSSignatureFromASN1DER(der []byte) ([]byte, error) {
+	var s HSSSignature
+	rest, err := asn1.Unmarshal(der, &s)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 || len(s.SignedPublicKeys) >= HSSMaxLevels {
+		return nil, ErrInvalidSignature
+	}
+	out := u32str(uint32(len(s.SignedPublicKeys)))
+	for _, spk := range s.SignedPublicKeys {
+		b, err := spk.Signature.bytes()
+		if err != nil {
+			return nil, err
+		}
+		if len(spk.PublicKey) != 8+idSize+n {
+			return nil, ErrInvalidSignature
+		}
+		out = append(out, b...)
+		out = append(out, spk.PublicKey...)
+	}
+	b, err := s.Signature.bytes()
+	if err != nil {
+		return nil, err
+	}
+	return append(out, b...), nil
+}
+
+// MarshalXMSSSignatureToASN1DER 把 4.1.8 编码的 XMSS 签名转为 DER
+func MarshalXMSSSignatureToASN1DER(sig []byte, typ XMSSType) ([]byte, error) {
+	h, ok := xmssHeights[typ]
+	if !ok {
+		return nil, ErrInvalidParams
+	}
+	if len(sig) != xmssSignatureSize(h) {
+		return nil, ErrInvalidSignature
+	}
+	return asn1.Marshal(XMSSSignature{
+		Index: int64(binary.BigEndian.Uint32(sig)),
+		R:     sig[4 : 4+n],
+		WOTS:  splitNodes(sig[4+n : 4+n+wotsLen*n]),
+		Auth:  splitNodes(sig[4+n+wotsLen*n:]),
+	})
+}
+
+// UnmarshalXMSSSignatureFromASN1DER 把 DER 编码的 XMSS 签名还原为 4.1.8 编码
+func UnmarshalXMSSSignatureFromASN1DER(der []byte, typ XMSSType) ([]byte, error) {
+	h, ok := xmssHeights[typ]
+	if !ok {
+		return nil, ErrInvalidParams
+	}
+	var s XMSSSignature
+	rest, err := asn1.Unmarshal(der, &s)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 || s.Index < 0 || s.Index >= int64(1)<<h ||
+		len(s.R) != n || len(s.WOTS) != wotsLen || len(s.Auth) != int(h) {
+		return nil, ErrInvalidSignature
+	}
+	wots, ok := joinNodes(s.WOTS)
+	if !ok {
+		return nil, ErrInvalidSignature
+	}
+	auth, ok := joinNodes(s.Auth)
+	if !ok {
+		return nil, ErrInvalidSignature
+	}
+	out := u32str(uint32(s.Index))
+	out = append(out, s.R...)
+	out = append(out, wots...)
+	return append(out, auth...), nil
+}
+
+// -----------------------------------------------------------------------------
+// 与 sm2 一致的 DER 签名和验证接口
+// -----------------------------------------------------------------------------
+
+// SignToASN1DER 签名并输出 DER 编码
+func (sk *LMSPrivateKey) SignToASN1DER(msg []byte) ([]byte, error) {
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalLMSSignatureToASN1DER(sig)
+}
+
+// VerifyFromASN1DER 验证 DER 编码的签名
+func (pk *LMSPublicKey) VerifyFromASN1DER(msg, der []byte) bool {
+	sig, err := UnmarshalLMSSignatureFromASN1DER(der)
+	return err == nil && pk.Verify(msg, sig)
+}
+
+// SignToASN1DER 签名并输出 DER 编码
+func (sk *HSSPrivateKey) SignToASN1DER(msg []byte) ([]byte, error) {
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalHSSSignatureToASN1DER(sig)
+}
+
+// VerifyFromASN1DER 验证 DER 编码的签名
+func (pk *HSSPublicKey) VerifyFromASN1DER(msg, der []byte) bool {
+	sig, err := UnmarshalHSSSignatureFromASN1DER(der)
+	return err == nil && pk.Verify(msg, sig)
+}
+
+// SignToASN1DER 签名并输出 DER 编码
+func (sk *XMSSPrivateKey) SignToASN1DER(msg []byte) ([]byte, error) {
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalXMSSSignatureToASN1DER(sig, sk.Type)
+}
+
+// VerifyFromASN1DER 验证 DER 编码的签名
+func (pk *XMSSPublicKey) VerifyFromASN1DER(msg, der []byte) bool {
+	sig, err := UnmarshalXMSSSignatureFromASN1DER(der, pk.Type)
+	return err == nil && pk.Verify(msg, sig)
+}
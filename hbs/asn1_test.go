@@ -0,0 +1,68 @@
+package hbs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestLMSSignatureASN1DER(t *testing.T) {
+	sk, err := GenerateLMSKey(rand.Reader, LMSSM3M32H5, LMOTSSM3N32W4, NewMemoryStore(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := sk.Public()
+	der, err := sk.SignToASN1DER([]byte("msg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.VerifyFromASN1DER([]byte("msg"), der) {
+		t.Error("TestLMSSignatureASN1DER 失败")
+	}
+	raw, _ := UnmarshalLMSSignatureFromASN1DER(der)
+	again, _ := MarshalLMSSignatureToASN1DER(raw)
+	if !bytes.Equal(der, again) {
+		t.Errorf(`TestLMSSignatureASN1DER 失败
+期望值=%x
+实际值=%x`, der, again)
+	}
+	if pk.VerifyFromASN1DER([]byte("msg"), der[:len(der)-1]) {
+		t.Error("TestLMSSignatureASN1DER 失败: 截断签名通过验证")
+	}
+}
+
+func TestHSSSignatureASN1DER(t *testing.T) {
+	sk, err := GenerateHSSKey(rand.Reader, testHSSParams, NewMemoryStore(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := sk.Public()
+	der, err := sk.SignToASN1DER([]byte("msg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.VerifyFromASN1DER([]byte("msg"), der) {
+		t.Error("TestHSSSignatureASN1DER 失败")
+	}
+	if pk.VerifyFromASN1DER([]byte("other"), der) {
+		t.Error("TestHSSSignatureASN1DER 失败: 篡改消息通过验证")
+	}
+}
+
+func TestXMSSSignatureASN1DER(t *testing.T) {
+	sk, err := GenerateXMSSKey(rand.Reader, xmssSM3H4, NewMemoryStore(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := sk.Public()
+	der, err := sk.SignToASN1DER([]byte("msg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.VerifyFromASN1DER([]byte("msg"), der) {
+		t.Error("TestXMSSSignatureASN1DER 失败")
+	}
+	if _, err := UnmarshalXMSSSignatureFromASN1DER(der, XMSSSM3H10); err == nil {
+		t.Error("TestXMSSSignatureASN1DER 失败: 参数集不符")
+	}
+}
@@ -0,0 +1,279 @@
+package hbs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+// -----------------------------------------------------------------------------
+// RFC 8554 6 分层签名 HSS
+//
+// 只有顶层树的 I 和 SEED 需要保存，下层树由父树的 SEED 和父树叶子序号确定地
+// 派生。全部层共用一个 64 比特计数器，从高位到低位依次是各层的叶子序号，下层树
+// 用完之后自然切换到父树的下一个叶子
+// -----------------------------------------------------------------------------
+
+// HSSMaxLevels 6.1 HSS 最大层数
+const HSSMaxLevels = 8
+
+// LMSParams 一层 LMS 树的参数
+type LMSParams struct {
+	Type    LMSType
+	OTSType LMOTSType
+}
+
+// HSSPublicKey 6.1 HSS 公钥
+type HSSPublicKey struct {
+	Levels int
+	LMS    *LMSPublicKey
+}
+
+// Bytes 6.1 公钥编码 u32str(L) || pub[0]
+func (pk *HSSPublicKey) Bytes() []byte {
+	return append(u32str(uint32(pk.Levels)), pk.LMS.Bytes()...)
+}
+
+// Equal 比较两个公钥
+func (pk *HSSPublicKey) Equal(other *HSSPublicKey) bool {
+	return bytes.Equal(pk.Bytes(), other.Bytes())
+}
+
+// ParseHSSPublicKey 解析 6.1 编码的公钥
+func ParseHSSPublicKey(data []byte) (*HSSPublicKey, error) {
+	if len(data) < 4 {
+		return nil, ErrInvalidPublicKey
+	}
+	levels := binary.BigEndian.Uint32(data)
+	if levels < 1 || levels > HSSMaxLevels {
+		return nil, ErrInvalidPublicKey
+	}
+	lms, err := ParseLMSPublicKey(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	return &HSSPublicKey{Levels: int(levels), LMS: lms}, nil
+}
+
+// splitHSSSignature 6.3 拆分签名，返回各层签名和下层公钥
+func splitHSSSignature(sig []byte, levels int) (sigs [][]byte, pubs [][]byte, ok bool) {
+	if len(sig) < 4 {
+		return nil, nil, false
+	}
+	nspk := binary.BigEndian.Uint32(sig)
+	if int(nspk)+1 != levels {
+		return nil, nil, false
+	}
+	rest := sig[4:]
+	for i := 0; i <= int(nspk); i++ {
+		size, ok := lmsSignatureSize(rest)
+		if !ok || size > len(rest) {
+			return nil, nil, false
+		}
+		sigs = append(sigs, rest[:size])
+		rest = rest[size:]
+		if i == int(nspk) {
+			break
+		}
+		if len(rest) < 8+idSize+n {
+			return nil, nil, false
+		}
+		pubs = append(pubs, rest[:8+idSize+n])
+		rest = rest[8+idSize+n:]
+	}
+	if len(rest) != 0 {
+		return nil, nil, false
+	}
+	return sigs, pubs, true
+}
+
+// Verify 6.3 验证 HSS 签名
+func (pk *HSSPublicKey) Verify(msg, sig []byte) bool {
+	sigs, pubs, ok := splitHSSSignature(sig, pk.Levels)
+	if !ok {
+		return false
+	}
+	key := pk.LMS
+	for i, pub := range pubs {
+		if !key.Verify(pub, sigs[i]) {
+			return false
+		}
+		next, err := ParseLMSPublicKey(pub)
+		if err != nil {
+			return false
+		}
+		key = next
+	}
+	return key.Verify(msg, sigs[len(sigs)-1])
+}
+
+// HSSPrivateKey HSS 私钥
+type HSSPrivateKey struct {
+	Params []LMSParams
+	I      []byte
+	Seed   []byte
+
+	state counter
+	mu    sync.Mutex
+	// trees[i] 为当前使用的第 i 层树，paths[i] 为其在计数器中的前缀
+	trees []*lmsTree
+	paths []uint64
+}
+
+func checkHSSParams(params []LMSParams) (uint, error) {
+	if len(params) < 1 || len(params) > HSSMaxLevels {
+		return 0, ErrInvalidParams
+	}
+	var total uint
+	for _, p := range params {
+		h, err := checkSigningParams(p.Type, p.OTSType)
+		if err != nil {
+			return 0, err
+		}
+		total += h
+	}
+	if total > 64 {
+		return 0, ErrInvalidParams
+	}
+	return total, nil
+}
+
+// GenerateHSSKey 生成 HSS 私钥，params[0] 为顶层
+func GenerateHSSKey(rand io.Reader, params []LMSParams, store StateStore) (*HSSPrivateKey, error) {
+	if _, err := checkHSSParams(params); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, idSize+n)
+	if _, err := io.ReadFull(rand, buf); err != nil {
+		return nil, err
+	}
+	return newHSSPrivateKey(params, buf[:idSize], buf[idSize:], store)
+}
+
+func newHSSPrivateKey(params []LMSParams, id, seed []byte, store StateStore) (*HSSPrivateKey, error) {
+	total, err := checkHSSParams(params)
+	if err != nil {
+		return nil, err
+	}
+	if len(id) != idSize || len(seed) != n {
+		return nil, ErrInvalidKey
+	}
+	max := ^uint64(0)
+	if total < 64 {
+		max = uint64(1) << total
+	}
+	return &HSSPrivateKey{
+		Params: append([]LMSParams(nil), params...),
+		I:      append([]byte(nil), id...),
+		Seed:   append([]byte(nil), seed...),
+		state:  counter{store: store, max: max},
+		trees:  make([]*lmsTree, len(params)),
+		paths:  make([]uint64, len(params)),
+	}, nil
+}
+
+// MarshalBinary 私钥编码 u32str(L) || (u32str(type) || u32str(otstype))*L || I || SEED，
+// 不含状态
+func (sk *HSSPrivateKey) MarshalBinary() ([]byte, error) {
+	out := u32str(uint32(len(sk.Params)))
+	for _, p := range sk.Params {
+		out = append(out, u32str(uint32(p.Type))...)
+		out = append(out, u32str(uint32(p.OTSType))...)
+	}
+	out = append(out, sk.I...)
+	return append(out, sk.Seed...), nil
+}
+
+// ParseHSSPrivateKey 解析 MarshalBinary 的输出，并与 store 关联
+func ParseHSSPrivateKey(data []byte, store StateStore) (*HSSPrivateKey, error) {
+	if len(data) < 4 {
+		return nil, ErrInvalidKey
+	}
+	levels := int(binary.BigEndian.Uint32(data))
+	if levels < 1 || levels > HSSMaxLevels || len(data) != 4+8*levels+idSize+n {
+		return nil, ErrInvalidKey
+	}
+	params := make([]LMSParams, levels)
+	for i := range params {
+		params[i].Type = LMSType(binary.BigEndian.Uint32(data[4+8*i:]))
+		params[i].OTSType = LMOTSType(binary.BigEndian.Uint32(data[8+8*i:]))
+	}
+	off := 4 + 8*levels
+	return newHSSPrivateKey(params, data[off:off+idSize], data[off+idSize:], store)
+}
+
+// indices 把计数器拆成各层的叶子序号
+func (sk *HSSPrivateKey) indices(c uint64) []uint32 {
+	qs := make([]uint32, len(sk.Params))
+	for i := len(sk.Params) - 1; i >= 0; i-- {
+		h := lmsHeights[sk.Params[i].Type]
+		qs[i] = uint32(c & (uint64(1)<<h - 1))
+		c >>= h
+	}
+	return qs
+}
+
+// tree 返回计数器 c 对应的第 level 层树，必要时从父树派生
+func (sk *HSSPrivateKey) tree(level int, c uint64) *lmsTree {
+	var shift uint
+	for i := level; i < len(sk.Params); i++ {
+		shift += lmsHeights[sk.Params[i].Type]
+	}
+	var path uint64
+	if shift < 64 {
+		path = c >> shift
+	}
+	if t := sk.trees[level]; t != nil && sk.paths[level] == path {
+		return t
+	}
+
+	p := sk.Params[level]
+	var t *lmsTree
+	if level == 0 {
+		t = newLMSTree(p.Type, p.OTSType, sk.I, sk.Seed)
+	} else {
+		parent := sk.tree(level-1, c)
+		q := u32str(sk.indices(c)[level-1])
+		seed := hashAll(parent.id, q, u16str(dCSEED), []byte{dPRIV}, parent.seed)
+		id := hashAll(parent.id, q, u16str(dCID), []byte{dPRIV}, parent.seed)[:idSize]
+		t = newLMSTree(p.Type, p.OTSType, id, seed)
+	}
+	sk.trees[level] = t
+	sk.paths[level] = path
+	return t
+}
+
+// Public 返回公钥，第一次调用时计算顶层树
+func (sk *HSSPrivateKey) Public() *HSSPublicKey {
+	sk.mu.Lock()
+	defer sk.mu.Unlock()
+	return &HSSPublicKey{Levels: len(sk.Params), LMS: sk.tree(0, 0).public()}
+}
+
+// Remaining 剩余可签名次数
+func (sk *HSSPrivateKey) Remaining() (uint64, error) {
+	return sk.state.remaining()
+}
+
+// Sign 6.2 分配一个新的计数器值并签名
+//
+// 下层公钥的签名由父树确定地生成，同一个父树叶子总是签同一个下层公钥
+func (sk *HSSPrivateKey) Sign(msg []byte) ([]byte, error) {
+	c, err := sk.state.reserve()
+	if err != nil {
+		return nil, err
+	}
+	sk.mu.Lock()
+	defer sk.mu.Unlock()
+
+	qs := sk.indices(c)
+	levels := len(sk.Params)
+	sig := u32str(uint32(levels - 1))
+	for i := 0; i < levels-1; i++ {
+		child := sk.tree(i+1, c).public().Bytes()
+		sig = append(sig, sk.tree(i, c).sign(qs[i], child)...)
+		sig = append(sig, child...)
+	}
+	return append(sig, sk.tree(levels-1, c).sign(qs[levels-1], msg)...), nil
+}
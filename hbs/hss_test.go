@@ -0,0 +1,82 @@
+package hbs
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+var testHSSParams = []LMSParams{
+	{Type: LMSSM3M32H5, OTSType: LMOTSSM3N32W8},
+	{Type: LMSSM3M32H5, OTSType: LMOTSSM3N32W4},
+}
+
+func TestHSSSignVerify(t *testing.T) {
+	sk, err := GenerateHSSKey(rand.Reader, testHSSParams, NewMemoryStore(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := sk.Public()
+	// 第 32 次签名时切换到新的下层树
+	for i := 30; i < 34; i++ {
+		msg := []byte{byte(i)}
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pk.Verify(msg, sig) {
+			t.Errorf("TestHSSSignVerify 失败: i=%d", i)
+		}
+		if pk.Verify([]byte("tampered"), sig) {
+			t.Errorf("TestHSSSignVerify 失败: 篡改消息通过验证 i=%d", i)
+		}
+	}
+	if left, _ := sk.Remaining(); left != 1024-34 {
+		t.Errorf("TestHSSSignVerify 失败: remaining=%d", left)
+	}
+}
+
+func TestHSSDeterministicChildren(t *testing.T) {
+	sk, err := GenerateHSSKey(rand.Reader, testHSSParams, NewMemoryStore(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := sk.Sign([]byte("a"))
+
+	data, _ := sk.MarshalBinary()
+	restored, err := ParseHSSPrivateKey(data, NewMemoryStore(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := restored.Sign([]byte("b"))
+
+	// 同一个父树叶子签的下层公钥及其签名必须完全相同
+	sigs1, pubs1, _ := splitHSSSignature(first, 2)
+	sigs2, pubs2, _ := splitHSSSignature(second, 2)
+	if string(pubs1[0]) != string(pubs2[0]) || string(sigs1[0]) != string(sigs2[0]) {
+		t.Error("TestHSSDeterministicChildren 失败")
+	}
+	if !restored.Public().Verify([]byte("b"), second) {
+		t.Error("TestHSSDeterministicChildren 失败: 验证")
+	}
+}
+
+func TestHSSPublicKeyEncoding(t *testing.T) {
+	sk, err := GenerateHSSKey(rand.Reader, testHSSParams[:1], NewMemoryStore(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := sk.Public()
+	parsed, err := ParseHSSPublicKey(pk.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _ := sk.Sign([]byte("single level"))
+	if !parsed.Equal(pk) || !parsed.Verify([]byte("single level"), sig) {
+		t.Error("TestHSSPublicKeyEncoding 失败")
+	}
+	// 层数与签名不符
+	parsed.Levels = 2
+	if parsed.Verify([]byte("single level"), sig) {
+		t.Error("TestHSSPublicKeyEncoding 失败: 层数不符通过验证")
+	}
+}
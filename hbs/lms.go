@@ -0,0 +1,504 @@
+package hbs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+// -----------------------------------------------------------------------------
+// RFC 8554 Leighton-Micali 签名，以 SM3 代替 SHA-256
+//
+// SM3 没有 IANA 分配的类型编号，这里使用 RFC 8554 中保留给私有用途的
+// 0xDDDDDDDD-0xFFFFFFFF 区间，低位与对应的 SHA-256 参数集保持一致
+// -----------------------------------------------------------------------------
+
+// n 杂凑输出长度
+const n = sm3.DigestSizeInByte
+
+// idSize RFC 8554 中 I 的长度
+const idSize = 16
+
+// 域分隔常量
+const (
+	dPBLC = 0x8080
+	dMESG = 0x8181
+	dLEAF = 0x8282
+	dINTR = 0x8383
+
+	// 以下用于从 SEED 伪随机地派生数据，不会与上面的常量冲突
+	dPRIV  = 0xff
+	dRAND  = 0xfffd
+	dCSEED = 0xfffe
+	dCID   = 0xffff
+)
+
+// LMOTSType 4.1 LM-OTS 参数集
+type LMOTSType uint32
+
+const (
+	LMOTSSM3N32W1 LMOTSType = 0xe0000001
+	LMOTSSM3N32W2 LMOTSType = 0xe0000002
+	LMOTSSM3N32W4 LMOTSType = 0xe0000003
+	LMOTSSM3N32W8 LMOTSType = 0xe0000004
+)
+
+// LMSType 5.1 LMS 参数集
+type LMSType uint32
+
+const (
+	LMSSM3M32H5  LMSType = 0xe0000005
+	LMSSM3M32H10 LMSType = 0xe0000006
+	LMSSM3M32H15 LMSType = 0xe0000007
+	LMSSM3M32H20 LMSType = 0xe0000008
+	LMSSM3M32H25 LMSType = 0xe0000009
+)
+
+var (
+	ErrInvalidParams    = errors.New("hbs: unsupported parameter set")
+	ErrInvalidPublicKey = errors.New("hbs: invalid public key")
+	ErrInvalidKey       = errors.New("hbs: invalid private key")
+	ErrInvalidSignature = errors.New("hbs: invalid signature encoding")
+)
+
+type lmotsParams struct {
+	w  uint // Winternitz 参数
+	p  int  // 链的个数
+	ls uint // 校验和左移位数
+}
+
+var lmotsParamSets = map[LMOTSType]lmotsParams{
+	LMOTSSM3N32W1: {w: 1, p: 265, ls: 7},
+	LMOTSSM3N32W2: {w: 2, p: 133, ls: 6},
+	LMOTSSM3N32W4: {w: 4, p: 67, ls: 4},
+	LMOTSSM3N32W8: {w: 8, p: 34, ls: 0},
+}
+
+// maxSigningHeight 私钥在内存中计算并保存整棵树，h = 25 需要 2^26 个节点（约
+// 2 GiB）和约 3 × 10^10 次 SM3，因此 LMSSM3M32H25 只能用于验证
+const maxSigningHeight = 20
+
+var lmsHeights = map[LMSType]uint{
+	LMSSM3M32H5:  5,
+	LMSSM3M32H10: 10,
+	LMSSM3M32H15: 15,
+	LMSSM3M32H20: 20,
+	LMSSM3M32H25: 25,
+}
+
+func u32str(v uint32) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return b[:]
+}
+
+func u16str(v uint16) []byte {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], v)
+	return b[:]
+}
+
+func hashAll(parts ...[]byte) []byte {
+	h := sm3.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// -----------------------------------------------------------------------------
+// 4 LM-OTS 一次性签名
+// -----------------------------------------------------------------------------
+
+// coef 4.4 取 S 的第 i 个 w 比特
+func coef(s []byte, i int, w uint) uint {
+	perByte := 8 / int(w)
+	b := s[i/perByte]
+	shift := 8 - (w*uint(i%perByte) + w)
+	return uint(b>>shift) & (1<<w - 1)
+}
+
+// checksum 4.4 Cksm(S)
+func (p lmotsParams) checksum(s []byte) []byte {
+	var sum uint
+	for i := 0; i < n*8/int(p.w); i++ {
+		sum += 1<<p.w - 1 - coef(s, i, p.w)
+	}
+	return u16str(uint16(sum << p.ls))
+}
+
+// digits Q || Cksm(Q) 展开为 p 个 w 比特数字
+func (p lmotsParams) digits(q []byte) []uint {
+	qc := append(append([]byte(nil), q...), p.checksum(q)...)
+	out := make([]uint, p.p)
+	for i := range out {
+		out[i] = coef(qc, i, p.w)
+	}
+	return out
+}
+
+// chain 从 x 开始按 j = from, ..., to-1 迭代
+func chain(id []byte, q uint32, i int, x []byte, from, to uint) []byte {
+	tmp := x
+	for j := from; j < to; j++ {
+		tmp = hashAll(id, u32str(q), u16str(uint16(i)), []byte{byte(j)}, tmp)
+	}
+	return tmp
+}
+
+// lmotsPrivate 附录 A 从 SEED 伪随机地生成第 q 个一次性私钥
+func lmotsPrivate(p lmotsParams, id, seed []byte, q uint32) [][]byte {
+	x := make([][]byte, p.p)
+	for i := range x {
+		x[i] = hashAll(id, u32str(q), u16str(uint16(i)), []byte{dPRIV}, seed)
+	}
+	return x
+}
+
+// lmotsPublic 4.3 一次性公钥 K
+func lmotsPublic(p lmotsParams, id, seed []byte, q uint32) []byte {
+	x := lmotsPrivate(p, id, seed, q)
+	h := sm3.New()
+	h.Write(id)
+	h.Write(u32str(q))
+	h.Write(u16str(dPBLC))
+	for i := range x {
+		h.Write(chain(id, q, i, x[i], 0, 1<<p.w-1))
+	}
+	return h.Sum(nil)
+}
+
+// lmotsSign 4.5 一次性签名，C 由 SEED 确定地派生，同一序号对同一消息总是给出
+// 相同的签名
+func lmotsSign(typ LMOTSType, id, seed []byte, q uint32, msg []byte) []byte {
+	p := lmotsParamSets[typ]
+	c := hashAll(id, u32str(q), u16str(dRAND), []byte{dPRIV}, seed)
+	digest := hashAll(id, u32str(q), u16str(dMESG), c, msg)
+	a := p.digits(digest)
+	x := lmotsPrivate(p, id, seed, q)
+
+	sig := make([]byte, 0, 4+n+p.p*n)
+	sig = append(sig, u32str(uint32(typ))...)
+	sig = append(sig, c...)
+	for i := range x {
+		sig = append(sig, chain(id, q, i, x[i], 0, a[i])...)
+	}
+	return sig
+}
+
+// lmotsSignatureSize 一次性签名长度
+func lmotsSignatureSize(typ LMOTSType) int {
+	return 4 + n + lmotsParamSets[typ].p*n
+}
+
+// lmotsCandidate 4.6 由签名计算候选公钥 Kc
+func lmotsCandidate(sig, id []byte, q uint32, msg []byte, expected LMOTSType) ([]byte, bool) {
+	if len(sig) < 4 {
+		return nil, false
+	}
+	typ := LMOTSType(binary.BigEndian.Uint32(sig))
+	p, ok := lmotsParamSets[typ]
+	if !ok || typ != expected || len(sig) != lmotsSignatureSize(typ) {
+		return nil, false
+	}
+	c := sig[4 : 4+n]
+	digest := hashAll(id, u32str(q), u16str(dMESG), c, msg)
+	a := p.digits(digest)
+
+	h := sm3.New()
+	h.Write(id)
+	h.Write(u32str(q))
+	h.Write(u16str(dPBLC))
+	for i := 0; i < p.p; i++ {
+		y := sig[4+n+i*n : 4+n+(i+1)*n]
+		h.Write(chain(id, q, i, y, a[i], 1<<p.w-1))
+	}
+	return h.Sum(nil), true
+}
+
+// -----------------------------------------------------------------------------
+// 5 LMS 签名
+// -----------------------------------------------------------------------------
+
+// lmsTree 一棵 LMS 树，nodes[r] 为 5.3 中的 T[r]，r 从 1 开始
+type lmsTree struct {
+	typ    LMSType
+	otsTyp LMOTSType
+	h      uint
+	id     []byte
+	seed   []byte
+	nodes  [][]byte
+}
+
+func checkLMSParams(typ LMSType, otsTyp LMOTSType) (uint, error) {
+	h, ok := lmsHeights[typ]
+	if !ok {
+		return 0, ErrInvalidParams
+	}
+	if _, ok := lmotsParamSets[otsTyp]; !ok {
+		return 0, ErrInvalidParams
+	}
+	return h, nil
+}
+
+// checkSigningParams 私钥的参数集，高度不能超过 maxSigningHeight
+func checkSigningParams(typ LMSType, otsTyp LMOTSType) (uint, error) {
+	h, err := checkLMSParams(typ, otsTyp)
+	if err != nil {
+		return 0, err
+	}
+	if h > maxSigningHeight {
+		return 0, ErrInvalidParams
+	}
+	return h, nil
+}
+
+// newLMSTree 计算整棵树，叶子的一次性公钥并行计算
+func newLMSTree(typ LMSType, otsTyp LMOTSType, id, seed []byte) *lmsTree {
+	h := lmsHeights[typ]
+	p := lmotsParamSets[otsTyp]
+	t := &lmsTree{typ: typ, otsTyp: otsTyp, h: h, id: id, seed: seed}
+	leaves := uint32(1) << h
+	t.nodes = make([][]byte, 2*leaves)
+
+	parallel(int(leaves), func(q int) {
+		r := leaves + uint32(q)
+		k := lmotsPublic(p, id, seed, uint32(q))
+		t.nodes[r] = hashAll(id, u32str(r), u16str(dLEAF), k)
+	})
+	for r := leaves - 1; r >= 1; r-- {
+		t.nodes[r] = hashAll(id, u32str(r), u16str(dINTR), t.nodes[2*r], t.nodes[2*r+1])
+	}
+	return t
+}
+
+// parallel 以 CPU 个数的 goroutine 执行 f(0), ..., f(count-1)
+func parallel(count int, f func(i int)) {
+	workers := runtime.NumCPU()
+	if workers > count {
+		workers = count
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < count; i += workers {
+				f(i)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+func (t *lmsTree) public() *LMSPublicKey {
+	return &LMSPublicKey{
+		Type:    t.typ,
+		OTSType: t.otsTyp,
+		I:       append([]byte(nil), t.id...),
+		T1:      append([]byte(nil), t.nodes[1]...),
+	}
+}
+
+// sign 5.4.1 以第 q 个叶子签名
+func (t *lmsTree) sign(q uint32, msg []byte) []byte {
+	sig := make([]byte, 0, 4+lmotsSignatureSize(t.otsTyp)+4+int(t.h)*n)
+	sig = append(sig, u32str(q)...)
+	sig = append(sig, lmotsSign(t.otsTyp, t.id, t.seed, q, msg)...)
+	sig = append(sig, u32str(uint32(t.typ))...)
+	r := uint32(1)<<t.h + q
+	for i := uint(0); i < t.h; i++ {
+		sig = append(sig, t.nodes[(r>>i)^1]...)
+	}
+	return sig
+}
+
+// LMSPublicKey 5.3 LMS 公钥
+type LMSPublicKey struct {
+	Type    LMSType
+	OTSType LMOTSType
+	I       []byte
+	T1      []byte
+}
+
+// Bytes 5.3 公钥编码 u32str(type) || u32str(otstype) || I || T[1]
+func (pk *LMSPublicKey) Bytes() []byte {
+	out := make([]byte, 0, 8+idSize+n)
+	out = append(out, u32str(uint32(pk.Type))...)
+	out = append(out, u32str(uint32(pk.OTSType))...)
+	out = append(out, pk.I...)
+	return append(out, pk.T1...)
+}
+
+// Equal 比较两个公钥
+func (pk *LMSPublicKey) Equal(other *LMSPublicKey) bool {
+	return bytes.Equal(pk.Bytes(), other.Bytes())
+}
+
+// ParseLMSPublicKey 解析 5.3 编码的公钥
+func ParseLMSPublicKey(data []byte) (*LMSPublicKey, error) {
+	if len(data) != 8+idSize+n {
+		return nil, ErrInvalidPublicKey
+	}
+	pk := &LMSPublicKey{
+		Type:    LMSType(binary.BigEndian.Uint32(data)),
+		OTSType: LMOTSType(binary.BigEndian.Uint32(data[4:])),
+		I:       append([]byte(nil), data[8:8+idSize]...),
+		T1:      append([]byte(nil), data[8+idSize:]...),
+	}
+	if _, err := checkLMSParams(pk.Type, pk.OTSType); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// lmsSignatureSize 由签名头部得到整个 LMS 签名的长度
+func lmsSignatureSize(sig []byte) (int, bool) {
+	if len(sig) < 8 {
+		return 0, false
+	}
+	otsTyp := LMOTSType(binary.BigEndian.Uint32(sig[4:]))
+	if _, ok := lmotsParamSets[otsTyp]; !ok {
+		return 0, false
+	}
+	off := 4 + lmotsSignatureSize(otsTyp)
+	if len(sig) < off+4 {
+		return 0, false
+	}
+	h, ok := lmsHeights[LMSType(binary.BigEndian.Uint32(sig[off:]))]
+	if !ok {
+		return 0, false
+	}
+	return off + 4 + int(h)*n, true
+}
+
+// Verify 5.4.2 验证 LMS 签名
+func (pk *LMSPublicKey) Verify(msg, sig []byte) bool {
+	h, err := checkLMSParams(pk.Type, pk.OTSType)
+	if err != nil || len(pk.I) != idSize || len(pk.T1) != n {
+		return false
+	}
+	size, ok := lmsSignatureSize(sig)
+	if !ok || size != len(sig) {
+		return false
+	}
+	q := binary.BigEndian.Uint32(sig)
+	otsEnd := 4 + lmotsSignatureSize(pk.OTSType)
+	if otsEnd+4 > len(sig) || LMSType(binary.BigEndian.Uint32(sig[otsEnd:])) != pk.Type {
+		return false
+	}
+	if uint64(q) >= uint64(1)<<h {
+		return false
+	}
+	kc, ok := lmotsCandidate(sig[4:otsEnd], pk.I, q, msg, pk.OTSType)
+	if !ok {
+		return false
+	}
+	path := sig[otsEnd+4:]
+
+	r := uint32(1)<<h + q
+	tmp := hashAll(pk.I, u32str(r), u16str(dLEAF), kc)
+	for i := 0; r > 1; i++ {
+		sibling := path[i*n : (i+1)*n]
+		if r&1 == 1 {
+			tmp = hashAll(pk.I, u32str(r/2), u16str(dINTR), sibling, tmp)
+		} else {
+			tmp = hashAll(pk.I, u32str(r/2), u16str(dINTR), tmp, sibling)
+		}
+		r /= 2
+	}
+	return bytes.Equal(tmp, pk.T1)
+}
+
+// LMSPrivateKey LMS 私钥，一次性签名序号保存在 StateStore 中
+type LMSPrivateKey struct {
+	Type    LMSType
+	OTSType LMOTSType
+	I       []byte
+	Seed    []byte
+
+	state counter
+	once  sync.Once
+	tree  *lmsTree
+}
+
+// GenerateLMSKey 生成 LMS 私钥，store 中的序号从 0 开始使用
+func GenerateLMSKey(rand io.Reader, typ LMSType, otsTyp LMOTSType, store StateStore) (*LMSPrivateKey, error) {
+	if _, err := checkSigningParams(typ, otsTyp); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, idSize+n)
+	if _, err := io.ReadFull(rand, buf); err != nil {
+		return nil, err
+	}
+	return newLMSPrivateKey(typ, otsTyp, buf[:idSize], buf[idSize:], store)
+}
+
+func newLMSPrivateKey(typ LMSType, otsTyp LMOTSType, id, seed []byte, store StateStore) (*LMSPrivateKey, error) {
+	h, err := checkSigningParams(typ, otsTyp)
+	if err != nil {
+		return nil, err
+	}
+	if len(id) != idSize || len(seed) != n {
+		return nil, ErrInvalidKey
+	}
+	return &LMSPrivateKey{
+		Type:    typ,
+		OTSType: otsTyp,
+		I:       append([]byte(nil), id...),
+		Seed:    append([]byte(nil), seed...),
+		state:   counter{store: store, max: uint64(1) << h},
+	}, nil
+}
+
+// MarshalBinary 私钥编码 u32str(type) || u32str(otstype) || I || SEED，不含状态
+func (sk *LMSPrivateKey) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 0, 8+idSize+n)
+	out = append(out, u32str(uint32(sk.Type))...)
+	out = append(out, u32str(uint32(sk.OTSType))...)
+	out = append(out, sk.I...)
+	return append(out, sk.Seed...), nil
+}
+
+// ParseLMSPrivateKey 解析 MarshalBinary 的输出，并与 store 关联
+func ParseLMSPrivateKey(data []byte, store StateStore) (*LMSPrivateKey, error) {
+	if len(data) != 8+idSize+n {
+		return nil, ErrInvalidKey
+	}
+	return newLMSPrivateKey(
+		LMSType(binary.BigEndian.Uint32(data)),
+		LMOTSType(binary.BigEndian.Uint32(data[4:])),
+		data[8:8+idSize], data[8+idSize:], store)
+}
+
+func (sk *LMSPrivateKey) lmsTree() *lmsTree {
+	sk.once.Do(func() {
+		sk.tree = newLMSTree(sk.Type, sk.OTSType, sk.I, sk.Seed)
+	})
+	return sk.tree
+}
+
+// Public 返回公钥，第一次调用时计算整棵树
+func (sk *LMSPrivateKey) Public() *LMSPublicKey {
+	return sk.lmsTree().public()
+}
+
+// Remaining 剩余可签名次数
+func (sk *LMSPrivateKey) Remaining() (uint64, error) {
+	return sk.state.remaining()
+}
+
+// Sign 分配一个新的序号并签名，签名为 5.4 编码
+func (sk *LMSPrivateKey) Sign(msg []byte) ([]byte, error) {
+	t := sk.lmsTree()
+	q, err := sk.state.reserve()
+	if err != nil {
+		return nil, err
+	}
+	return t.sign(uint32(q), msg), nil
+}
@@ -0,0 +1,138 @@
+package hbs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestLMSSignVerify(t *testing.T) {
+	for _, otsTyp := range []LMOTSType{LMOTSSM3N32W1, LMOTSSM3N32W2, LMOTSSM3N32W4, LMOTSSM3N32W8} {
+		sk, err := GenerateLMSKey(rand.Reader, LMSSM3M32H5, otsTyp, NewMemoryStore(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pk := sk.Public()
+		msg := []byte("firmware image")
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pk.Verify(msg, sig) {
+			t.Errorf("TestLMSSignVerify 失败: otstype=%x", otsTyp)
+		}
+		if pk.Verify([]byte("tampered image"), sig) {
+			t.Errorf("TestLMSSignVerify 失败: 篡改消息通过验证 otstype=%x", otsTyp)
+		}
+		forged := append([]byte(nil), sig...)
+		forged[len(forged)-1] ^= 1
+		if pk.Verify(msg, forged) {
+			t.Errorf("TestLMSSignVerify 失败: 篡改签名通过验证 otstype=%x", otsTyp)
+		}
+		if pk.Verify(msg, sig[:len(sig)-1]) {
+			t.Errorf("TestLMSSignVerify 失败: 截断签名通过验证 otstype=%x", otsTyp)
+		}
+	}
+}
+
+func TestLMSExhaustion(t *testing.T) {
+	sk, err := GenerateLMSKey(rand.Reader, LMSSM3M32H5, LMOTSSM3N32W4, NewMemoryStore(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := sk.Public()
+	seen := make(map[uint32]bool)
+	for i := 0; i < 32; i++ {
+		msg := []byte{byte(i)}
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := uint32(sig[0])<<24 | uint32(sig[1])<<16 | uint32(sig[2])<<8 | uint32(sig[3])
+		if seen[q] {
+			t.Errorf("TestLMSExhaustion 失败: 序号 %d 被重用", q)
+		}
+		seen[q] = true
+		if !pk.Verify(msg, sig) {
+			t.Errorf("TestLMSExhaustion 失败: q=%d", q)
+		}
+	}
+	if _, err := sk.Sign([]byte("one more")); err != ErrKeyExhausted {
+		t.Errorf("TestLMSExhaustion 失败: %v", err)
+	}
+}
+
+func TestLMSRefusesIndexReuse(t *testing.T) {
+	store := NewMemoryStore(0)
+	sk, err := GenerateLMSKey(rand.Reader, LMSSM3M32H5, LMOTSSM3N32W4, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sk.Sign([]byte("first")); err != nil {
+		t.Fatal(err)
+	}
+	store.Store(1, 0)
+	if _, err := sk.Sign([]byte("second")); err != ErrIndexReuse {
+		t.Errorf("TestLMSRefusesIndexReuse 失败: %v", err)
+	}
+}
+
+func TestLMSKeyEncoding(t *testing.T) {
+	store := NewMemoryStore(0)
+	sk, err := GenerateLMSKey(rand.Reader, LMSSM3M32H5, LMOTSSM3N32W4, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := sk.Public()
+	sk.Sign([]byte("before restart"))
+
+	data, _ := sk.MarshalBinary()
+	restored, err := ParseLMSPrivateKey(data, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := restored.Sign([]byte("after restart"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig[:4], []byte{0, 0, 0, 1}) {
+		t.Errorf("TestLMSKeyEncoding 失败: 重启后序号 %x", sig[:4])
+	}
+
+	parsed, err := ParseLMSPublicKey(pk.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Equal(pk) || !parsed.Verify([]byte("after restart"), sig) {
+		t.Error("TestLMSKeyEncoding 失败")
+	}
+	if _, err := ParseLMSPublicKey(pk.Bytes()[1:]); err == nil {
+		t.Error("TestLMSKeyEncoding 失败: 截断公钥")
+	}
+}
+
+func TestLMSInvalidParams(t *testing.T) {
+	if _, err := GenerateLMSKey(rand.Reader, LMSType(5), LMOTSSM3N32W4, NewMemoryStore(0)); err != ErrInvalidParams {
+		t.Errorf("TestLMSInvalidParams 失败: %v", err)
+	}
+	if _, err := GenerateLMSKey(rand.Reader, LMSSM3M32H5, LMOTSType(4), NewMemoryStore(0)); err != ErrInvalidParams {
+		t.Errorf("TestLMSInvalidParams 失败: %v", err)
+	}
+}
+
+// TestLMSH25VerifyOnly h = 25 的私钥不能生成或解析，公钥可以解析
+func TestLMSH25VerifyOnly(t *testing.T) {
+	if _, err := GenerateLMSKey(rand.Reader, LMSSM3M32H25, LMOTSSM3N32W8, NewMemoryStore(0)); err != ErrInvalidParams {
+		t.Errorf("TestLMSH25VerifyOnly 失败\n期望值=%v\n实际值=%v", ErrInvalidParams, err)
+	}
+	if _, err := GenerateHSSKey(rand.Reader, []LMSParams{{LMSSM3M32H25, LMOTSSM3N32W8}}, NewMemoryStore(0)); err != ErrInvalidParams {
+		t.Errorf("TestLMSH25VerifyOnly 失败\n期望值=%v\n实际值=%v", ErrInvalidParams, err)
+	}
+	key := append(append(u32str(uint32(LMSSM3M32H25)), u32str(uint32(LMOTSSM3N32W8))...), make([]byte, idSize+n)...)
+	if _, err := ParseLMSPrivateKey(key, NewMemoryStore(0)); err != ErrInvalidParams {
+		t.Errorf("TestLMSH25VerifyOnly 失败\n期望值=%v\n实际值=%v", ErrInvalidParams, err)
+	}
+	if _, err := ParseLMSPublicKey(key); err != nil {
+		t.Errorf("TestLMSH25VerifyOnly 失败: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package hbs
+
+import (
+	"os"
+	"time"
+)
+
+// lockFile 以 O_EXCL 创建 path 作为锁，已存在时等待，返回解锁函数
+func lockFile(path string) (func(), error) {
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,203 @@
+package hbs
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// -----------------------------------------------------------------------------
+// 一次性签名序号的状态管理
+//
+// 有状态签名的安全性依赖于每个一次性签名序号只使用一次。签名之前先把下一个序号
+// 持久化，再使用当前序号签名；即使签名过程中崩溃也只会浪费一个序号，而不会重用。
+//
+// 同一个状态可能被多个私钥对象或多个进程共用，StateStore.Store 是比较并交换：
+// 只有持久化的序号仍为 old 时才写入 next，否则返回 ErrStateConflict，分配方重新
+// 读取后再试，因此两个使用者不会分配到同一个序号
+// -----------------------------------------------------------------------------
+
+var (
+	ErrKeyExhausted = errors.New("hbs: all one-time signature indices are used")
+	ErrIndexReuse   = errors.New("hbs: state store went backwards, refusing to reuse index")
+	ErrCorruptState = errors.New("hbs: corrupt state")
+	// ErrStateConflict 状态已被其他使用者修改
+	ErrStateConflict = errors.New("hbs: state changed concurrently")
+)
+
+// StateStore 持久化下一个可用的一次性签名序号
+type StateStore interface {
+	// Load 读取下一个可用序号
+	Load() (uint64, error)
+	// Store 当前保存的序号为 old 时原子地改为 next，否则返回 ErrStateConflict；
+	// 返回 nil 之前必须保证已经持久化
+	Store(old, next uint64) error
+}
+
+// MemoryStore 保存在内存中的状态，仅用于测试或短生命周期的密钥
+type MemoryStore struct {
+	mu   sync.Mutex
+	next uint64
+}
+
+// NewMemoryStore 以 next 作为下一个可用序号
+func NewMemoryStore(next uint64) *MemoryStore {
+	return &MemoryStore{next: next}
+}
+
+// Load 实现 StateStore
+func (s *MemoryStore) Load() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next, nil
+}
+
+// Store 实现 StateStore
+func (s *MemoryStore) Store(old, next uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.next != old {
+		return ErrStateConflict
+	}
+	s.next = next
+	return nil
+}
+
+// FileStore 保存在文件中的状态，文件内容为 8 字节大端序号，不存在时视为 0
+//
+// Store 在 path + ".lock" 上加排他锁后读取、比较并写入，写入时先写临时文件并
+// fsync，再原子地重命名。锁在 Unix 上使用 flock(2)，同一进程中不同的 FileStore
+// 对象之间同样互斥；其他系统上使用以 O_EXCL 创建的锁文件，进程崩溃后遗留的锁
+// 文件需要手工删除
+type FileStore struct {
+	path string
+}
+
+// NewFileStore 以 path 作为状态文件
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load 实现 StateStore
+func (s *FileStore) Load() (uint64, error) {
+	return s.load()
+}
+
+func (s *FileStore) load() (uint64, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(data) != 8 {
+		return 0, ErrCorruptState
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
+// Store 实现 StateStore
+func (s *FileStore) Store(old, next uint64) error {
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	current, err := s.load()
+	if err != nil {
+		return err
+	}
+	if current != old {
+		return ErrStateConflict
+	}
+	return s.write(next)
+}
+
+func (s *FileStore) write(next uint64) error {
+	dir := filepath.Dir(s.path)
+	f, err := ioutil.TempFile(dir, filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], next)
+	if _, err := f.Write(buf[:]); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// counter 在 StateStore 之上分配序号，并记住本进程已经分配过的最大序号，
+// 防止状态被回滚后重用
+type counter struct {
+	mu    sync.Mutex
+	store StateStore
+	next  uint64
+	max   uint64
+}
+
+// reserve 分配一个序号，分配成功时下一个序号已经持久化。其他使用者同时分配时
+// Store 返回 ErrStateConflict，重新读取后再试
+func (c *counter) reserve() (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		q, err := c.store.Load()
+		if err != nil {
+			return 0, err
+		}
+		if q < c.next {
+			return 0, ErrIndexReuse
+		}
+		if q >= c.max {
+			return 0, ErrKeyExhausted
+		}
+		err = c.store.Store(q, q+1)
+		if err == ErrStateConflict {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		c.next = q + 1
+		return q, nil
+	}
+}
+
+// remaining 剩余可用的序号个数
+func (c *counter) remaining() (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q, err := c.store.Load()
+	if err != nil {
+		return 0, err
+	}
+	if q >= c.max {
+		return 0, nil
+	}
+	return c.max - q, nil
+}
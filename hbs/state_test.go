@@ -0,0 +1,136 @@
+package hbs
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hbs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(filepath.Join(dir, "state"))
+	next, err := store.Load()
+	if err != nil || next != 0 {
+		t.Errorf("TestFileStore 失败: next=%d err=%v", next, err)
+	}
+	if err := store.Store(0, 42); err != nil {
+		t.Fatal(err)
+	}
+	next, err = NewFileStore(filepath.Join(dir, "state")).Load()
+	if err != nil || next != 42 {
+		t.Errorf(`TestFileStore 失败
+期望值=%d
+实际值=%d`, 42, next)
+	}
+
+	if err := store.Store(41, 43); err != ErrStateConflict {
+		t.Errorf("TestFileStore 失败\n期望值=%v\n实际值=%v", ErrStateConflict, err)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "state"), []byte{1, 2, 3}, 0600)
+	if _, err := store.Load(); err != ErrCorruptState {
+		t.Errorf("TestFileStore 失败: %v", err)
+	}
+}
+
+func TestCounter(t *testing.T) {
+	store := NewMemoryStore(0)
+	c := counter{store: store, max: 3}
+	for i := uint64(0); i < 3; i++ {
+		q, err := c.reserve()
+		if err != nil || q != i {
+			t.Errorf("TestCounter 失败: q=%d err=%v", q, err)
+		}
+	}
+	if _, err := c.reserve(); err != ErrKeyExhausted {
+		t.Errorf("TestCounter 失败: %v", err)
+	}
+	if left, _ := c.remaining(); left != 0 {
+		t.Errorf("TestCounter 失败: remaining=%d", left)
+	}
+}
+
+func TestCounterRollback(t *testing.T) {
+	store := NewMemoryStore(0)
+	c := counter{store: store, max: 10}
+	c.reserve()
+	c.reserve()
+	// 模拟从备份恢复了旧的状态
+	store.Store(2, 1)
+	if _, err := c.reserve(); err != ErrIndexReuse {
+		t.Errorf("TestCounterRollback 失败: %v", err)
+	}
+}
+
+// TestFileStoreConcurrent 两个私钥对象各自打开同一个状态文件并发签名，分配的序号
+// 不能重复
+func TestFileStoreConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hbs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state")
+
+	sk, err := GenerateLMSKey(rand.Reader, LMSSM3M32H5, LMOTSSM3N32W8, NewFileStore(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := sk.MarshalBinary()
+	keys := []*LMSPrivateKey{sk}
+	for i := 0; i < 3; i++ {
+		k, err := ParseLMSPrivateKey(data, NewFileStore(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, k)
+	}
+
+	var mu sync.Mutex
+	used := make(map[uint32]bool)
+	var wg sync.WaitGroup
+	for _, k := range keys {
+		wg.Add(1)
+		go func(k *LMSPrivateKey) {
+			defer wg.Done()
+			for i := 0; i < 8; i++ {
+				sig, err := k.Sign([]byte("concurrent"))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				// 5.4 签名以 u32str(q) 开头
+				q := binary.BigEndian.Uint32(sig)
+				mu.Lock()
+				if used[q] {
+					t.Errorf("TestFileStoreConcurrent 失败: 序号 %d 被重复使用", q)
+				}
+				used[q] = true
+				mu.Unlock()
+			}
+		}(k)
+	}
+	wg.Wait()
+	if next, _ := NewFileStore(path).Load(); next != 32 || len(used) != 32 {
+		t.Errorf("TestFileStoreConcurrent 失败\n期望值=%d\n实际值=%d %d", 32, next, len(used))
+	}
+}
+
+func TestMemoryStoreConflict(t *testing.T) {
+	store := NewMemoryStore(5)
+	if err := store.Store(4, 6); err != ErrStateConflict {
+		t.Errorf("TestMemoryStoreConflict 失败\n期望值=%v\n实际值=%v", ErrStateConflict, err)
+	}
+	if err := store.Store(5, 6); err != nil {
+		t.Errorf("TestMemoryStoreConflict 失败: %v", err)
+	}
+}
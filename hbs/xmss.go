@@ -0,0 +1,455 @@
+package hbs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+// -----------------------------------------------------------------------------
+// RFC 8391 XMSS，按 5.1 中 SHA-256 的构造方式以 SM3 实例化，n = 32，w = 16
+//
+// 同样使用 RFC 8391 中保留给私有用途的 0xDDDDDDDD-0xFFFFFFFF 区间作为 OID
+// -----------------------------------------------------------------------------
+
+// XMSSType 5.3 XMSS 参数集
+type XMSSType uint32
+
+const (
+	XMSSSM3H10 XMSSType = 0xe0000001
+	XMSSSM3H16 XMSSType = 0xe0000002
+	XMSSSM3H20 XMSSType = 0xe0000003
+)
+
+var xmssHeights = map[XMSSType]uint{
+	XMSSSM3H10: 10,
+	XMSSSM3H16: 16,
+	XMSSSM3H20: 20,
+}
+
+// 3.1.1 WOTS+ 参数，w = 16
+const (
+	wotsW    = 16
+	wotsLogW = 4
+	wotsLen1 = 8 * n / wotsLogW
+	wotsLen2 = 3
+	wotsLen  = wotsLen1 + wotsLen2
+)
+
+// 5.1 各函数的域分隔前缀
+const (
+	padF    = 0
+	padH    = 1
+	padHMsg = 2
+	padPRF  = 3
+)
+
+// 2.5 地址类型
+const (
+	adrsOTS   = 0
+	adrsLTree = 1
+	adrsHash  = 2
+)
+
+// adrs 2.5 32 字节的哈希地址
+type adrs [8]uint32
+
+func (a *adrs) setType(t uint32) {
+	a[3] = t
+	a[4], a[5], a[6], a[7] = 0, 0, 0, 0
+}
+
+func (a *adrs) bytes() []byte {
+	out := make([]byte, 32)
+	for i, w := range a {
+		binary.BigEndian.PutUint32(out[i*4:], w)
+	}
+	return out
+}
+
+// OTS 地址
+func (a *adrs) setOTS(i uint32)   { a[4] = i }
+func (a *adrs) setChain(i uint32) { a[5] = i }
+func (a *adrs) setHash(i uint32)  { a[6] = i }
+
+// L-tree 地址
+func (a *adrs) setLTree(i uint32) { a[4] = i }
+
+// L-tree 和哈希树地址
+func (a *adrs) setTreeHeight(i uint32) { a[5] = i }
+func (a *adrs) setTreeIndex(i uint32)  { a[6] = i }
+
+func (a *adrs) setKeyAndMask(i uint32) { a[7] = i }
+
+func toByte(x uint64, size int) []byte {
+	out := make([]byte, size)
+	for i := size - 1; i >= 0 && x > 0; i-- {
+		out[i] = byte(x)
+		x >>= 8
+	}
+	return out
+}
+
+func xmssHash(pad uint64, key, m []byte) []byte {
+	h := sm3.New()
+	h.Write(toByte(pad, n))
+	h.Write(key)
+	h.Write(m)
+	return h.Sum(nil)
+}
+
+func prf(key []byte, a *adrs) []byte {
+	return xmssHash(padPRF, key, a.bytes())
+}
+
+func xor(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+// wotsChain 3.1.2 chain(X, i, s, SEED, ADRS)
+func wotsChain(x []byte, start, steps uint32, seed []byte, a *adrs) []byte {
+	tmp := x
+	for j := start; j < start+steps; j++ {
+		a.setHash(j)
+		a.setKeyAndMask(0)
+		key := prf(seed, a)
+		a.setKeyAndMask(1)
+		bm := prf(seed, a)
+		tmp = xmssHash(padF, key, xor(tmp, bm))
+	}
+	return tmp
+}
+
+// baseW 2.6 base_w(X, w, out_len)
+func baseW(x []byte, outLen int) []uint32 {
+	out := make([]uint32, outLen)
+	in, bits := 0, uint(0)
+	var total byte
+	for i := range out {
+		if bits == 0 {
+			total = x[in]
+			in++
+			bits = 8
+		}
+		bits -= wotsLogW
+		out[i] = uint32(total>>bits) & (wotsW - 1)
+	}
+	return out
+}
+
+// wotsDigits 3.1.5 消息和校验和展开为 len 个 base-w 数字
+func wotsDigits(m []byte) []uint32 {
+	msg := baseW(m, wotsLen1)
+	var csum uint64
+	for _, d := range msg {
+		csum += wotsW - 1 - uint64(d)
+	}
+	csum <<= 8 - (wotsLen2*wotsLogW)%8
+	return append(msg, baseW(toByte(csum, (wotsLen2*wotsLogW+7)/8), wotsLen2)...)
+}
+
+// wotsSK 3.1.7 伪随机地生成第 i 个 WOTS+ 私钥
+func wotsSK(skSeed []byte, a adrs) [][]byte {
+	a.setChain(0)
+	a.setHash(0)
+	a.setKeyAndMask(0)
+	s := prf(skSeed, &a)
+	sk := make([][]byte, wotsLen)
+	for i := range sk {
+		sk[i] = xmssHash(padPRF, s, toByte(uint64(i), 32))
+	}
+	return sk
+}
+
+// randHash 4.1.4 RAND_HASH
+func randHash(left, right, seed []byte, a *adrs) []byte {
+	a.setKeyAndMask(0)
+	key := prf(seed, a)
+	a.setKeyAndMask(1)
+	bm0 := prf(seed, a)
+	a.setKeyAndMask(2)
+	bm1 := prf(seed, a)
+	return xmssHash(padH, key, append(xor(left, bm0), xor(right, bm1)...))
+}
+
+// lTree 4.1.5 把 WOTS+ 公钥压缩为一个叶子
+func lTree(pk [][]byte, seed []byte, a *adrs) []byte {
+	l := len(pk)
+	a.setTreeHeight(0)
+	for l > 1 {
+		for i := 0; i < l/2; i++ {
+			a.setTreeIndex(uint32(i))
+			pk[i] = randHash(pk[2*i], pk[2*i+1], seed, a)
+		}
+		if l%2 == 1 {
+			pk[l/2] = pk[l-1]
+		}
+		l = (l + 1) / 2
+		a.setTreeHeight(a[5] + 1)
+	}
+	return pk[0]
+}
+
+// xmssLeaf 第 i 个叶子
+func xmssLeaf(skSeed, seed []byte, i uint32) []byte {
+	var a adrs
+	a.setType(adrsOTS)
+	a.setOTS(i)
+	sk := wotsSK(skSeed, a)
+	pk := make([][]byte, wotsLen)
+	for j := range sk {
+		a.setChain(uint32(j))
+		pk[j] = wotsChain(sk[j], 0, wotsW-1, seed, &a)
+	}
+	var la adrs
+	la.setType(adrsLTree)
+	la.setLTree(i)
+	return lTree(pk, seed, &la)
+}
+
+// xmssTree nodes[k] 为高度 k 的全部节点
+type xmssTree struct {
+	h     uint
+	nodes [][][]byte
+}
+
+func newXMSSTree(h uint, skSeed, seed []byte) *xmssTree {
+	t := &xmssTree{h: h, nodes: make([][][]byte, h+1)}
+	t.nodes[0] = make([][]byte, 1<<h)
+	parallel(1<<h, func(i int) {
+		t.nodes[0][i] = xmssLeaf(skSeed, seed, uint32(i))
+	})
+	for k := uint(0); k < h; k++ {
+		level := make([][]byte, len(t.nodes[k])/2)
+		for j := range level {
+			var a adrs
+			a.setType(adrsHash)
+			a.setTreeHeight(uint32(k))
+			a.setTreeIndex(uint32(j))
+			level[j] = randHash(t.nodes[k][2*j], t.nodes[k][2*j+1], seed, &a)
+		}
+		t.nodes[k+1] = level
+	}
+	return t
+}
+
+func (t *xmssTree) root() []byte {
+	return t.nodes[t.h][0]
+}
+
+// authPath 4.1.9 第 idx 个叶子的认证路径
+func (t *xmssTree) authPath(idx uint32) [][]byte {
+	auth := make([][]byte, t.h)
+	for k := uint(0); k < t.h; k++ {
+		auth[k] = t.nodes[k][(idx>>k)^1]
+	}
+	return auth
+}
+
+// XMSSPublicKey 4.1.7 XMSS 公钥
+type XMSSPublicKey struct {
+	Type XMSSType
+	Root []byte
+	Seed []byte
+}
+
+// Bytes 4.1.7 公钥编码 OID || root || SEED
+func (pk *XMSSPublicKey) Bytes() []byte {
+	out := u32str(uint32(pk.Type))
+	out = append(out, pk.Root...)
+	return append(out, pk.Seed...)
+}
+
+// Equal 比较两个公钥
+func (pk *XMSSPublicKey) Equal(other *XMSSPublicKey) bool {
+	return bytes.Equal(pk.Bytes(), other.Bytes())
+}
+
+// ParseXMSSPublicKey 解析 4.1.7 编码的公钥
+func ParseXMSSPublicKey(data []byte) (*XMSSPublicKey, error) {
+	if len(data) != 4+2*n {
+		return nil, ErrInvalidPublicKey
+	}
+	typ := XMSSType(binary.BigEndian.Uint32(data))
+	if _, ok := xmssHeights[typ]; !ok {
+		return nil, ErrInvalidParams
+	}
+	return &XMSSPublicKey{
+		Type: typ,
+		Root: append([]byte(nil), data[4:4+n]...),
+		Seed: append([]byte(nil), data[4+n:]...),
+	}, nil
+}
+
+// xmssSignatureSize 4.1.8 签名长度 idx || r || WOTS+ 签名 || 认证路径
+func xmssSignatureSize(h uint) int {
+	return 4 + n + wotsLen*n + int(h)*n
+}
+
+func hashMsg(r, root []byte, idx uint32, msg []byte) []byte {
+	key := make([]byte, 0, 3*n)
+	key = append(key, r...)
+	key = append(key, root...)
+	key = append(key, toByte(uint64(idx), n)...)
+	return xmssHash(padHMsg, key, msg)
+}
+
+// Verify 4.1.10 验证 XMSS 签名
+func (pk *XMSSPublicKey) Verify(msg, sig []byte) bool {
+	h, ok := xmssHeights[pk.Type]
+	if !ok || len(pk.Root) != n || len(pk.Seed) != n || len(sig) != xmssSignatureSize(h) {
+		return false
+	}
+	idx := binary.BigEndian.Uint32(sig)
+	if uint64(idx) >= uint64(1)<<h {
+		return false
+	}
+	r := sig[4 : 4+n]
+	digest := hashMsg(r, pk.Root, idx, msg)
+
+	var a adrs
+	a.setType(adrsOTS)
+	a.setOTS(idx)
+	digits := wotsDigits(digest)
+	wots := sig[4+n:]
+	pkOTS := make([][]byte, wotsLen)
+	for i := range pkOTS {
+		a.setChain(uint32(i))
+		pkOTS[i] = wotsChain(wots[i*n:(i+1)*n], digits[i], wotsW-1-digits[i], pk.Seed, &a)
+	}
+	var la adrs
+	la.setType(adrsLTree)
+	la.setLTree(idx)
+	node := lTree(pkOTS, pk.Seed, &la)
+
+	auth := sig[4+n+wotsLen*n:]
+	for k := uint(0); k < h; k++ {
+		var ha adrs
+		ha.setType(adrsHash)
+		ha.setTreeHeight(uint32(k))
+		ha.setTreeIndex(idx >> (k + 1))
+		sibling := auth[int(k)*n : int(k+1)*n]
+		if (idx>>k)&1 == 0 {
+			node = randHash(node, sibling, pk.Seed, &ha)
+		} else {
+			node = randHash(sibling, node, pk.Seed, &ha)
+		}
+	}
+	return bytes.Equal(node, pk.Root)
+}
+
+// XMSSPrivateKey XMSS 私钥，一次性签名序号保存在 StateStore 中
+type XMSSPrivateKey struct {
+	Type   XMSSType
+	SKSeed []byte
+	SKPRF  []byte
+	Seed   []byte
+
+	state counter
+	once  sync.Once
+	tree  *xmssTree
+}
+
+// GenerateXMSSKey 4.1.7 生成 XMSS 私钥，store 中的序号从 0 开始使用
+func GenerateXMSSKey(rand io.Reader, typ XMSSType, store StateStore) (*XMSSPrivateKey, error) {
+	if _, ok := xmssHeights[typ]; !ok {
+		return nil, ErrInvalidParams
+	}
+	buf := make([]byte, 3*n)
+	if _, err := io.ReadFull(rand, buf); err != nil {
+		return nil, err
+	}
+	return newXMSSPrivateKey(typ, buf[:n], buf[n:2*n], buf[2*n:], store)
+}
+
+func newXMSSPrivateKey(typ XMSSType, skSeed, skPRF, seed []byte, store StateStore) (*XMSSPrivateKey, error) {
+	h, ok := xmssHeights[typ]
+	if !ok {
+		return nil, ErrInvalidParams
+	}
+	if len(skSeed) != n || len(skPRF) != n || len(seed) != n {
+		return nil, ErrInvalidKey
+	}
+	return &XMSSPrivateKey{
+		Type:   typ,
+		SKSeed: append([]byte(nil), skSeed...),
+		SKPRF:  append([]byte(nil), skPRF...),
+		Seed:   append([]byte(nil), seed...),
+		state:  counter{store: store, max: uint64(1) << h},
+	}, nil
+}
+
+// MarshalBinary 私钥编码 OID || SK_SEED || SK_PRF || SEED，不含状态
+func (sk *XMSSPrivateKey) MarshalBinary() ([]byte, error) {
+	out := u32str(uint32(sk.Type))
+	out = append(out, sk.SKSeed...)
+	out = append(out, sk.SKPRF...)
+	return append(out, sk.Seed...), nil
+}
+
+// ParseXMSSPrivateKey 解析 MarshalBinary 的输出，并与 store 关联
+func ParseXMSSPrivateKey(data []byte, store StateStore) (*XMSSPrivateKey, error) {
+	if len(data) != 4+3*n {
+		return nil, ErrInvalidKey
+	}
+	return newXMSSPrivateKey(XMSSType(binary.BigEndian.Uint32(data)),
+		data[4:4+n], data[4+n:4+2*n], data[4+2*n:], store)
+}
+
+func (sk *XMSSPrivateKey) xmssTree() *xmssTree {
+	sk.once.Do(func() {
+		sk.tree = newXMSSTree(xmssHeights[sk.Type], sk.SKSeed, sk.Seed)
+	})
+	return sk.tree
+}
+
+// Public 返回公钥，第一次调用时计算整棵树
+func (sk *XMSSPrivateKey) Public() *XMSSPublicKey {
+	return &XMSSPublicKey{
+		Type: sk.Type,
+		Root: append([]byte(nil), sk.xmssTree().root()...),
+		Seed: append([]byte(nil), sk.Seed...),
+	}
+}
+
+// Remaining 剩余可签名次数
+func (sk *XMSSPrivateKey) Remaining() (uint64, error) {
+	return sk.state.remaining()
+}
+
+// Sign 4.1.9 分配一个新的序号并签名
+func (sk *XMSSPrivateKey) Sign(msg []byte) ([]byte, error) {
+	t := sk.xmssTree()
+	q, err := sk.state.reserve()
+	if err != nil {
+		return nil, err
+	}
+	idx := uint32(q)
+	r := xmssHash(padPRF, sk.SKPRF, toByte(uint64(idx), 32))
+	digest := hashMsg(r, t.root(), idx, msg)
+
+	var a adrs
+	a.setType(adrsOTS)
+	a.setOTS(idx)
+	wotsKey := wotsSK(sk.SKSeed, a)
+	digits := wotsDigits(digest)
+
+	sig := make([]byte, 0, xmssSignatureSize(t.h))
+	sig = append(sig, u32str(idx)...)
+	sig = append(sig, r...)
+	for i := range wotsKey {
+		a.setChain(uint32(i))
+		sig = append(sig, wotsChain(wotsKey[i], 0, digits[i], sk.Seed, &a)...)
+	}
+	for _, node := range t.authPath(idx) {
+		sig = append(sig, node...)
+	}
+	return sig, nil
+}
@@ -0,0 +1,106 @@
+package hbs
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+// xmssSM3H4 仅用于测试的小参数集
+const xmssSM3H4 XMSSType = 0xefffffff
+
+func init() {
+	xmssHeights[xmssSM3H4] = 4
+}
+
+func TestBaseW(t *testing.T) {
+	actual := baseW([]byte{0x12, 0x34}, 4)
+	expected := []uint32{1, 2, 3, 4}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf(`TestBaseW 失败
+期望值=%v
+实际值=%v`, expected, actual)
+			break
+		}
+	}
+}
+
+func testXMSS(t *testing.T, typ XMSSType, count int) {
+	sk, err := GenerateXMSSKey(rand.Reader, typ, NewMemoryStore(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := sk.Public()
+	for i := 0; i < count; i++ {
+		msg := []byte{byte(i)}
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pk.Verify(msg, sig) {
+			t.Errorf("testXMSS 失败: i=%d", i)
+		}
+		if pk.Verify([]byte("tampered"), sig) {
+			t.Errorf("testXMSS 失败: 篡改消息通过验证 i=%d", i)
+		}
+		forged := append([]byte(nil), sig...)
+		forged[40] ^= 1
+		if pk.Verify(msg, forged) {
+			t.Errorf("testXMSS 失败: 篡改签名通过验证 i=%d", i)
+		}
+	}
+}
+
+func TestXMSSSignVerify(t *testing.T) {
+	testXMSS(t, xmssSM3H4, 16)
+}
+
+func TestXMSSSM3H10(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping XMSS-SM3_10_256 key generation in short mode")
+	}
+	testXMSS(t, XMSSSM3H10, 2)
+}
+
+func TestXMSSExhaustionAndReuse(t *testing.T) {
+	store := NewMemoryStore(0)
+	sk, err := GenerateXMSSKey(rand.Reader, xmssSM3H4, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk.Sign([]byte("first"))
+	store.Store(1, 0)
+	if _, err := sk.Sign([]byte("again")); err != ErrIndexReuse {
+		t.Errorf("TestXMSSExhaustionAndReuse 失败: %v", err)
+	}
+
+	sk, _ = GenerateXMSSKey(rand.Reader, xmssSM3H4, NewMemoryStore(15))
+	if _, err := sk.Sign([]byte("last")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sk.Sign([]byte("too many")); err != ErrKeyExhausted {
+		t.Errorf("TestXMSSExhaustionAndReuse 失败: %v", err)
+	}
+}
+
+func TestXMSSKeyEncoding(t *testing.T) {
+	store := NewMemoryStore(0)
+	sk, err := GenerateXMSSKey(rand.Reader, xmssSM3H4, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := sk.Public()
+	data, _ := sk.MarshalBinary()
+	restored, err := ParseXMSSPrivateKey(data, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _ := restored.Sign([]byte("restored"))
+	parsed, err := ParseXMSSPublicKey(pk.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Equal(pk) || !parsed.Verify([]byte("restored"), sig) {
+		t.Error("TestXMSSKeyEncoding 失败")
+	}
+}
@@ -0,0 +1,91 @@
+package jose
+
+import (
+	"encoding/json"
+)
+
+// -----------------------------------------------------------------------------
+// 7.2 JSON 序列化
+//
+// 签名时输出一般语法（7.2.1），每个 Signer 一个签名。验证时接受一般语法和
+// 扁平语法（7.2.2），alg 必须在受保护头部中；任意一个签名通过验证即返回载荷，
+// alg 不允许或 kid 不对应的签名被跳过，格式错误的签名使整个 JWS 无效
+// -----------------------------------------------------------------------------
+
+type jsonSignature struct {
+	Protected string                     `json:"protected,omitempty"`
+	Header    map[string]json.RawMessage `json:"header,omitempty"`
+	Signature string                     `json:"signature"`
+}
+
+type jsonJWS struct {
+	Payload    string          `json:"payload"`
+	Signatures []jsonSignature `json:"signatures,omitempty"`
+	// 扁平语法
+	Protected string                     `json:"protected,omitempty"`
+	Header    map[string]json.RawMessage `json:"header,omitempty"`
+	Signature *string                    `json:"signature,omitempty"`
+}
+
+// SignJSON 生成一般语法的 JSON 序列化 JWS，header 可以为 nil
+func SignJSON(payload []byte, header *Header, signers ...*Signer) ([]byte, error) {
+	if len(signers) == 0 {
+		return nil, ErrNoSignature
+	}
+	jws := jsonJWS{Payload: b64.EncodeToString(payload)}
+	for _, s := range signers {
+		protected, sig, err := s.sign(jws.Payload, header)
+		if err != nil {
+			return nil, err
+		}
+		jws.Signatures = append(jws.Signatures, jsonSignature{Protected: protected, Signature: sig})
+	}
+	return json.Marshal(&jws)
+}
+
+// VerifyJSON 验证 JSON 序列化的 JWS，返回载荷和通过验证的签名的受保护头部
+func (v *Verifier) VerifyJSON(data []byte) ([]byte, *Header, error) {
+	var jws jsonJWS
+	if err := json.Unmarshal(data, &jws); err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	signatures := jws.Signatures
+	if jws.Signature != nil {
+		if signatures != nil {
+			return nil, nil, ErrInvalidToken
+		}
+		signatures = []jsonSignature{{Protected: jws.Protected, Header: jws.Header, Signature: *jws.Signature}}
+	} else if jws.Protected != "" || jws.Header != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	if len(signatures) == 0 {
+		return nil, nil, ErrNoSignature
+	}
+	payload, err := b64.DecodeString(jws.Payload)
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	// 没有可验证的签名时返回第一个被跳过的原因
+	var skipped error
+	verified := false
+	for _, sig := range signatures {
+		header, err := v.verify(sig.Protected, jws.Payload, sig.Signature, sig.Header)
+		switch err {
+		case nil:
+			return payload, header, nil
+		case ErrUnsupportedAlgorithm, ErrKeyIDMismatch:
+			if skipped == nil {
+				skipped = err
+			}
+		case ErrInvalidSignature:
+			verified = true
+		default:
+			return nil, nil, err
+		}
+	}
+	if verified || skipped == nil {
+		return nil, nil, ErrInvalidSignature
+	}
+	return nil, nil, skipped
+}
@@ -0,0 +1,85 @@
+package jose
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/sm2"
+)
+
+func TestJSON(t *testing.T) {
+	var signers []*Signer
+	var verifiers []*Verifier
+	for _, kid := range []string{"a", "b"} {
+		sk, _, err := sm2.GenKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := NewSigner(sk, nil)
+		s.KeyID = kid
+		signers = append(signers, s)
+		v := NewVerifier(sk.GenPubKey(), nil)
+		v.KeyID = kid
+		verifiers = append(verifiers, v)
+	}
+	payload := []byte(`{"iss":"joe"}`)
+	data, err := SignJSON(payload, &Header{Type: "JOSE+JSON"}, signers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range verifiers {
+		actual, header, err := v.VerifyJSON(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(actual) != string(payload) || header.KeyID != v.KeyID || header.Type != "JOSE+JSON" {
+			t.Errorf("TestJSON 失败\n期望值=%s\n实际值=%s %+v", payload, actual, header)
+		}
+	}
+
+	// 没有对应 kid 的签名
+	verifiers[0].KeyID = "c"
+	if _, _, err := verifiers[0].VerifyJSON(data); err != ErrKeyIDMismatch {
+		t.Errorf("TestJSON kid 失败\n期望值=%v\n实际值=%v", ErrKeyIDMismatch, err)
+	}
+	verifiers[0].KeyID = ""
+	var jws jsonJWS
+	if err := json.Unmarshal(data, &jws); err != nil {
+		t.Fatal(err)
+	}
+	jws.Signatures = jws.Signatures[1:]
+	data, _ = json.Marshal(&jws)
+	if _, _, err := verifiers[0].VerifyJSON(data); err != ErrInvalidSignature {
+		t.Errorf("TestJSON 失败\n期望值=%v\n实际值=%v", ErrInvalidSignature, err)
+	}
+	if _, err := SignJSON(payload, nil); err != ErrNoSignature {
+		t.Errorf("TestJSON 失败\n期望值=%v\n实际值=%v", ErrNoSignature, err)
+	}
+}
+
+func TestJSONFlattened(t *testing.T) {
+	sk := fixtureKey(t)
+	v := NewVerifier(sk.GenPubKey(), nil)
+	// 由紧凑序列化的 fixtureToken 改写
+	protected := `"eyJhbGciOiJTTTJTTTMiLCJraWQiOiJjNVJUVzhOOENKNHdWWE14OGVpNU16bHVranVPbGMzOUtOR0NlS2tuRzdnIiwidHlwIjoiSldUIn0"`
+	payload := `"eyJpc3MiOiJodHRwczovL2lzc3Vlci5leGFtcGxlIiwic3ViIjoiYWxpY2UiLCJhdWQiOiJhcGkiLCJleHAiOjE4OTM0NTYwMDAsImlhdCI6MTc2NzIyNTYwMH0"`
+	signature := `"tS3r5mxtR34eiTktNqQrjoQXfN4fQq8noIq6yJu9C-VFjW9qHOAKpI-KIB2f3kaiudklsScf-7yC2FxTXIZN0w"`
+	tests := []struct {
+		name string
+		data string
+		err  error
+	}{
+		{"扁平语法", `{"payload":` + payload + `,"protected":` + protected + `,"header":{"x-trace":"1"},"signature":` + signature + `}`, nil},
+		{"一般语法", `{"payload":` + payload + `,"signatures":[{"protected":` + protected + `,"signature":` + signature + `}]}`, nil},
+		{"混合语法", `{"payload":` + payload + `,"signature":` + signature + `,"signatures":[{"protected":` + protected + `,"signature":` + signature + `}]}`, ErrInvalidToken},
+		{"头部参数重复", `{"payload":` + payload + `,"protected":` + protected + `,"header":{"kid":"x"},"signature":` + signature + `}`, ErrInvalidToken},
+		{"非保护头部的 alg", `{"payload":` + payload + `,"header":{"alg":"SM2SM3"},"signature":` + signature + `}`, ErrInvalidToken},
+		{"没有签名", `{"payload":` + payload + `}`, ErrNoSignature},
+	}
+	for _, tt := range tests {
+		if _, _, err := v.VerifyJSON([]byte(tt.data)); err != tt.err {
+			t.Errorf("TestJSONFlattened %s 失败\n期望值=%v\n实际值=%v", tt.name, tt.err, err)
+		}
+	}
+}
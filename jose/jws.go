@@ -0,0 +1,224 @@
+// Package jose 实现以 SM2-SM3 签名的 JSON Web Signature（RFC 7515）和 JSON Web
+// Token（RFC 7519），算法名称为 "SM2SM3"，签名为 r || s 定长编码
+package jose
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/t1anchen/gogmlib/sm2"
+)
+
+// -----------------------------------------------------------------------------
+// RFC 7515 JWS
+//
+// 签名输入为 ASCII(BASE64URL(UTF8(JWS 受保护头部)) || '.' || BASE64URL(JWS 载荷))，
+// 按 GB/T 32918.2-2016 以 SM3 计算 e = SM3(Z || M)，Z 中的用户身份标识由每个
+// 密钥单独配置，为空时使用默认值 1234567812345678。签名为 64 字节的 r || s，
+// 与 RFC 7518 3.4 中 ECDSA 的编码方式相同。
+//
+// 验证时算法由验证密钥决定，头部中的 alg 只用来核对：alg 必须在验证器的允许
+// 列表中，且必须是 SM2SM3，"none" 和其他算法一律拒绝。本实现不支持任何扩展
+// 头部参数，带 crit 的 JWS 按 4.1.11 拒绝
+// -----------------------------------------------------------------------------
+
+// SM2SM3 JWS 算法名称
+const SM2SM3 = "SM2SM3"
+
+var (
+	ErrInvalidToken         = errors.New("jose: malformed JWS")
+	ErrUnsupportedAlgorithm = errors.New("jose: algorithm is not allowed")
+	ErrUnsupportedCritical  = errors.New("jose: critical header parameters are not supported")
+	ErrKeyIDMismatch        = errors.New("jose: kid does not match the verification key")
+	ErrInvalidSignature     = errors.New("jose: invalid signature")
+	ErrNoSignature          = errors.New("jose: no signature")
+)
+
+// b64 base64url 编码，不带填充，解码时拒绝非规范编码
+var b64 = base64.RawURLEncoding.Strict()
+
+// Header JWS 受保护头部
+type Header struct {
+	// Algorithm 签名时由 Signer 填写
+	Algorithm   string `json:"alg"`
+	KeyID       string `json:"kid,omitempty"`
+	Type        string `json:"typ,omitempty"`
+	ContentType string `json:"cty,omitempty"`
+}
+
+// Signer 签名密钥
+type Signer struct {
+	key    *sm2.PrivKey
+	userID []byte
+	// KeyID 非空且头部没有指定 kid 时写入受保护头部
+	KeyID string
+}
+
+// NewSigner 以 SM2 私钥创建签名密钥，userID 为空时使用默认值
+func NewSigner(sk *sm2.PrivKey, userID []byte) *Signer {
+	return &Signer{key: sk, userID: append([]byte(nil), userID...)}
+}
+
+// Verifier 验证密钥，创建后只读，可以并发使用
+type Verifier struct {
+	v *sm2.Verifier
+	// KeyID 非空时受保护头部中的 kid 必须与之相同
+	KeyID string
+	// Algorithms 允许的 alg，为空时只允许 SM2SM3
+	Algorithms []string
+}
+
+// NewVerifier 以 SM2 公钥创建验证密钥，userID 为空时使用默认值。Z 和公钥点的
+// 多倍点表在这里预先计算
+func NewVerifier(pk *sm2.PubKey, userID []byte) *Verifier {
+	if len(userID) == 0 {
+		userID = nil
+	}
+	return &Verifier{v: pk.Precompute(userID)}
+}
+
+// PubKey 返回验证密钥对应的公钥
+func (v *Verifier) PubKey() *sm2.PubKey {
+	return v.v.PubKey()
+}
+
+// -----------------------------------------------------------------------------
+// 7.1 紧凑序列化
+// -----------------------------------------------------------------------------
+
+// SignCompact 生成紧凑序列化的 JWS，header 可以为 nil
+func (s *Signer) SignCompact(payload []byte, header *Header) (string, error) {
+	protected, sig, err := s.sign(b64.EncodeToString(payload), header)
+	if err != nil {
+		return "", err
+	}
+	return protected + "." + b64.EncodeToString(payload) + "." + sig, nil
+}
+
+// VerifyCompact 验证紧凑序列化的 JWS，返回载荷和受保护头部
+func (v *Verifier) VerifyCompact(token string) ([]byte, *Header, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, ErrInvalidToken
+	}
+	header, err := v.verify(parts[0], parts[1], parts[2], nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	payload, err := b64.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	return payload, header, nil
+}
+
+// -----------------------------------------------------------------------------
+// 签名和验证
+// -----------------------------------------------------------------------------
+
+// sign 返回编码后的受保护头部和签名
+func (s *Signer) sign(encodedPayload string, header *Header) (protected, signature string, err error) {
+	h := Header{}
+	if header != nil {
+		h = *header
+	}
+	if h.Algorithm != "" && h.Algorithm != SM2SM3 {
+		return "", "", ErrUnsupportedAlgorithm
+	}
+	h.Algorithm = SM2SM3
+	if h.KeyID == "" {
+		h.KeyID = s.KeyID
+	}
+	encoded, err := json.Marshal(&h)
+	if err != nil {
+		return "", "", err
+	}
+	protected = b64.EncodeToString(encoded)
+	sig, err := s.key.SignWithFormat(s.userID, []byte(protected+"."+encodedPayload), sm2.SignatureRaw)
+	if err != nil {
+		return "", "", err
+	}
+	return protected, b64.EncodeToString(sig), nil
+}
+
+// verify 检查受保护头部并验证签名，unprotected 为 JSON 序列化中的非保护头部
+func (v *Verifier) verify(protected, encodedPayload, signature string, unprotected map[string]json.RawMessage) (*Header, error) {
+	header, err := v.parseHeader(protected, unprotected)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := b64.DecodeString(signature)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if v.v.CheckSignatureWithFormat([]byte(protected+"."+encodedPayload), sig, sm2.SignatureRaw) != nil {
+		return nil, ErrInvalidSignature
+	}
+	return header, nil
+}
+
+// parseHeader 解码受保护头部，检查 alg、crit 和 kid
+func (v *Verifier) parseHeader(protected string, unprotected map[string]json.RawMessage) (*Header, error) {
+	data, err := b64.DecodeString(protected)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil || members == nil {
+		return nil, ErrInvalidToken
+	}
+	// 4 受保护头部和非保护头部的参数名不能重复，crit 必须受保护
+	for name := range unprotected {
+		if _, ok := members[name]; ok || name == "crit" {
+			return nil, ErrInvalidToken
+		}
+	}
+	if _, ok := members["crit"]; ok {
+		return nil, ErrUnsupportedCritical
+	}
+	// 4 参数名区分大小写，json.Unmarshal 解码到结构时不区分，所以逐个按原样读取
+	header := new(Header)
+	for _, p := range []struct {
+		name  string
+		value *string
+	}{
+		{"alg", &header.Algorithm},
+		{"kid", &header.KeyID},
+		{"typ", &header.Type},
+		{"cty", &header.ContentType},
+	} {
+		raw, ok := members[p.name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, p.value); err != nil || bytes.Equal(raw, []byte("null")) {
+			return nil, ErrInvalidToken
+		}
+	}
+	// 没有 alg（包括只有 "ALG" 等写法）时 Algorithm 为空，不在允许列表中
+	if !v.allowed(header.Algorithm) {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if v.KeyID != "" && header.KeyID != v.KeyID {
+		return nil, ErrKeyIDMismatch
+	}
+	return header, nil
+}
+
+func (v *Verifier) allowed(alg string) bool {
+	if alg != SM2SM3 {
+		return false
+	}
+	if len(v.Algorithms) == 0 {
+		return true
+	}
+	for _, a := range v.Algorithms {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package jose
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/sm2"
+)
+
+// sm2/cert/testdata/sm2_pkcs8.pem 中由 OpenSSL 生成的密钥
+const fixtureD = "3407082cbd3f4b06e5b9ac6141449979c52cab005037081573a6dbc2ed33923b"
+
+// 本库生成的 JWT，签名由 openssl pkeyutl -verify -rawin -digest sm3 验证通过
+const fixtureToken = "eyJhbGciOiJTTTJTTTMiLCJraWQiOiJjNVJUVzhOOENKNHdWWE14OGVpNU16bHVranVPbGMzOUtOR0NlS2tuRzdnIiwidHlwIjoiSldUIn0." +
+	"eyJpc3MiOiJodHRwczovL2lzc3Vlci5leGFtcGxlIiwic3ViIjoiYWxpY2UiLCJhdWQiOiJhcGkiLCJleHAiOjE4OTM0NTYwMDAsImlhdCI6MTc2NzIyNTYwMH0." +
+	"tS3r5mxtR34eiTktNqQrjoQXfN4fQq8noIq6yJu9C-VFjW9qHOAKpI-KIB2f3kaiudklsScf-7yC2FxTXIZN0w"
+
+// 由 openssl pkeyutl -sign -rawin -digest sm3 签名的 JWT，DER 签名转换为 r || s
+const opensslToken = "eyJhbGciOiJTTTJTTTMiLCJ0eXAiOiJKV1QifQ." +
+	"eyJpc3MiOiJvcGVuc3NsIiwiYXVkIjpbImFwaSIsIndlYiJdLCJuYmYiOjE3NjcyMjU2MDAsImV4cCI6MTg5MzQ1NjAwMH0." +
+	"cg3zYg7VeRk13N-eq74OBpukl3PpWJxvXh2vNripLk5x1vHJn9mJjecz5wSSRWQE4Ex4iBz7PwoLriNvQZ2yHQ"
+
+func fixtureKey(t *testing.T) *sm2.PrivKey {
+	d, err := hex.DecodeString(fixtureD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &sm2.PrivKey{D: new(big.Int).SetBytes(d), Curve: sm2.GetSm2P256()}
+}
+
+// signRaw 以任意受保护头部签名，用于构造本库不会生成的 JWS
+func signRaw(t *testing.T, sk *sm2.PrivKey, header, payload string) string {
+	input := b64.EncodeToString([]byte(header)) + "." + b64.EncodeToString([]byte(payload))
+	sig, err := sk.SignWithFormat(nil, []byte(input), sm2.SignatureRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + b64.EncodeToString(sig)
+}
+
+func TestCompactInterop(t *testing.T) {
+	v := NewVerifier(fixtureKey(t).GenPubKey(), nil)
+	for _, token := range []string{fixtureToken, opensslToken} {
+		payload, header, err := v.VerifyCompact(token)
+		if err != nil {
+			t.Fatalf("TestCompactInterop 失败: %v", err)
+		}
+		if header.Algorithm != SM2SM3 || header.Type != "JWT" || !strings.HasPrefix(string(payload), `{"iss":`) {
+			t.Errorf("TestCompactInterop 失败\n实际值=%+v %s", header, payload)
+		}
+	}
+}
+
+func TestCompactRoundTrip(t *testing.T) {
+	sk, _, err := sm2.GenKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID := []byte("alice@example.com")
+	s := NewSigner(sk, userID)
+	s.KeyID = "key-1"
+	payload := []byte("In our village, folks say God crumbles up the old moon into stars.")
+	token, err := s.SignCompact(payload, &Header{ContentType: "text/plain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := NewVerifier(sk.GenPubKey(), userID)
+	v.KeyID = "key-1"
+	actual, header, err := v.VerifyCompact(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(actual) != string(payload) || header.KeyID != "key-1" || header.ContentType != "text/plain" {
+		t.Errorf("TestCompactRoundTrip 失败\n期望值=%s\n实际值=%s %+v", payload, actual, header)
+	}
+
+	// 用户身份标识是签名的一部分
+	if _, _, err := NewVerifier(sk.GenPubKey(), nil).VerifyCompact(token); err != ErrInvalidSignature {
+		t.Errorf("TestCompactRoundTrip 用户身份标识失败\n期望值=%v\n实际值=%v", ErrInvalidSignature, err)
+	}
+	v.KeyID = "key-2"
+	if _, _, err := v.VerifyCompact(token); err != ErrKeyIDMismatch {
+		t.Errorf("TestCompactRoundTrip kid 失败\n期望值=%v\n实际值=%v", ErrKeyIDMismatch, err)
+	}
+	if _, err := s.SignCompact(payload, &Header{Algorithm: "ES256"}); err != ErrUnsupportedAlgorithm {
+		t.Errorf("TestCompactRoundTrip alg 失败\n期望值=%v\n实际值=%v", ErrUnsupportedAlgorithm, err)
+	}
+}
+
+func TestCompactAlgorithm(t *testing.T) {
+	sk := fixtureKey(t)
+	v := NewVerifier(sk.GenPubKey(), nil)
+	none := b64.EncodeToString([]byte(`{"alg":"none"}`)) + "." + b64.EncodeToString([]byte("{}")) + "."
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"none", none},
+		{"ES256", signRaw(t, sk, `{"alg":"ES256"}`, "{}")},
+		{"小写", signRaw(t, sk, `{"alg":"sm2sm3"}`, "{}")},
+		{"缺少 alg", signRaw(t, sk, `{"typ":"JWT"}`, "{}")},
+		// 参数名区分大小写
+		{"ALG", signRaw(t, sk, `{"ALG":"SM2SM3"}`, "{}")},
+		{"Alg", signRaw(t, sk, `{"Alg":"SM2SM3","typ":"JWT"}`, "{}")},
+	}
+	for _, tt := range tests {
+		if _, _, err := v.VerifyCompact(tt.token); err != ErrUnsupportedAlgorithm {
+			t.Errorf("TestCompactAlgorithm %s 失败\n期望值=%v\n实际值=%v", tt.name, ErrUnsupportedAlgorithm, err)
+		}
+	}
+
+	// 允许列表中没有 SM2SM3 时拒绝
+	v.Algorithms = []string{"ES256"}
+	if _, _, err := v.VerifyCompact(fixtureToken); err != ErrUnsupportedAlgorithm {
+		t.Errorf("TestCompactAlgorithm 允许列表失败\n期望值=%v\n实际值=%v", ErrUnsupportedAlgorithm, err)
+	}
+	v.Algorithms = []string{"ES256", SM2SM3}
+	if _, _, err := v.VerifyCompact(fixtureToken); err != nil {
+		t.Errorf("TestCompactAlgorithm 允许列表失败: %v", err)
+	}
+}
+
+func TestCompactInvalid(t *testing.T) {
+	sk := fixtureKey(t)
+	v := NewVerifier(sk.GenPubKey(), nil)
+	parts := strings.Split(fixtureToken, ".")
+	otherPayload := b64.EncodeToString([]byte(`{"sub":"mallory"}`))
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"两段", parts[0] + "." + parts[1], ErrInvalidToken},
+		{"四段", fixtureToken + ".", ErrInvalidToken},
+		{"签名带填充", fixtureToken + "==", ErrInvalidToken},
+		{"头部不是 JSON 对象", b64.EncodeToString([]byte(`"SM2SM3"`)) + "." + parts[1] + "." + parts[2], ErrInvalidToken},
+		{"篡改载荷", parts[0] + "." + otherPayload + "." + parts[2], ErrInvalidSignature},
+		{"签名长度", parts[0] + "." + parts[1] + "." + parts[2][:80], ErrInvalidSignature},
+		{"crit", signRaw(t, sk, `{"alg":"SM2SM3","crit":["exp"],"exp":1}`, "{}"), ErrUnsupportedCritical},
+		{"alg 类型", signRaw(t, sk, `{"alg":1}`, "{}"), ErrInvalidToken},
+		{"alg 为 null", signRaw(t, sk, `{"alg":null}`, "{}"), ErrInvalidToken},
+		{"kid 类型", signRaw(t, sk, `{"alg":"SM2SM3","kid":["k1"]}`, "{}"), ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		if _, _, err := v.VerifyCompact(tt.token); err != tt.err {
+			t.Errorf("TestCompactInvalid %s 失败\n期望值=%v\n实际值=%v", tt.name, tt.err, err)
+		}
+	}
+
+	// 大小写不同的 KID 不是 kid
+	v.KeyID = "k1"
+	if _, _, err := v.VerifyCompact(signRaw(t, sk, `{"alg":"SM2SM3","KID":"k1"}`, "{}")); err != ErrKeyIDMismatch {
+		t.Errorf("TestCompactInvalid KID 失败\n期望值=%v\n实际值=%v", ErrKeyIDMismatch, err)
+	}
+	v.KeyID = ""
+
+	other, _, err := sm2.GenKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := NewVerifier(other.GenPubKey(), nil).VerifyCompact(fixtureToken); err != ErrInvalidSignature {
+		t.Errorf("TestCompactInvalid 其他公钥失败\n期望值=%v\n实际值=%v", ErrInvalidSignature, err)
+	}
+}
@@ -0,0 +1,182 @@
+package jose
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+)
+
+// -----------------------------------------------------------------------------
+// RFC 7519 JWT
+//
+// JWT 是载荷为 JSON 对象、以紧凑序列化表示的 JWS，受保护头部带 typ "JWT"。
+// 解析时先验证签名，再按 4.1 检查注册声明：
+//
+//	exp 当前时间不早于 exp 时拒绝
+//	nbf 当前时间早于 nbf 时拒绝
+//	iss 期望的签发者非空时必须相同
+//	aud 期望的受众非空时 aud 必须包含它；令牌带 aud 而验证方没有指定受众时
+//	    按 4.1.3 拒绝
+//
+// exp 和 nbf 的比较允许 Leeway 的时钟偏差。嵌套 JWT（cty 为 "JWT"）不支持
+// -----------------------------------------------------------------------------
+
+var (
+	ErrExpired         = errors.New("jose: token has expired")
+	ErrNotValidYet     = errors.New("jose: token is not valid yet")
+	ErrInvalidIssuer   = errors.New("jose: unexpected issuer")
+	ErrInvalidAudience = errors.New("jose: unexpected audience")
+)
+
+// NumericDate 2 自 1970-01-01T00:00:00Z 起的秒数，解析时小数部分被舍去
+type NumericDate int64
+
+// NewNumericDate 将 t 转换为 NumericDate
+func NewNumericDate(t time.Time) *NumericDate {
+	d := NumericDate(t.Unix())
+	return &d
+}
+
+// Time 返回对应的时间
+func (d NumericDate) Time() time.Time {
+	return time.Unix(int64(d), 0)
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	f, err := strconv.ParseFloat(string(data), 64)
+	if err != nil || math.Abs(f) >= 1<<62 {
+		return ErrInvalidToken
+	}
+	*d = NumericDate(f)
+	return nil
+}
+
+// Audience aud 声明，可以是一个字符串或字符串数组，只有一个值时编码为字符串
+type Audience []string
+
+// MarshalJSON 实现 json.Marshaler
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return ErrInvalidToken
+	}
+	*a = list
+	return nil
+}
+
+// Contains aud 是否包含 aud
+func (a Audience) Contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+// Claims 4.1 注册声明，应用可以把它嵌入自己的声明结构。时间声明为 nil 时表示
+// 令牌中没有该声明，值为 0 的声明同样会被检查
+type Claims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  Audience     `json:"aud,omitempty"`
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+}
+
+// Expected 验证方对注册声明的要求
+type Expected struct {
+	// Issuer 非空时 iss 必须相同
+	Issuer string
+	// Audience 验证方自己的标识，非空时 aud 必须包含它
+	Audience string
+	// Time 验证时间，零值时使用 time.Now()
+	Time time.Time
+	// Leeway 比较 exp 和 nbf 时允许的时钟偏差
+	Leeway time.Duration
+}
+
+// Validate 按 e 检查注册声明
+func (c *Claims) Validate(e Expected) error {
+	now := e.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if c.ExpiresAt != nil && !now.Before(c.ExpiresAt.Time().Add(e.Leeway)) {
+		return ErrExpired
+	}
+	if c.NotBefore != nil && now.Add(e.Leeway).Before(c.NotBefore.Time()) {
+		return ErrNotValidYet
+	}
+	if e.Issuer != "" && c.Issuer != e.Issuer {
+		return ErrInvalidIssuer
+	}
+	if (e.Audience != "" || len(c.Audience) > 0) && (e.Audience == "" || !c.Audience.Contains(e.Audience)) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+// SignJWT 将 claims 编码为 JSON 并生成 JWT，claims 通常是嵌入了 Claims 的结构
+func (s *Signer) SignJWT(claims interface{}) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	return s.SignCompact(payload, &Header{Type: "JWT"})
+}
+
+// ParseJWT 验证 JWT 的签名和注册声明，返回注册声明。claims 不为 nil 时载荷同时
+// 解码到 claims 中
+func (v *Verifier) ParseJWT(token string, e Expected, claims interface{}) (*Claims, error) {
+	payload, header, err := v.VerifyCompact(token)
+	if err != nil {
+		return nil, err
+	}
+	if header.ContentType == "JWT" {
+		return nil, ErrInvalidToken
+	}
+	// 7.2 载荷必须是 JSON 对象
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &members); err != nil || members == nil {
+		return nil, ErrInvalidToken
+	}
+	// 解码到指针时 null 被当作没有该声明，这里拒绝
+	for _, name := range []string{"exp", "nbf", "iat"} {
+		if raw, ok := members[name]; ok && bytes.Equal(raw, []byte("null")) {
+			return nil, ErrInvalidToken
+		}
+	}
+	registered := new(Claims)
+	if err := json.Unmarshal(payload, registered); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := registered.Validate(e); err != nil {
+		return nil, err
+	}
+	if claims != nil {
+		if err := json.Unmarshal(payload, claims); err != nil {
+			return nil, ErrInvalidToken
+		}
+	}
+	return registered, nil
+}
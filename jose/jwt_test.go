@@ -0,0 +1,177 @@
+package jose
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/t1anchen/gogmlib/sm2"
+)
+
+func TestParseJWT(t *testing.T) {
+	v := NewVerifier(fixtureKey(t).GenPubKey(), nil)
+	now := time.Unix(1800000000, 0)
+	claims, err := v.ParseJWT(fixtureToken, Expected{Issuer: "https://issuer.example", Audience: "api", Time: now}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "alice" || *claims.ExpiresAt != 1893456000 || *claims.IssuedAt != 1767225600 {
+		t.Errorf("TestParseJWT 失败\n实际值=%+v", claims)
+	}
+
+	claims, err = v.ParseJWT(opensslToken, Expected{Issuer: "openssl", Audience: "web", Time: now}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claims.Audience) != 2 || *claims.NotBefore != 1767225600 {
+		t.Errorf("TestParseJWT 失败\n实际值=%+v", claims)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	exp := time.Unix(1893456000, 0)
+	nbf := time.Unix(1767225600, 0)
+	claims := &Claims{
+		Issuer:    "https://issuer.example",
+		Audience:  Audience{"api", "web"},
+		ExpiresAt: NewNumericDate(exp),
+		NotBefore: NewNumericDate(nbf),
+	}
+	valid := Expected{Issuer: "https://issuer.example", Audience: "api", Time: nbf}
+	tests := []struct {
+		name string
+		e    func(e Expected) Expected
+		err  error
+	}{
+		{"有效", func(e Expected) Expected { return e }, nil},
+		{"到期", func(e Expected) Expected { e.Time = exp; return e }, ErrExpired},
+		{"到期前一秒", func(e Expected) Expected { e.Time = exp.Add(-time.Second); return e }, nil},
+		{"到期后在偏差内", func(e Expected) Expected { e.Time = exp; e.Leeway = time.Minute; return e }, nil},
+		{"生效前", func(e Expected) Expected { e.Time = nbf.Add(-time.Second); return e }, ErrNotValidYet},
+		{"生效前在偏差内", func(e Expected) Expected { e.Time = nbf.Add(-time.Second); e.Leeway = time.Minute; return e }, nil},
+		{"签发者", func(e Expected) Expected { e.Issuer = "https://other.example"; return e }, ErrInvalidIssuer},
+		{"不检查签发者", func(e Expected) Expected { e.Issuer = ""; return e }, nil},
+		{"受众", func(e Expected) Expected { e.Audience = "admin"; return e }, ErrInvalidAudience},
+		{"没有指定受众", func(e Expected) Expected { e.Audience = ""; return e }, ErrInvalidAudience},
+	}
+	for _, tt := range tests {
+		if err := claims.Validate(tt.e(valid)); err != tt.err {
+			t.Errorf("TestValidate %s 失败\n期望值=%v\n实际值=%v", tt.name, tt.err, err)
+		}
+	}
+
+	// 令牌没有 aud 而验证方要求受众
+	if err := (&Claims{}).Validate(Expected{Audience: "api"}); err != ErrInvalidAudience {
+		t.Errorf("TestValidate 失败\n期望值=%v\n实际值=%v", ErrInvalidAudience, err)
+	}
+	if err := (&Claims{}).Validate(Expected{}); err != nil {
+		t.Errorf("TestValidate 失败: %v", err)
+	}
+
+	// 值为 0 的 exp 同样检查，不当作没有该声明
+	zero := NumericDate(0)
+	if err := (&Claims{ExpiresAt: &zero}).Validate(Expected{}); err != ErrExpired {
+		t.Errorf("TestValidate exp=0 失败\n期望值=%v\n实际值=%v", ErrExpired, err)
+	}
+}
+
+// TestParseJWTZeroDate 令牌中显式的 exp、nbf 即使为 0 或小于 1 也要检查
+func TestParseJWTZeroDate(t *testing.T) {
+	sk := fixtureKey(t)
+	v := NewVerifier(sk.GenPubKey(), nil)
+	now := time.Unix(1800000000, 0)
+	tests := []struct {
+		name    string
+		payload string
+		e       Expected
+		err     error
+	}{
+		{"exp 为 0", `{"exp":0}`, Expected{Time: now}, ErrExpired},
+		{"exp 为 0.9", `{"exp":0.9}`, Expected{Time: now}, ErrExpired},
+		{"exp 为 null", `{"exp":null}`, Expected{Time: now}, ErrInvalidToken},
+		{"nbf 为 0", `{"nbf":0}`, Expected{Time: time.Unix(-1, 0)}, ErrNotValidYet},
+		{"nbf 为 0 已生效", `{"nbf":0}`, Expected{Time: now}, nil},
+	}
+	for _, tt := range tests {
+		_, err := v.ParseJWT(signRaw(t, sk, `{"alg":"SM2SM3"}`, tt.payload), tt.e, nil)
+		if err != tt.err {
+			t.Errorf("TestParseJWTZeroDate %s 失败\n期望值=%v\n实际值=%v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func TestSignJWT(t *testing.T) {
+	type accessClaims struct {
+		Claims
+		Scope string `json:"scope"`
+	}
+	sk, _, err := sm2.GenKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	token, err := NewSigner(sk, nil).SignJWT(&accessClaims{
+		Claims: Claims{
+			Issuer:    "issuer",
+			Audience:  Audience{"api"},
+			ExpiresAt: NewNumericDate(now.Add(time.Hour)),
+			IssuedAt:  NewNumericDate(now),
+		},
+		Scope: "read write",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := NewVerifier(sk.GenPubKey(), nil)
+	var actual accessClaims
+	if _, err := v.ParseJWT(token, Expected{Issuer: "issuer", Audience: "api"}, &actual); err != nil {
+		t.Fatal(err)
+	}
+	if actual.Scope != "read write" || actual.Issuer != "issuer" {
+		t.Errorf("TestSignJWT 失败\n实际值=%+v", actual)
+	}
+	if _, err := v.ParseJWT(token, Expected{Issuer: "issuer", Audience: "api", Time: now.Add(2 * time.Hour)}, nil); err != ErrExpired {
+		t.Errorf("TestSignJWT 失败\n期望值=%v\n实际值=%v", ErrExpired, err)
+	}
+}
+
+func TestParseJWTInvalid(t *testing.T) {
+	sk := fixtureKey(t)
+	v := NewVerifier(sk.GenPubKey(), nil)
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"载荷不是对象", signRaw(t, sk, `{"alg":"SM2SM3"}`, `["iss"]`)},
+		{"载荷为 null", signRaw(t, sk, `{"alg":"SM2SM3"}`, `null`)},
+		{"exp 类型", signRaw(t, sk, `{"alg":"SM2SM3"}`, `{"exp":"1893456000"}`)},
+		{"aud 类型", signRaw(t, sk, `{"alg":"SM2SM3"}`, `{"aud":1}`)},
+		{"嵌套 JWT", signRaw(t, sk, `{"alg":"SM2SM3","cty":"JWT"}`, `{}`)},
+	}
+	for _, tt := range tests {
+		if _, err := v.ParseJWT(tt.token, Expected{}, nil); err != ErrInvalidToken {
+			t.Errorf("TestParseJWTInvalid %s 失败\n期望值=%v\n实际值=%v", tt.name, ErrInvalidToken, err)
+		}
+	}
+}
+
+func TestClaimsJSON(t *testing.T) {
+	data, err := json.Marshal(&Claims{Audience: Audience{"api"}, ExpiresAt: NewNumericDate(time.Unix(1893456000, 0))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"aud":"api","exp":1893456000}`
+	if string(data) != expected {
+		t.Errorf("TestClaimsJSON 失败\n期望值=%s\n实际值=%s", expected, data)
+	}
+
+	var c Claims
+	if err := json.Unmarshal([]byte(`{"aud":["a","b"],"exp":1893456000.75,"nbf":1.7672256e9}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Audience) != 2 || *c.ExpiresAt != 1893456000 || *c.NotBefore != 1767225600 || c.IssuedAt != nil {
+		t.Errorf("TestClaimsJSON 失败\n实际值=%+v", c)
+	}
+}
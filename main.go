@@ -1,10 +1,16 @@
 package main
 
 import (
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 
+	"github.com/t1anchen/gogmlib/randtest"
+	"github.com/t1anchen/gogmlib/registry"
+	"github.com/t1anchen/gogmlib/sm2"
+	"github.com/t1anchen/gogmlib/sm2/cert"
 	"github.com/t1anchen/gogmlib/sm3"
 	"github.com/t1anchen/gogmlib/utils"
 	"github.com/urfave/cli"
@@ -29,6 +35,72 @@ func main() {
 						return nil
 					},
 				},
+				{
+					Name:      "sign",
+					Usage:     "使用 PEM 私钥对文件签名，输出十六进制签名",
+					ArgsUsage: "[FILE]",
+					Flags:     sm2SignatureFlags("私钥 PEM 文件"),
+					Action: func(c *cli.Context) error {
+						format, err := parseSignatureFormat(c.String("format"))
+						if err != nil {
+							return err
+						}
+						keyPEM, err := ioutil.ReadFile(c.String("key"))
+						if err != nil {
+							return err
+						}
+						sk, err := cert.ParsePrivateKeyFromPEM(keyPEM)
+						if err != nil {
+							return err
+						}
+						msg, err := readInput(c)
+						if err != nil {
+							return err
+						}
+						sig, err := sk.SignWithFormat(userIDFlag(c), msg, format)
+						if err != nil {
+							return err
+						}
+						fmt.Println(hex.EncodeToString(sig))
+						return nil
+					},
+				},
+				{
+					Name:      "verify",
+					Usage:     "使用 PEM 公钥验证文件的十六进制签名",
+					ArgsUsage: "[FILE]",
+					Flags: append(sm2SignatureFlags("公钥 PEM 文件"), cli.StringFlag{
+						Name:  "sig",
+						Usage: "十六进制签名",
+					}),
+					Action: func(c *cli.Context) error {
+						format, err := parseSignatureFormat(c.String("format"))
+						if err != nil {
+							return err
+						}
+						keyPEM, err := ioutil.ReadFile(c.String("key"))
+						if err != nil {
+							return err
+						}
+						pk, err := cert.ParsePublicKeyFromPEM(keyPEM)
+						if err != nil {
+							return err
+						}
+						sig, err := hex.DecodeString(c.String("sig"))
+						if err != nil {
+							return err
+						}
+						msg, err := readInput(c)
+						if err != nil {
+							return err
+						}
+						if err := pk.CheckSignatureWithFormat(userIDFlag(c), msg, sig, format); err != nil {
+							return cli.NewExitError(err.Error(), 1)
+						}
+						fmt.Println("OK")
+						return nil
+					},
+				},
 			},
 		},
 		{
@@ -52,6 +124,58 @@ func main() {
 				},
 			},
 		},
+		{
+			Name: "rand",
+			Subcommands: []cli.Command{
+				{
+					Name:      "test",
+					Usage:     "对文件中的随机数进行 GM/T 0005 随机性检测",
+					ArgsUsage: "FILE",
+					Flags: []cli.Flag{
+						cli.Float64Flag{
+							Name:  "alpha",
+							Value: randtest.DefaultAlpha,
+							Usage: "显著性水平",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						if len(c.Args()) < 1 {
+							return cli.NewExitError("缺少 FILE 参数", 1)
+						}
+						data, err := ioutil.ReadFile(c.Args().First())
+						if err != nil {
+							return err
+						}
+						results := randtest.Run(randtest.BitsFromBytes(data), &randtest.Config{Alpha: c.Float64("alpha")})
+						for _, r := range results {
+							fmt.Println(r)
+						}
+						if randtest.Ran(results) == 0 {
+							return cli.NewExitError("样本过短，全部检测项都被跳过", 1)
+						}
+						if !randtest.Passed(results) {
+							return cli.NewExitError("随机性检测未通过", 1)
+						}
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name: "algo",
+			Subcommands: []cli.Command{
+				{
+					Name:  "list",
+					Usage: "列出已注册的算法标识",
+					Action: func(c *cli.Context) error {
+						for _, alg := range registry.All() {
+							fmt.Printf("%-16s %-14s %s\n", alg.Name, alg.Kind, alg.OID)
+						}
+						return nil
+					},
+				},
+			},
+		},
 	}
 
 	err := app.Run(os.Args)
@@ -59,3 +183,46 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// sm2SignatureFlags sm2 sign 和 verify 共用的参数
+func sm2SignatureFlags(keyUsage string) []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: keyUsage,
+		},
+		cli.StringFlag{
+			Name:  "id",
+			Usage: "用户身份标识，为空时使用默认值 1234567812345678",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Value: sm2.SignatureDER.String(),
+			Usage: "签名格式，der 或 raw（r || s）",
+		},
+	}
+}
+
+func parseSignatureFormat(name string) (sm2.SignatureFormat, error) {
+	for _, f := range []sm2.SignatureFormat{sm2.SignatureDER, sm2.SignatureRaw} {
+		if f.String() == name {
+			return f, nil
+		}
+	}
+	return 0, cli.NewExitError("未知的签名格式: "+name, 1)
+}
+
+func userIDFlag(c *cli.Context) []byte {
+	if id := c.String("id"); id != "" {
+		return []byte(id)
+	}
+	return nil
+}
+
+// readInput 读取第一个参数指定的文件，没有参数时读取标准输入
+func readInput(c *cli.Context) ([]byte, error) {
+	if len(c.Args()) < 1 {
+		return utils.ReadBytesFromStdinToBuffer().Bytes(), nil
+	}
+	return ioutil.ReadFile(c.Args().First())
+}
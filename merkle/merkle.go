@@ -0,0 +1,436 @@
+package merkle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+// -----------------------------------------------------------------------------
+// RFC 9162 2.1 Merkle 树，以 SM3 作为杂凑函数
+// -----------------------------------------------------------------------------
+
+// HashSize 节点杂凑值长度
+const HashSize = sm3.DigestSizeInByte
+
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+var (
+	ErrIndexOutOfRange   = errors.New("merkle: index out of range")
+	ErrInvalidTreeSize   = errors.New("merkle: invalid tree size")
+	ErrInvalidProof      = errors.New("merkle: invalid proof")
+	ErrRootMismatch      = errors.New("merkle: root mismatch")
+	ErrInvalidEncoding   = errors.New("merkle: invalid encoding")
+	ErrInvalidHashLength = errors.New("merkle: invalid hash length")
+)
+
+// HashEmpty 空树的根，即 SM3 空串
+func HashEmpty() []byte {
+	return sm3.New().Sum(nil)
+}
+
+// HashLeaf 叶子节点 SM3(0x00 || data)
+func HashLeaf(data []byte) []byte {
+	h := sm3.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// HashChildren 内部节点 SM3(0x01 || left || right)
+func HashChildren(left, right []byte) []byte {
+	h := sm3.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// Tree 只追加的 Merkle 树，保存全部叶子杂凑值和已经完整的子树杂凑值
+//
+// nodes[l][i] 为叶子 [i·2^l, (i+1)·2^l) 组成的完整子树的杂凑值，nodes[0] 即叶子。
+// 追加叶子时补齐新完整的子树，树根和证明只需组合 O(log n) 个缓存的子树，不再从
+// 叶子重新计算
+type Tree struct {
+	nodes [][][]byte
+}
+
+// New 生成空树
+func New() *Tree {
+	return &Tree{}
+}
+
+// Append 追加一条数据，返回其叶子序号
+func (t *Tree) Append(data []byte) uint64 {
+	index, _ := t.AppendLeafHash(HashLeaf(data))
+	return index
+}
+
+// AppendLeafHash 追加已经计算好的叶子杂凑值，长度不是 HashSize 时返回
+// ErrInvalidHashLength
+func (t *Tree) AppendLeafHash(leafHash []byte) (uint64, error) {
+	if len(leafHash) != HashSize {
+		return 0, ErrInvalidHashLength
+	}
+	h := make([]byte, len(leafHash))
+	copy(h, leafHash)
+	t.push(h)
+	return t.Size() - 1, nil
+}
+
+// push 追加叶子，并逐层合并由此完整的子树
+func (t *Tree) push(h []byte) {
+	for level := 0; ; level++ {
+		if level == len(t.nodes) {
+			t.nodes = append(t.nodes, nil)
+		}
+		t.nodes[level] = append(t.nodes[level], h)
+		row := t.nodes[level]
+		if len(row)&1 == 1 {
+			return
+		}
+		h = HashChildren(row[len(row)-2], row[len(row)-1])
+	}
+}
+
+// Size 叶子个数
+func (t *Tree) Size() uint64 {
+	if len(t.nodes) == 0 {
+		return 0
+	}
+	return uint64(len(t.nodes[0]))
+}
+
+// LeafHash 返回第 index 个叶子杂凑值的副本
+func (t *Tree) LeafHash(index uint64) ([]byte, error) {
+	if index >= t.Size() {
+		return nil, ErrIndexOutOfRange
+	}
+	return append([]byte(nil), t.nodes[0][index]...), nil
+}
+
+// Root 当前树根
+func (t *Tree) Root() []byte {
+	return t.mth(0, t.Size())
+}
+
+// RootAt 前 size 个叶子组成的树根
+func (t *Tree) RootAt(size uint64) ([]byte, error) {
+	if size > t.Size() {
+		return nil, ErrInvalidTreeSize
+	}
+	return t.mth(0, size), nil
+}
+
+// split 小于 n 的最大的 2 的幂
+func split(n uint64) uint64 {
+	k := uint64(1)
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// mth 2.1.1 叶子 [begin, end) 的 Merkle Tree Hash。按 2.1.1 递归时 begin 总是
+// 2^⌈log2 n⌉ 的倍数，叶子个数为 2 的幂的区间就是一棵缓存的完整子树，其余区间
+// 只沿右边缘计算 O(log n) 次。返回值不与树共享内存
+func (t *Tree) mth(begin, end uint64) []byte {
+	n := end - begin
+	if n == 0 {
+		return HashEmpty()
+	}
+	if n&(n-1) == 0 && begin%n == 0 {
+		level := bits.TrailingZeros64(n)
+		return append([]byte(nil), t.nodes[level][begin>>uint(level)]...)
+	}
+	k := split(n)
+	return HashChildren(t.mth(begin, begin+k), t.mth(begin+k, end))
+}
+
+// -----------------------------------------------------------------------------
+// RFC 9162 2.1.3 包含性证明
+// -----------------------------------------------------------------------------
+
+// InclusionProof 包含性证明
+type InclusionProof struct {
+	LeafIndex uint64
+	TreeSize  uint64
+	Path      [][]byte
+}
+
+// InclusionProof 生成第 index 个叶子在前 size 个叶子组成的树中的包含性证明
+func (t *Tree) InclusionProof(index, size uint64) (*InclusionProof, error) {
+	if size > t.Size() {
+		return nil, ErrInvalidTreeSize
+	}
+	if index >= size {
+		return nil, ErrIndexOutOfRange
+	}
+	return &InclusionProof{
+		LeafIndex: index,
+		TreeSize:  size,
+		Path:      t.path(index, 0, size),
+	}, nil
+}
+
+// path 2.1.3.1 PATH(m, D[begin:end])
+func (t *Tree) path(m, begin, end uint64) [][]byte {
+	n := end - begin
+	if n <= 1 {
+		return nil
+	}
+	k := split(n)
+	if m < k {
+		return append(t.path(m, begin, begin+k), t.mth(begin+k, end))
+	}
+	return append(t.path(m-k, begin+k, end), t.mth(begin, begin+k))
+}
+
+// VerifyInclusion 2.1.3.2 以叶子杂凑值验证包含性证明
+func VerifyInclusion(leafHash []byte, proof *InclusionProof, root []byte) error {
+	if proof == nil || !validPath(proof.Path) {
+		return ErrInvalidProof
+	}
+	if proof.LeafIndex >= proof.TreeSize {
+		return ErrIndexOutOfRange
+	}
+	fn, sn := proof.LeafIndex, proof.TreeSize-1
+	r := leafHash
+	for _, p := range proof.Path {
+		if sn == 0 {
+			return ErrInvalidProof
+		}
+		if fn&1 == 1 || fn == sn {
+			r = HashChildren(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = HashChildren(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return ErrInvalidProof
+	}
+	if !bytes.Equal(r, root) {
+		return ErrRootMismatch
+	}
+	return nil
+}
+
+// validPath 证明路径中的节点都是 HashSize 字节
+func validPath(path [][]byte) bool {
+	for _, h := range path {
+		if len(h) != HashSize {
+			return false
+		}
+	}
+	return true
+}
+
+// -----------------------------------------------------------------------------
+// RFC 9162 2.1.4 一致性证明
+// -----------------------------------------------------------------------------
+
+// ConsistencyProof 一致性证明
+type ConsistencyProof struct {
+	OldSize uint64
+	NewSize uint64
+	Path    [][]byte
+}
+
+// ConsistencyProof 生成前 oldSize 个叶子和前 newSize 个叶子组成的两棵树之间的一致性证明
+func (t *Tree) ConsistencyProof(oldSize, newSize uint64) (*ConsistencyProof, error) {
+	if newSize > t.Size() || oldSize > newSize {
+		return nil, ErrInvalidTreeSize
+	}
+	proof := &ConsistencyProof{OldSize: oldSize, NewSize: newSize}
+	if oldSize > 0 && oldSize < newSize {
+		proof.Path = t.subproof(oldSize, 0, newSize, true)
+	}
+	return proof, nil
+}
+
+// subproof 2.1.4.1 SUBPROOF(m, D[begin:end], b)
+func (t *Tree) subproof(m, begin, end uint64, b bool) [][]byte {
+	n := end - begin
+	if m == n {
+		if b {
+			return nil
+		}
+		return [][]byte{t.mth(begin, end)}
+	}
+	k := split(n)
+	if m <= k {
+		return append(t.subproof(m, begin, begin+k, b), t.mth(begin+k, end))
+	}
+	return append(t.subproof(m-k, begin+k, end, false), t.mth(begin, begin+k))
+}
+
+// VerifyConsistency 2.1.4.2 验证一致性证明
+func VerifyConsistency(proof *ConsistencyProof, oldRoot, newRoot []byte) error {
+	if proof == nil || !validPath(proof.Path) {
+		return ErrInvalidProof
+	}
+	if proof.OldSize > proof.NewSize {
+		return ErrInvalidTreeSize
+	}
+	if proof.OldSize == proof.NewSize || proof.OldSize == 0 {
+		if len(proof.Path) != 0 {
+			return ErrInvalidProof
+		}
+		if proof.OldSize == proof.NewSize && !bytes.Equal(oldRoot, newRoot) {
+			return ErrRootMismatch
+		}
+		return nil
+	}
+	if len(proof.Path) == 0 {
+		return ErrInvalidProof
+	}
+
+	path := proof.Path
+	if proof.OldSize&(proof.OldSize-1) == 0 {
+		path = append([][]byte{oldRoot}, path...)
+	}
+	fn, sn := proof.OldSize-1, proof.NewSize-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+	fr, sr := path[0], path[0]
+	for _, c := range path[1:] {
+		if sn == 0 {
+			return ErrInvalidProof
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = HashChildren(c, fr)
+			sr = HashChildren(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = HashChildren(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return ErrInvalidProof
+	}
+	if !bytes.Equal(fr, oldRoot) || !bytes.Equal(sr, newRoot) {
+		return ErrRootMismatch
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// 序列化
+//
+// 证明: uint64 大端 || uint64 大端 || uint16 路径节点个数 || 节点杂凑值...
+// 树:   uint64 大端叶子个数 || 叶子杂凑值...
+// -----------------------------------------------------------------------------
+
+func marshalProof(a, b uint64, path [][]byte) ([]byte, error) {
+	if len(path) > 0xffff {
+		return nil, ErrInvalidProof
+	}
+	out := make([]byte, 18, 18+len(path)*HashSize)
+	binary.BigEndian.PutUint64(out[0:], a)
+	binary.BigEndian.PutUint64(out[8:], b)
+	binary.BigEndian.PutUint16(out[16:], uint16(len(path)))
+	for _, h := range path {
+		if len(h) != HashSize {
+			return nil, ErrInvalidHashLength
+		}
+		out = append(out, h...)
+	}
+	return out, nil
+}
+
+func unmarshalProof(data []byte) (a, b uint64, path [][]byte, err error) {
+	if len(data) < 18 {
+		return 0, 0, nil, ErrInvalidEncoding
+	}
+	a = binary.BigEndian.Uint64(data[0:])
+	b = binary.BigEndian.Uint64(data[8:])
+	count := int(binary.BigEndian.Uint16(data[16:]))
+	rest := data[18:]
+	if len(rest) != count*HashSize {
+		return 0, 0, nil, ErrInvalidEncoding
+	}
+	path = make([][]byte, count)
+	for i := range path {
+		path[i] = append([]byte(nil), rest[i*HashSize:(i+1)*HashSize]...)
+	}
+	return a, b, path, nil
+}
+
+// MarshalBinary 实现 encoding.BinaryMarshaler
+func (p *InclusionProof) MarshalBinary() ([]byte, error) {
+	return marshalProof(p.LeafIndex, p.TreeSize, p.Path)
+}
+
+// UnmarshalBinary 实现 encoding.BinaryUnmarshaler
+func (p *InclusionProof) UnmarshalBinary(data []byte) error {
+	index, size, path, err := unmarshalProof(data)
+	if err != nil {
+		return err
+	}
+	p.LeafIndex, p.TreeSize, p.Path = index, size, path
+	return nil
+}
+
+// MarshalBinary 实现 encoding.BinaryMarshaler
+func (p *ConsistencyProof) MarshalBinary() ([]byte, error) {
+	return marshalProof(p.OldSize, p.NewSize, p.Path)
+}
+
+// UnmarshalBinary 实现 encoding.BinaryUnmarshaler
+func (p *ConsistencyProof) UnmarshalBinary(data []byte) error {
+	oldSize, newSize, path, err := unmarshalProof(data)
+	if err != nil {
+		return err
+	}
+	p.OldSize, p.NewSize, p.Path = oldSize, newSize, path
+	return nil
+}
+
+// MarshalBinary 实现 encoding.BinaryMarshaler
+func (t *Tree) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 8, 8+t.Size()*HashSize)
+	binary.BigEndian.PutUint64(out, t.Size())
+	if len(t.nodes) > 0 {
+		for _, h := range t.nodes[0] {
+			out = append(out, h...)
+		}
+	}
+	return out, nil
+}
+
+// UnmarshalBinary 实现 encoding.BinaryUnmarshaler
+func (t *Tree) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return ErrInvalidEncoding
+	}
+	size := binary.BigEndian.Uint64(data)
+	rest := data[8:]
+	if uint64(len(rest))/HashSize != size || len(rest)%HashSize != 0 {
+		return ErrInvalidEncoding
+	}
+	restored := New()
+	for i := uint64(0); i < size; i++ {
+		restored.push(append([]byte(nil), rest[i*HashSize:(i+1)*HashSize]...))
+	}
+	t.nodes = restored.nodes
+	return nil
+}
@@ -0,0 +1,285 @@
+package merkle
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+const testMaxSize = 33
+
+func buildTree(n int) *Tree {
+	t := New()
+	for i := 0; i < n; i++ {
+		t.Append([]byte(fmt.Sprintf("entry-%d", i)))
+	}
+	return t
+}
+
+func TestHashEmpty(t *testing.T) {
+	expected, _ := hex.DecodeString("1ab21d8355cfa17f8e61194831e81a8f22bec8c728fefb747ed035eb5082aa2b")
+	actual := New().Root()
+	if !bytes.Equal(expected, actual) {
+		t.Errorf(`TestHashEmpty 失败
+期望值=%x
+实际值=%x`, expected, actual)
+	}
+}
+
+func TestHashLeaf(t *testing.T) {
+	expected, _ := hex.DecodeString("2daef60e7a0b8f5e024c81cd2ab3109f2b4f155cf83adeb2ae5532f74a157fdf")
+	actual := HashLeaf(nil)
+	if !bytes.Equal(expected, actual) {
+		t.Errorf(`TestHashLeaf 失败
+期望值=%x
+实际值=%x`, expected, actual)
+	}
+}
+
+func TestRoot(t *testing.T) {
+	tree := New()
+	for _, s := range []string{"a", "b", "c"} {
+		tree.Append([]byte(s))
+	}
+	expected, _ := hex.DecodeString("2706e4e4d41c1ed9c3fe7f7822bf360a67abcc052cc2c00022c1313ec3ded965")
+	actual := tree.Root()
+	if !bytes.Equal(expected, actual) {
+		t.Errorf(`TestRoot 失败
+期望值=%x
+实际值=%x`, expected, actual)
+	}
+}
+
+func TestInclusionProof(t *testing.T) {
+	tree := buildTree(testMaxSize)
+	for size := uint64(1); size <= testMaxSize; size++ {
+		root, _ := tree.RootAt(size)
+		for index := uint64(0); index < size; index++ {
+			proof, err := tree.InclusionProof(index, size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			leaf, _ := tree.LeafHash(index)
+			if err := VerifyInclusion(leaf, proof, root); err != nil {
+				t.Errorf("TestInclusionProof 失败: index=%d size=%d: %v", index, size, err)
+			}
+			// 篡改叶子
+			if err := VerifyInclusion(HashLeaf([]byte("forged")), proof, root); err == nil {
+				t.Errorf("TestInclusionProof 失败: 伪造叶子通过验证 index=%d size=%d", index, size)
+			}
+			// 篡改路径
+			if len(proof.Path) > 0 {
+				proof.Path[0] = HashLeaf([]byte("forged"))
+				if err := VerifyInclusion(leaf, proof, root); err == nil {
+					t.Errorf("TestInclusionProof 失败: 伪造路径通过验证 index=%d size=%d", index, size)
+				}
+			}
+		}
+	}
+}
+
+func TestInclusionProofWrongSize(t *testing.T) {
+	tree := buildTree(10)
+	root := tree.Root()
+	leaf, _ := tree.LeafHash(3)
+	proof, _ := tree.InclusionProof(3, 10)
+	proof.TreeSize = 17
+	if err := VerifyInclusion(leaf, proof, root); err == nil {
+		t.Error("TestInclusionProofWrongSize 失败")
+	}
+	if _, err := tree.InclusionProof(10, 10); err != ErrIndexOutOfRange {
+		t.Errorf("TestInclusionProofWrongSize 失败: %v", err)
+	}
+	if _, err := tree.InclusionProof(0, 11); err != ErrInvalidTreeSize {
+		t.Errorf("TestInclusionProofWrongSize 失败: %v", err)
+	}
+}
+
+func TestConsistencyProof(t *testing.T) {
+	tree := buildTree(testMaxSize)
+	for newSize := uint64(0); newSize <= testMaxSize; newSize++ {
+		newRoot, _ := tree.RootAt(newSize)
+		for oldSize := uint64(0); oldSize <= newSize; oldSize++ {
+			oldRoot, _ := tree.RootAt(oldSize)
+			proof, err := tree.ConsistencyProof(oldSize, newSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyConsistency(proof, oldRoot, newRoot); err != nil {
+				t.Errorf("TestConsistencyProof 失败: old=%d new=%d: %v", oldSize, newSize, err)
+			}
+			if oldSize == 0 || oldSize == newSize {
+				continue
+			}
+			if err := VerifyConsistency(proof, HashLeaf([]byte("forged")), newRoot); err == nil {
+				t.Errorf("TestConsistencyProof 失败: 伪造旧根通过验证 old=%d new=%d", oldSize, newSize)
+			}
+			if err := VerifyConsistency(proof, oldRoot, HashLeaf([]byte("forged"))); err == nil {
+				t.Errorf("TestConsistencyProof 失败: 伪造新根通过验证 old=%d new=%d", oldSize, newSize)
+			}
+		}
+	}
+}
+
+func TestConsistencyProofForkedLog(t *testing.T) {
+	tree := buildTree(7)
+	oldRoot := tree.Root()
+
+	forked := buildTree(6)
+	forked.Append([]byte("rewritten"))
+	forked.Append([]byte("entry-7"))
+	proof, _ := forked.ConsistencyProof(7, 8)
+	if err := VerifyConsistency(proof, oldRoot, forked.Root()); err == nil {
+		t.Error("TestConsistencyProofForkedLog 失败")
+	}
+}
+
+func TestProofMarshalBinary(t *testing.T) {
+	tree := buildTree(21)
+
+	inclusion, _ := tree.InclusionProof(13, 21)
+	data, err := inclusion.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 18+len(inclusion.Path)*HashSize {
+		t.Errorf("TestProofMarshalBinary 失败: 长度 %d", len(data))
+	}
+	var decoded InclusionProof
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := tree.LeafHash(13)
+	if err := VerifyInclusion(leaf, &decoded, tree.Root()); err != nil {
+		t.Errorf("TestProofMarshalBinary 失败: %v", err)
+	}
+
+	consistency, _ := tree.ConsistencyProof(5, 21)
+	data, _ = consistency.MarshalBinary()
+	var decodedConsistency ConsistencyProof
+	if err := decodedConsistency.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	oldRoot, _ := tree.RootAt(5)
+	if err := VerifyConsistency(&decodedConsistency, oldRoot, tree.Root()); err != nil {
+		t.Errorf("TestProofMarshalBinary 失败: %v", err)
+	}
+
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err != ErrInvalidEncoding {
+		t.Errorf("TestProofMarshalBinary 失败: %v", err)
+	}
+}
+
+func TestTreeMarshalBinary(t *testing.T) {
+	tree := buildTree(11)
+	data, err := tree.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := New()
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Size() != tree.Size() || !bytes.Equal(restored.Root(), tree.Root()) {
+		t.Errorf(`TestTreeMarshalBinary 失败
+期望值=%x
+实际值=%x`, tree.Root(), restored.Root())
+	}
+	if err := restored.UnmarshalBinary(data[:len(data)-1]); err != ErrInvalidEncoding {
+		t.Errorf("TestTreeMarshalBinary 失败: %v", err)
+	}
+}
+
+func TestAppendLeafHashLength(t *testing.T) {
+	tree := New()
+	for _, h := range [][]byte{nil, make([]byte, HashSize-1), make([]byte, HashSize+1)} {
+		if _, err := tree.AppendLeafHash(h); err != ErrInvalidHashLength {
+			t.Errorf("TestAppendLeafHashLength 失败\n期望值=%v\n实际值=%v", ErrInvalidHashLength, err)
+		}
+	}
+	if index, err := tree.AppendLeafHash(HashLeaf([]byte("a"))); err != nil || index != 0 {
+		t.Errorf("TestAppendLeafHashLength 失败: index=%d err=%v", index, err)
+	}
+	data, _ := tree.MarshalBinary()
+	if err := New().UnmarshalBinary(data); err != nil {
+		t.Errorf("TestAppendLeafHashLength 失败: %v", err)
+	}
+}
+
+func TestVerifyInvalidProof(t *testing.T) {
+	tree := buildTree(8)
+	leaf, _ := tree.LeafHash(3)
+	if err := VerifyInclusion(leaf, nil, tree.Root()); err != ErrInvalidProof {
+		t.Errorf("TestVerifyInvalidProof 失败\n期望值=%v\n实际值=%v", ErrInvalidProof, err)
+	}
+	if err := VerifyConsistency(nil, tree.Root(), tree.Root()); err != ErrInvalidProof {
+		t.Errorf("TestVerifyInvalidProof 失败\n期望值=%v\n实际值=%v", ErrInvalidProof, err)
+	}
+
+	inclusion, _ := tree.InclusionProof(3, 8)
+	inclusion.Path[1] = inclusion.Path[1][:HashSize-1]
+	if err := VerifyInclusion(leaf, inclusion, tree.Root()); err != ErrInvalidProof {
+		t.Errorf("TestVerifyInvalidProof 失败\n期望值=%v\n实际值=%v", ErrInvalidProof, err)
+	}
+	oldRoot, _ := tree.RootAt(3)
+	consistency, _ := tree.ConsistencyProof(3, 8)
+	consistency.Path[0] = nil
+	if err := VerifyConsistency(consistency, oldRoot, tree.Root()); err != ErrInvalidProof {
+		t.Errorf("TestVerifyInvalidProof 失败\n期望值=%v\n实际值=%v", ErrInvalidProof, err)
+	}
+}
+
+// TestLeafHashCopy 修改 LeafHash 的返回值不影响树
+func TestLeafHashCopy(t *testing.T) {
+	tree := buildTree(4)
+	root := tree.Root()
+	leaf, _ := tree.LeafHash(1)
+	leaf[0] ^= 0xff
+	if actual := tree.Root(); !bytes.Equal(actual, root) {
+		t.Errorf("TestLeafHashCopy 失败\n期望值=%x\n实际值=%x", root, actual)
+	}
+}
+
+// referenceMTH 直接按 RFC 9162 2.1.1 从叶子递归计算，检查缓存的子树杂凑值
+func referenceMTH(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		return HashEmpty()
+	case 1:
+		return leaves[0]
+	}
+	k := split(uint64(len(leaves)))
+	return HashChildren(referenceMTH(leaves[:k]), referenceMTH(leaves[k:]))
+}
+
+func TestRootAtReference(t *testing.T) {
+	tree := buildTree(testMaxSize)
+	data, _ := tree.MarshalBinary()
+	restored := New()
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	var leaves [][]byte
+	for size := uint64(0); size <= testMaxSize; size++ {
+		expected := referenceMTH(leaves)
+		for _, tr := range []*Tree{tree, restored} {
+			if actual, _ := tr.RootAt(size); !bytes.Equal(actual, expected) {
+				t.Errorf("TestRootAtReference 失败: size=%d\n期望值=%x\n实际值=%x", size, expected, actual)
+			}
+		}
+		if size < testMaxSize {
+			leaf, _ := tree.LeafHash(size)
+			leaves = append(leaves, leaf)
+		}
+	}
+}
+
+func BenchmarkInclusionProof(b *testing.B) {
+	tree := buildTree(1 << 16)
+	size := tree.Size()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.InclusionProof(uint64(i)%size, size)
+	}
+}
@@ -0,0 +1,43 @@
+package pbkdf2
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"hash"
+)
+
+// -----------------------------------------------------------------------------
+// RFC 8018 5.2 PBKDF2
+// -----------------------------------------------------------------------------
+
+// Key 以 HMAC(h) 作为伪随机函数，从口令和盐派生 keyLen 字节的密钥，iter 为迭代次数
+func Key(password, salt []byte, iter, keyLen int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, password)
+	hLen := prf.Size()
+	blocks := (keyLen + hLen - 1) / hLen
+
+	var counter [4]byte
+	out := make([]byte, 0, blocks*hLen)
+	u := make([]byte, hLen)
+	for i := 1; i <= blocks; i++ {
+		// U_1 = PRF(P, S || INT(i))
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(counter[:], uint32(i))
+		prf.Write(counter[:])
+		out = prf.Sum(out)
+		t := out[len(out)-hLen:]
+		copy(u, t)
+
+		// T_i = U_1 ^ U_2 ^ ... ^ U_c
+		for n := 2; n <= iter; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range u {
+				t[j] ^= u[j]
+			}
+		}
+	}
+	return out[:keyLen]
+}
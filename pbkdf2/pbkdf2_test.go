@@ -0,0 +1,42 @@
+package pbkdf2
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"hash"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+var testCases = []struct {
+	name     string
+	h        func() hash.Hash
+	password string
+	salt     string
+	iter     int
+	expected string
+}{
+	// RFC 6070 2 PBKDF2 HMAC-SHA1 测试向量
+	{"SHA1", sha1.New, "password", "salt", 1, "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+	{"SHA1", sha1.New, "password", "salt", 4096, "4b007901b765489abead49d926f721d065a429c1"},
+	{"SHA1", sha1.New, "passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096,
+		"3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038"},
+	{"SHA1", sha1.New, "pass\x00word", "sa\x00lt", 4096, "56fa6aa75548099dcc37d7f03425e0c3"},
+	// HMAC-SM3，由 openssl kdf -kdfopt digest:SM3 PBKDF2 生成
+	{"SM3", sm3.New, "password", "salt", 1, "4612f922a1fdcefaf4312fc6f8f3322b489cbf24f2ea361b44c2bd8fa2c6dcb0"},
+	{"SM3", sm3.New, "password", "salt", 2, "fee723a2bc966e11dffb66133f4e8df577383c78ade30e3298edbd3e54ed85b7"},
+	{"SM3", sm3.New, "password", "salt", 4096, "b6e8f2074c87432b78f62e5ced980fdff89e86af2f693dab1638e2b3683045dd"},
+	{"SM3", sm3.New, "passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096,
+		"3b6282ac8519f059e465abff0ea37b0dbfe6c672a76e6b805312d53900db630732ccc1a88fa5512a6e8bbd7e48d336632a25"},
+}
+
+func TestKey(t *testing.T) {
+	for _, c := range testCases {
+		expected, _ := hex.DecodeString(c.expected)
+		actual := Key([]byte(c.password), []byte(c.salt), c.iter, len(expected), c.h)
+		if hex.EncodeToString(actual) != c.expected {
+			t.Errorf("TestKey %s 失败\n期望值=%s\n实际值=%x", c.name, c.expected, actual)
+		}
+	}
+}
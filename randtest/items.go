@@ -0,0 +1,504 @@
+package randtest
+
+import (
+	"math"
+)
+
+// -----------------------------------------------------------------------------
+// GM/T 0005-2021 随机性检测规范 5 检测项目
+//
+// 输入 bits 的每个元素为 0 或 1，返回值为 P-value
+// -----------------------------------------------------------------------------
+
+// Frequency 5.1 单比特频数检测
+func Frequency(bits []byte) float64 {
+	n := len(bits)
+	s := 0
+	for _, b := range bits {
+		s += 2*int(b) - 1
+	}
+	v := math.Abs(float64(s)) / math.Sqrt(float64(n))
+	return math.Erfc(v / math.Sqrt2)
+}
+
+// BlockFrequency 5.2 块内频数检测，m 为子序列长度
+func BlockFrequency(bits []byte, m int) float64 {
+	blocks := len(bits) / m
+	v := 0.0
+	for i := 0; i < blocks; i++ {
+		ones := 0
+		for _, b := range bits[i*m : (i+1)*m] {
+			ones += int(b)
+		}
+		pi := float64(ones)/float64(m) - 0.5
+		v += pi * pi
+	}
+	v *= 4 * float64(m)
+	return igamc(float64(blocks)/2, v/2)
+}
+
+// Poker 5.3 扑克检测，m 为子序列长度
+func Poker(bits []byte, m int) float64 {
+	blocks := len(bits) / m
+	counts := make([]int, 1<<uint(m))
+	for i := 0; i < blocks; i++ {
+		counts[pattern(bits[i*m:], m)]++
+	}
+	sum := 0.0
+	for _, c := range counts {
+		sum += float64(c) * float64(c)
+	}
+	v := float64(len(counts))/float64(blocks)*sum - float64(blocks)
+	return igamc(float64(len(counts)-1)/2, v/2)
+}
+
+// pattern 将 bits[:m] 视为大端整数
+func pattern(bits []byte, m int) int {
+	p := 0
+	for _, b := range bits[:m] {
+		p = p<<1 | int(b)
+	}
+	return p
+}
+
+// psiSquare 重叠子序列检测中的 ψ²m，序列首尾相接
+func psiSquare(bits []byte, m int) float64 {
+	if m <= 0 {
+		return 0
+	}
+	n := len(bits)
+	counts := make([]int, 1<<uint(m))
+	mask := len(counts) - 1
+	p := pattern(bits, m-1)
+	for i := 0; i < n; i++ {
+		p = (p<<1 | int(bits[(i+m-1)%n])) & mask
+		counts[p]++
+	}
+	sum := 0.0
+	for _, c := range counts {
+		sum += float64(c) * float64(c)
+	}
+	return float64(len(counts))/float64(n)*sum - float64(n)
+}
+
+// Serial 5.4 重叠子序列检测，m 为子序列长度，返回两个 P-value
+func Serial(bits []byte, m int) (p1, p2 float64) {
+	psi0 := psiSquare(bits, m)
+	psi1 := psiSquare(bits, m-1)
+	psi2 := psiSquare(bits, m-2)
+	d1 := psi0 - psi1
+	d2 := psi0 - 2*psi1 + psi2
+	p1 = igamc(math.Pow(2, float64(m-2)), d1/2)
+	p2 = igamc(math.Pow(2, float64(m-3)), d2/2)
+	return
+}
+
+// Runs 5.5 游程总数检测
+func Runs(bits []byte) float64 {
+	n := len(bits)
+	ones := 0
+	for _, b := range bits {
+		ones += int(b)
+	}
+	pi := float64(ones) / float64(n)
+	vn := 1
+	for i := 0; i < n-1; i++ {
+		if bits[i] != bits[i+1] {
+			vn++
+		}
+	}
+	num := math.Abs(float64(vn) - 2*float64(n)*pi*(1-pi))
+	den := 2 * math.Sqrt(2*float64(n)) * pi * (1 - pi)
+	return math.Erfc(num / den)
+}
+
+// RunDistribution 5.6 游程分布检测
+func RunDistribution(bits []byte) float64 {
+	n := float64(len(bits))
+	expected := func(i int) float64 {
+		return (n - float64(i) + 3) / math.Pow(2, float64(i+2))
+	}
+	k := 1
+	for expected(k+1) >= 5 {
+		k++
+	}
+
+	zeros := make([]int, k+1)
+	ones := make([]int, k+1)
+	run := 1
+	record := func(bit byte, length int) {
+		if length > k {
+			return
+		}
+		if bit == 0 {
+			zeros[length]++
+		} else {
+			ones[length]++
+		}
+	}
+	for i := 1; i < len(bits); i++ {
+		if bits[i] == bits[i-1] {
+			run++
+			continue
+		}
+		record(bits[i-1], run)
+		run = 1
+	}
+	record(bits[len(bits)-1], run)
+
+	v := 0.0
+	for i := 1; i <= k; i++ {
+		e := expected(i)
+		v += (float64(zeros[i])-e)*(float64(zeros[i])-e)/e +
+			(float64(ones[i])-e)*(float64(ones[i])-e)/e
+	}
+	return igamc(float64(k-1), v/2)
+}
+
+// longestRunTable 块内最大游程检测的分类边界和概率
+type longestRunTable struct {
+	m         int
+	min       int
+	minBlocks int
+	probs     []float64
+}
+
+var longestRunTables = []longestRunTable{
+	{8, 1, 16, []float64{0.2148, 0.3672, 0.2305, 0.1875}},
+	{128, 4, 49, []float64{0.1174, 0.2430, 0.2493, 0.1752, 0.1027, 0.1124}},
+	{10000, 10, 75, []float64{0.0882, 0.2092, 0.2483, 0.1933, 0.1208, 0.0675, 0.0727}},
+}
+
+func findLongestRunTable(m int) *longestRunTable {
+	for i := range longestRunTables {
+		if longestRunTables[i].m == m {
+			return &longestRunTables[i]
+		}
+	}
+	return nil
+}
+
+// LongestRun 5.7 块内最大游程检测，m 取 8、128 或 10000，bit 为统计的游程比特
+func LongestRun(bits []byte, m int, bit byte) float64 {
+	table := findLongestRunTable(m)
+	if table == nil {
+		return math.NaN()
+	}
+
+	blocks := len(bits) / m
+	counts := make([]int, len(table.probs))
+	for i := 0; i < blocks; i++ {
+		longest, run := 0, 0
+		for _, b := range bits[i*m : (i+1)*m] {
+			if b == bit {
+				run++
+				if run > longest {
+					longest = run
+				}
+			} else {
+				run = 0
+			}
+		}
+		idx := longest - table.min
+		if idx < 0 {
+			idx = 0
+		} else if idx >= len(counts) {
+			idx = len(counts) - 1
+		}
+		counts[idx]++
+	}
+
+	v := 0.0
+	for i, p := range table.probs {
+		e := float64(blocks) * p
+		v += (float64(counts[i]) - e) * (float64(counts[i]) - e) / e
+	}
+	return igamc(float64(len(table.probs)-1)/2, v/2)
+}
+
+// BinaryDerivation 5.8 二元推导检测，k 为推导次数
+func BinaryDerivation(bits []byte, k int) float64 {
+	derived := make([]byte, len(bits))
+	copy(derived, bits)
+	n := len(bits)
+	for j := 0; j < k; j++ {
+		n--
+		for i := 0; i < n; i++ {
+			derived[i] ^= derived[i+1]
+		}
+	}
+	return Frequency(derived[:n])
+}
+
+// Autocorrelation 5.9 自相关检测，d 为移位长度
+func Autocorrelation(bits []byte, d int) float64 {
+	n := len(bits) - d
+	a := 0
+	for i := 0; i < n; i++ {
+		a += int(bits[i] ^ bits[i+d])
+	}
+	v := 2 * (float64(a) - float64(n)/2) / math.Sqrt(float64(n))
+	return math.Erfc(math.Abs(v) / math.Sqrt2)
+}
+
+// rankMatrixSize 矩阵秩检测中矩阵的行列数
+const rankMatrixSize = 32
+
+// Rank 5.10 矩阵秩检测，使用 32×32 矩阵
+func Rank(bits []byte) float64 {
+	const q = rankMatrixSize
+	blocks := len(bits) / (q * q)
+	full, minus1 := 0, 0
+	for i := 0; i < blocks; i++ {
+		var rows [q]uint32
+		for r := 0; r < q; r++ {
+			for c := 0; c < q; c++ {
+				rows[r] = rows[r]<<1 | uint32(bits[i*q*q+r*q+c])
+			}
+		}
+		switch binaryRank(rows[:]) {
+		case q:
+			full++
+		case q - 1:
+			minus1++
+		}
+	}
+	n := float64(blocks)
+	pFull, pMinus1, pRest := 0.2888, 0.5776, 0.1336
+	rest := float64(blocks - full - minus1)
+	chi := (float64(full)-pFull*n)*(float64(full)-pFull*n)/(pFull*n) +
+		(float64(minus1)-pMinus1*n)*(float64(minus1)-pMinus1*n)/(pMinus1*n) +
+		(rest-pRest*n)*(rest-pRest*n)/(pRest*n)
+	return math.Exp(-chi / 2)
+}
+
+// binaryRank GF(2) 上矩阵的秩，每行以 uint32 表示
+func binaryRank(rows []uint32) int {
+	rank := 0
+	for col := 31; col >= 0 && rank < len(rows); col-- {
+		mask := uint32(1) << uint(col)
+		pivot := -1
+		for r := rank; r < len(rows); r++ {
+			if rows[r]&mask != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		rows[rank], rows[pivot] = rows[pivot], rows[rank]
+		for r := 0; r < len(rows); r++ {
+			if r != rank && rows[r]&mask != 0 {
+				rows[r] ^= rows[rank]
+			}
+		}
+		rank++
+	}
+	return rank
+}
+
+// CumulativeSums 5.11 累加和检测，返回前向和后向两个 P-value
+func CumulativeSums(bits []byte) (forward, backward float64) {
+	n := len(bits)
+	maxAbs := func(reverse bool) int {
+		s, z := 0, 0
+		for i := 0; i < n; i++ {
+			b := bits[i]
+			if reverse {
+				b = bits[n-1-i]
+			}
+			s += 2*int(b) - 1
+			if s > z {
+				z = s
+			} else if -s > z {
+				z = -s
+			}
+		}
+		return z
+	}
+	return cusumPValue(n, maxAbs(false)), cusumPValue(n, maxAbs(true))
+}
+
+// cusumPValue 求和上下限与 NIST 参考实现一致，按整数除法截断
+func cusumPValue(n, z int) float64 {
+	if z == 0 {
+		return 0
+	}
+	sqrtN := math.Sqrt(float64(n))
+	fz := float64(z)
+	sum1 := 0.0
+	for k := (-n/z + 1) / 4; k <= (n/z-1)/4; k++ {
+		fk := float64(k)
+		sum1 += normalCDF((4*fk+1)*fz/sqrtN) - normalCDF((4*fk-1)*fz/sqrtN)
+	}
+	sum2 := 0.0
+	for k := (-n/z - 3) / 4; k <= (n/z-1)/4; k++ {
+		fk := float64(k)
+		sum2 += normalCDF((4*fk+3)*fz/sqrtN) - normalCDF((4*fk+1)*fz/sqrtN)
+	}
+	return 1 - sum1 + sum2
+}
+
+// phi 近似熵检测中的 φm，序列首尾相接
+func phi(bits []byte, m int) float64 {
+	if m <= 0 {
+		return 0
+	}
+	n := len(bits)
+	counts := make([]int, 1<<uint(m))
+	mask := len(counts) - 1
+	p := pattern(bits, m-1)
+	for i := 0; i < n; i++ {
+		p = (p<<1 | int(bits[(i+m-1)%n])) & mask
+		counts[p]++
+	}
+	sum := 0.0
+	for _, c := range counts {
+		if c > 0 {
+			pi := float64(c) / float64(n)
+			sum += pi * math.Log(pi)
+		}
+	}
+	return sum
+}
+
+// ApproximateEntropy 5.12 近似熵检测，m 为子序列长度
+func ApproximateEntropy(bits []byte, m int) float64 {
+	n := float64(len(bits))
+	apEn := phi(bits, m) - phi(bits, m+1)
+	chi := 2 * n * (math.Ln2 - apEn)
+	return igamc(math.Pow(2, float64(m-1)), chi/2)
+}
+
+// LinearComplexity 5.13 线性复杂度检测，m 为子序列长度
+func LinearComplexity(bits []byte, m int) float64 {
+	probs := []float64{0.010417, 0.03125, 0.125, 0.5, 0.25, 0.0625, 0.020833}
+	blocks := len(bits) / m
+	fm := float64(m)
+	sign := 1.0
+	if m%2 == 1 {
+		sign = -1.0
+	}
+	mu := fm/2 + (9-sign)/36 - (fm/3+2.0/9)/math.Pow(2, fm)
+
+	counts := make([]int, len(probs))
+	for i := 0; i < blocks; i++ {
+		l := berlekampMassey(bits[i*m : (i+1)*m])
+		t := sign*(float64(l)-mu) + 2.0/9
+		switch {
+		case t <= -2.5:
+			counts[0]++
+		case t <= -1.5:
+			counts[1]++
+		case t <= -0.5:
+			counts[2]++
+		case t <= 0.5:
+			counts[3]++
+		case t <= 1.5:
+			counts[4]++
+		case t <= 2.5:
+			counts[5]++
+		default:
+			counts[6]++
+		}
+	}
+	chi := 0.0
+	for i, p := range probs {
+		e := float64(blocks) * p
+		chi += (float64(counts[i]) - e) * (float64(counts[i]) - e) / e
+	}
+	return igamc(float64(len(probs)-1)/2, chi/2)
+}
+
+// berlekampMassey 返回序列的线性复杂度
+func berlekampMassey(s []byte) int {
+	n := len(s)
+	c := make([]byte, n+1)
+	b := make([]byte, n+1)
+	t := make([]byte, n+1)
+	c[0], b[0] = 1, 1
+	l, m := 0, -1
+	for i := 0; i < n; i++ {
+		d := s[i]
+		for j := 1; j <= l; j++ {
+			d ^= c[j] & s[i-j]
+		}
+		if d == 0 {
+			continue
+		}
+		copy(t, c)
+		for j := 0; j+i-m <= n; j++ {
+			c[j+i-m] ^= b[j]
+		}
+		if 2*l <= i {
+			l = i + 1 - l
+			m = i
+			copy(b, t)
+		}
+	}
+	return l
+}
+
+// universalTable L 取 1 到 16 时 fn 的期望值和方差
+var universalTable = [17][2]float64{
+	{0, 0},
+	{0.7326495, 0.690}, {1.5374383, 1.338}, {2.4016068, 1.901}, {3.3112247, 2.358},
+	{4.2534266, 2.705}, {5.2177052, 2.954}, {6.1962507, 3.125}, {7.1836656, 3.238},
+	{8.1764248, 3.311}, {9.1723243, 3.356}, {10.170032, 3.384}, {11.168765, 3.401},
+	{12.168070, 3.410}, {13.167693, 3.416}, {14.167488, 3.419}, {15.167379, 3.421},
+}
+
+// Universal 5.14 Maurer 通用统计检测，l 为子序列长度，q 为初始化序列的子序列个数
+func Universal(bits []byte, l, q int) float64 {
+	if l < 1 || l >= len(universalTable) {
+		return math.NaN()
+	}
+	k := len(bits)/l - q
+	fn := universalStatistic(bits, l, q)
+
+	expected, variance := universalTable[l][0], universalTable[l][1]
+	fl, fk := float64(l), float64(k)
+	c := 0.7 - 0.8/fl + (4+32/fl)*math.Pow(fk, -3/fl)/15
+	sigma := c * math.Sqrt(variance/fk)
+	return math.Erfc(math.Abs(fn-expected) / (math.Sqrt2 * sigma))
+}
+
+// universalStatistic 统计量 fn
+func universalStatistic(bits []byte, l, q int) float64 {
+	total := len(bits) / l
+	last := make([]int, 1<<uint(l))
+	for i := 1; i <= q; i++ {
+		last[pattern(bits[(i-1)*l:], l)] = i
+	}
+	sum := 0.0
+	for i := q + 1; i <= total; i++ {
+		p := pattern(bits[(i-1)*l:], l)
+		sum += math.Log2(float64(i - last[p]))
+		last[p] = i
+	}
+	return sum / float64(total-q)
+}
+
+// DFT 5.15 离散傅里叶检测
+func DFT(bits []byte) float64 {
+	n := len(bits)
+	x := make([]complex128, n)
+	for i, b := range bits {
+		x[i] = complex(2*float64(b)-1, 0)
+	}
+	f := fft(x)
+
+	fn := float64(n)
+	threshold := math.Sqrt(2.995732274 * fn)
+	n1 := 0
+	for j := 0; j < n/2; j++ {
+		re, im := real(f[j]), imag(f[j])
+		if math.Sqrt(re*re+im*im) < threshold {
+			n1++
+		}
+	}
+	n0 := 0.95 * fn / 2
+	d := (float64(n1) - n0) / math.Sqrt(fn*0.95*0.05/3.8)
+	return math.Erfc(math.Abs(d) / math.Sqrt2)
+}
@@ -0,0 +1,170 @@
+package randtest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+// bitsFromString 将 "0101..." 转换为比特序列
+func bitsFromString(s string) []byte {
+	bits := make([]byte, len(s))
+	for i := range s {
+		bits[i] = s[i] - '0'
+	}
+	return bits
+}
+
+func checkPValue(t *testing.T, name string, expected, actual float64) {
+	if math.Abs(expected-actual) > 1e-6 {
+		t.Errorf(`%s 失败
+期望值=%f
+实际值=%f`, name, expected, actual)
+	}
+}
+
+// 以下示例来自 NIST SP 800-22 Rev 1a 第 2 章
+
+func TestFrequencyExample(t *testing.T) {
+	checkPValue(t, "TestFrequencyExample", 0.527089, Frequency(bitsFromString("1011010101")))
+}
+
+func TestBlockFrequencyExample(t *testing.T) {
+	checkPValue(t, "TestBlockFrequencyExample", 0.801252, BlockFrequency(bitsFromString("0110011010"), 3))
+}
+
+func TestRunsExample(t *testing.T) {
+	checkPValue(t, "TestRunsExample", 0.147232, Runs(bitsFromString("1001101011")))
+}
+
+func TestLongestRunExample(t *testing.T) {
+	bits := bitsFromString("11001100000101010110110001001100111000000000001001001101010100010001001111010110100000001101011111001100111001101101100010110010")
+	checkPValue(t, "TestLongestRunExample", 0.180598, LongestRun(bits, 8, 1))
+}
+
+func TestSerialExample(t *testing.T) {
+	p1, p2 := Serial(bitsFromString("0011011101"), 3)
+	checkPValue(t, "TestSerialExample:p1", 0.808792, p1)
+	checkPValue(t, "TestSerialExample:p2", 0.670320, p2)
+}
+
+func TestApproximateEntropyExample(t *testing.T) {
+	checkPValue(t, "TestApproximateEntropyExample", 0.261961, ApproximateEntropy(bitsFromString("0100110101"), 3))
+}
+
+func TestCumulativeSumsExample(t *testing.T) {
+	forward, _ := CumulativeSums(bitsFromString("1011010111"))
+	checkPValue(t, "TestCumulativeSumsExample", 0.4116588, forward)
+}
+
+func TestBerlekampMasseyExample(t *testing.T) {
+	actual := berlekampMassey(bitsFromString("1101011110001"))
+	if actual != 4 {
+		t.Errorf(`TestBerlekampMasseyExample 失败
+期望值=%d
+实际值=%d`, 4, actual)
+	}
+}
+
+func TestUniversalExample(t *testing.T) {
+	checkPValue(t, "TestUniversalExample", 1.1949875, universalStatistic(bitsFromString("01011010011101010111"), 2, 4))
+}
+
+func TestBinaryRank(t *testing.T) {
+	identity := make([]uint32, 32)
+	for i := range identity {
+		identity[i] = 1 << uint(i)
+	}
+	if r := binaryRank(identity); r != 32 {
+		t.Errorf("TestBinaryRank 失败: identity rank = %d", r)
+	}
+	rows := []uint32{0x3, 0x1, 0x2, 0}
+	if r := binaryRank(rows); r != 2 {
+		t.Errorf("TestBinaryRank 失败: rank = %d", r)
+	}
+}
+
+func TestBinaryDerivationDegenerate(t *testing.T) {
+	// 全 1 序列一次推导后全为 0
+	bits := make([]byte, 1000)
+	for i := range bits {
+		bits[i] = 1
+	}
+	if p := BinaryDerivation(bits, 1); p > 1e-6 {
+		t.Errorf("TestBinaryDerivationDegenerate 失败: p = %f", p)
+	}
+}
+
+func TestAutocorrelationPeriodic(t *testing.T) {
+	// 周期为 2 的序列在 d=2 时完全相关
+	bits := make([]byte, 1000)
+	for i := range bits {
+		bits[i] = byte(i & 1)
+	}
+	if p := Autocorrelation(bits, 2); p > 1e-6 {
+		t.Errorf("TestAutocorrelationPeriodic 失败: p = %f", p)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// GM/T 0005-2021 特有检测项的交叉验证
+//
+// NIST SP 800-22 没有扑克、游程分布、二元推导、自相关检测，离散傅里叶检测的方差
+// 也与之不同（GM/T 0005-2021 除以 3.8）。以下期望值由独立的 Python 实现按
+// GM/T 0005-2021 第 5 章的公式计算：不完全伽马函数使用整数和半整数参数的闭式，
+// 离散傅里叶变换逐项求和，不经过本包的 igamc 和 fft。样本为 SM3(i) 的串联，
+// i 为 0 到 99 的 32 位大端整数，共 25600 比特
+// -----------------------------------------------------------------------------
+
+func referenceBits() []byte {
+	var data []byte
+	var counter [4]byte
+	for i := uint32(0); i < 100; i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+		h := sm3.New()
+		h.Write(counter[:])
+		data = h.Sum(data)
+	}
+	return BitsFromBytes(data)
+}
+
+func TestPokerReference(t *testing.T) {
+	bits := referenceBits()
+	checkPValue(t, "TestPokerReference:m=4", 0.151660, Poker(bits, 4))
+	checkPValue(t, "TestPokerReference:m=8", 0.481872, Poker(bits, 8))
+}
+
+func TestRunDistributionReference(t *testing.T) {
+	checkPValue(t, "TestRunDistributionReference", 0.529605, RunDistribution(referenceBits()))
+}
+
+func TestBinaryDerivationReference(t *testing.T) {
+	bits := referenceBits()
+	checkPValue(t, "TestBinaryDerivationReference:k=3", 0.063402, BinaryDerivation(bits, 3))
+	checkPValue(t, "TestBinaryDerivationReference:k=7", 0.345231, BinaryDerivation(bits, 7))
+}
+
+func TestAutocorrelationReference(t *testing.T) {
+	bits := referenceBits()
+	for _, c := range []struct {
+		d        int
+		expected float64
+	}{
+		{1, 0.935242},
+		{2, 0.381555},
+		{8, 0.617020},
+		{16, 0.851223},
+	} {
+		checkPValue(t, fmt.Sprintf("TestAutocorrelationReference:d=%d", c.d), c.expected, Autocorrelation(bits, c.d))
+	}
+}
+
+// TestDFTReference 3000 比特经过 Bluestein 算法，4096 比特经过基 2 算法
+func TestDFTReference(t *testing.T) {
+	bits := referenceBits()
+	checkPValue(t, "TestDFTReference:n=3000", 0.191418, DFT(bits[:3000]))
+	checkPValue(t, "TestDFTReference:n=4096", 0.933174, DFT(bits[:4096]))
+}
@@ -0,0 +1,165 @@
+package randtest
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// -----------------------------------------------------------------------------
+// 特殊函数
+// -----------------------------------------------------------------------------
+
+const (
+	gammaEpsilon = 1e-15
+	gammaMaxIter = 10000
+	gammaTiny    = 1e-300
+)
+
+// igamc 正则化上不完全伽马函数 Q(a, x)
+func igamc(a, x float64) float64 {
+	if x <= 0 || a <= 0 {
+		return 1
+	}
+	if x < a+1 {
+		return 1 - igamSeries(a, x)
+	}
+	return igamContinuedFraction(a, x)
+}
+
+func lgamma(x float64) float64 {
+	v, _ := math.Lgamma(x)
+	return v
+}
+
+// igamSeries 以级数展开计算 P(a, x)
+func igamSeries(a, x float64) float64 {
+	ap := a
+	sum := 1 / a
+	del := sum
+	for i := 0; i < gammaMaxIter; i++ {
+		ap++
+		del *= x / ap
+		sum += del
+		if math.Abs(del) < math.Abs(sum)*gammaEpsilon {
+			break
+		}
+	}
+	return sum * math.Exp(-x+a*math.Log(x)-lgamma(a))
+}
+
+// igamContinuedFraction 以连分式计算 Q(a, x)
+func igamContinuedFraction(a, x float64) float64 {
+	b := x + 1 - a
+	c := 1 / gammaTiny
+	d := 1 / b
+	h := d
+	for i := 1; i < gammaMaxIter; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < gammaTiny {
+			d = gammaTiny
+		}
+		c = b + an/c
+		if math.Abs(c) < gammaTiny {
+			c = gammaTiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < gammaEpsilon {
+			break
+		}
+	}
+	return math.Exp(-x+a*math.Log(x)-lgamma(a)) * h
+}
+
+// normalCDF 标准正态分布函数
+func normalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// -----------------------------------------------------------------------------
+// 快速傅里叶变换
+// -----------------------------------------------------------------------------
+
+// fft 任意长度的离散傅里叶变换，长度为 2 的幂时直接使用基 2 算法，否则使用
+// Bluestein 算法转化为卷积
+func fft(x []complex128) []complex128 {
+	n := len(x)
+	if n == 0 {
+		return nil
+	}
+	if n&(n-1) == 0 {
+		out := make([]complex128, n)
+		copy(out, x)
+		fftRadix2(out, false)
+		return out
+	}
+
+	m := 1
+	for m < 2*n-1 {
+		m <<= 1
+	}
+	w := make([]complex128, n)
+	for k := 0; k < n; k++ {
+		// k*k 取模避免大 n 时的精度损失
+		kk := (int64(k) * int64(k)) % int64(2*n)
+		w[k] = cmplx.Exp(complex(0, -math.Pi*float64(kk)/float64(n)))
+	}
+	a := make([]complex128, m)
+	b := make([]complex128, m)
+	for k := 0; k < n; k++ {
+		a[k] = x[k] * w[k]
+	}
+	b[0] = cmplx.Conj(w[0])
+	for k := 1; k < n; k++ {
+		b[k] = cmplx.Conj(w[k])
+		b[m-k] = b[k]
+	}
+	fftRadix2(a, false)
+	fftRadix2(b, false)
+	for i := range a {
+		a[i] *= b[i]
+	}
+	fftRadix2(a, true)
+
+	out := make([]complex128, n)
+	scale := complex(1/float64(m), 0)
+	for k := 0; k < n; k++ {
+		out[k] = a[k] * scale * w[k]
+	}
+	return out
+}
+
+// fftRadix2 原地基 2 FFT，inverse 时不做 1/n 缩放
+func fftRadix2(x []complex128, inverse bool) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, sign*2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				u := x[start+k]
+				v := x[start+k+size/2] * w
+				x[start+k] = u + v
+				x[start+k+size/2] = u - v
+				w *= step
+			}
+		}
+	}
+}
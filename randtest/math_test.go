@@ -0,0 +1,39 @@
+package randtest
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestIgamc(t *testing.T) {
+	// Q(1, x) = e^-x
+	for _, x := range []float64{0.1, 1, 5, 20} {
+		checkPValue(t, "TestIgamc", math.Exp(-x), igamc(1, x))
+	}
+	// Q(0.5, x) = erfc(sqrt(x))
+	for _, x := range []float64{0.1, 1, 5} {
+		checkPValue(t, "TestIgamc", math.Erfc(math.Sqrt(x)), igamc(0.5, x))
+	}
+}
+
+func TestFFT(t *testing.T) {
+	for _, n := range []int{8, 10, 37} {
+		x := make([]complex128, n)
+		for i := range x {
+			x[i] = complex(float64(i%3)-1, 0)
+		}
+		actual := fft(x)
+		for k := 0; k < n; k++ {
+			var expected complex128
+			for j := 0; j < n; j++ {
+				expected += x[j] * cmplx.Exp(complex(0, -2*math.Pi*float64(j*k)/float64(n)))
+			}
+			if cmplx.Abs(expected-actual[k]) > 1e-9 {
+				t.Errorf(`TestFFT(n=%d) 失败
+期望值=%v
+实际值=%v`, n, expected, actual[k])
+			}
+		}
+	}
+}
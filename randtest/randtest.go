@@ -0,0 +1,233 @@
+package randtest
+
+import (
+	"fmt"
+	"math"
+)
+
+// -----------------------------------------------------------------------------
+// GM/T 0005-2021 随机性检测规范
+// -----------------------------------------------------------------------------
+
+// DefaultAlpha 默认显著性水平
+const DefaultAlpha = 0.01
+
+// Config 检测参数，零值字段使用 GM/T 0005 对 10^6 比特样本推荐的参数
+type Config struct {
+	Alpha float64
+
+	BlockFrequencyM     int
+	PokerM              []int
+	SerialM             []int
+	LongestRunM         int
+	BinaryDerivationK   []int
+	AutocorrelationD    []int
+	ApproximateEntropyM []int
+	LinearComplexityM   int
+	UniversalL          int
+	UniversalQ          int
+}
+
+// DefaultConfig 返回推荐参数
+func DefaultConfig() *Config {
+	return &Config{
+		Alpha:               DefaultAlpha,
+		BlockFrequencyM:     10000,
+		PokerM:              []int{4, 8},
+		SerialM:             []int{3, 5},
+		LongestRunM:         10000,
+		BinaryDerivationK:   []int{3, 7},
+		AutocorrelationD:    []int{1, 2, 8, 16},
+		ApproximateEntropyM: []int{2, 5},
+		LinearComplexityM:   500,
+		UniversalL:          7,
+		UniversalQ:          1280,
+	}
+}
+
+func (cfg *Config) withDefaults() *Config {
+	def := DefaultConfig()
+	if cfg == nil {
+		return def
+	}
+	c := *cfg
+	if c.Alpha <= 0 {
+		c.Alpha = def.Alpha
+	}
+	if c.BlockFrequencyM <= 0 {
+		c.BlockFrequencyM = def.BlockFrequencyM
+	}
+	if len(c.PokerM) == 0 {
+		c.PokerM = def.PokerM
+	}
+	if len(c.SerialM) == 0 {
+		c.SerialM = def.SerialM
+	}
+	if c.LongestRunM <= 0 {
+		c.LongestRunM = def.LongestRunM
+	}
+	if len(c.BinaryDerivationK) == 0 {
+		c.BinaryDerivationK = def.BinaryDerivationK
+	}
+	if len(c.AutocorrelationD) == 0 {
+		c.AutocorrelationD = def.AutocorrelationD
+	}
+	if len(c.ApproximateEntropyM) == 0 {
+		c.ApproximateEntropyM = def.ApproximateEntropyM
+	}
+	if c.LinearComplexityM <= 0 {
+		c.LinearComplexityM = def.LinearComplexityM
+	}
+	if c.UniversalL <= 0 {
+		c.UniversalL = def.UniversalL
+	}
+	if c.UniversalQ <= 0 {
+		c.UniversalQ = def.UniversalQ
+	}
+	return &c
+}
+
+// Result 单个检测项的结果
+type Result struct {
+	Name    string
+	PValues []float64
+	// Skipped 样本长度不满足该检测项的要求
+	Skipped bool
+	Passed  bool
+}
+
+func (r Result) String() string {
+	if r.Skipped {
+		return fmt.Sprintf("%-36s skipped", r.Name)
+	}
+	verdict := "FAIL"
+	if r.Passed {
+		verdict = "pass"
+	}
+	return fmt.Sprintf("%-36s %v %s", r.Name, r.PValues, verdict)
+}
+
+// BitsFromBytes 将字节流按高位在前展开成比特序列
+func BitsFromBytes(data []byte) []byte {
+	bits := make([]byte, len(data)*8)
+	for i, b := range data {
+		for j := 0; j < 8; j++ {
+			bits[i*8+j] = (b >> uint(7-j)) & 1
+		}
+	}
+	return bits
+}
+
+// Run 依次执行全部 15 个检测项，cfg 可以为 nil
+func Run(bits []byte, cfg *Config) []Result {
+	c := cfg.withDefaults()
+	n := len(bits)
+	var results []Result
+	add := func(name string, ok bool, pvalues func() []float64) {
+		r := Result{Name: name}
+		if !ok {
+			r.Skipped = true
+			results = append(results, r)
+			return
+		}
+		r.PValues = pvalues()
+		r.Passed = true
+		for _, p := range r.PValues {
+			if math.IsNaN(p) || p < c.Alpha {
+				r.Passed = false
+			}
+		}
+		results = append(results, r)
+	}
+	one := func(p float64) []float64 { return []float64{p} }
+	two := func(p1, p2 float64) []float64 { return []float64{p1, p2} }
+
+	add("frequency", n >= 100, func() []float64 {
+		return one(Frequency(bits))
+	})
+	add(fmt.Sprintf("block frequency m=%d", c.BlockFrequencyM), n >= c.BlockFrequencyM, func() []float64 {
+		return one(BlockFrequency(bits, c.BlockFrequencyM))
+	})
+	for _, m := range c.PokerM {
+		m := m
+		add(fmt.Sprintf("poker m=%d", m), n/m >= 5*(1<<uint(m)), func() []float64 {
+			return one(Poker(bits, m))
+		})
+	}
+	for _, m := range c.SerialM {
+		m := m
+		add(fmt.Sprintf("serial m=%d", m), m >= 2 && n >= 1<<uint(m+2), func() []float64 {
+			return two(Serial(bits, m))
+		})
+	}
+	add("runs", n >= 100, func() []float64 {
+		return one(Runs(bits))
+	})
+	add("run distribution", n >= 100, func() []float64 {
+		return one(RunDistribution(bits))
+	})
+	table := findLongestRunTable(c.LongestRunM)
+	add(fmt.Sprintf("longest run m=%d", c.LongestRunM), table != nil && n/table.m >= table.minBlocks, func() []float64 {
+		return two(LongestRun(bits, c.LongestRunM, 1), LongestRun(bits, c.LongestRunM, 0))
+	})
+	for _, k := range c.BinaryDerivationK {
+		k := k
+		add(fmt.Sprintf("binary derivation k=%d", k), n >= 100+k, func() []float64 {
+			return one(BinaryDerivation(bits, k))
+		})
+	}
+	for _, d := range c.AutocorrelationD {
+		d := d
+		add(fmt.Sprintf("autocorrelation d=%d", d), n >= 100+d, func() []float64 {
+			return one(Autocorrelation(bits, d))
+		})
+	}
+	add("rank", n >= 38*rankMatrixSize*rankMatrixSize, func() []float64 {
+		return one(Rank(bits))
+	})
+	add("cumulative sums", n >= 100, func() []float64 {
+		return two(CumulativeSums(bits))
+	})
+	for _, m := range c.ApproximateEntropyM {
+		m := m
+		add(fmt.Sprintf("approximate entropy m=%d", m), m >= 1 && n >= 1<<uint(m+5), func() []float64 {
+			return one(ApproximateEntropy(bits, m))
+		})
+	}
+	add(fmt.Sprintf("linear complexity m=%d", c.LinearComplexityM), n >= c.LinearComplexityM*200, func() []float64 {
+		return one(LinearComplexity(bits, c.LinearComplexityM))
+	})
+	add(fmt.Sprintf("universal L=%d Q=%d", c.UniversalL, c.UniversalQ),
+		c.UniversalL < len(universalTable) && n/c.UniversalL-c.UniversalQ >= 1000*(1<<uint(c.UniversalL)), func() []float64 {
+			return one(Universal(bits, c.UniversalL, c.UniversalQ))
+		})
+	add("discrete fourier transform", n >= 1000, func() []float64 {
+		return one(DFT(bits))
+	})
+	return results
+}
+
+// Passed 全部未跳过的检测项都通过时返回 true，没有任何检测项执行时（样本过短）
+// 返回 false
+func Passed(results []Result) bool {
+	if Ran(results) == 0 {
+		return false
+	}
+	for _, r := range results {
+		if !r.Skipped && !r.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Ran 返回实际执行（未跳过）的检测项数
+func Ran(results []Result) int {
+	n := 0
+	for _, r := range results {
+		if !r.Skipped {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,70 @@
+package randtest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/drbg"
+)
+
+func TestBitsFromBytes(t *testing.T) {
+	actual := BitsFromBytes([]byte{0xa5, 0x01})
+	expected := bitsFromString("1010010100000001")
+	if !bytes.Equal(actual, expected) {
+		t.Errorf(`TestBitsFromBytes 失败
+期望值=%v
+实际值=%v`, expected, actual)
+	}
+}
+
+func TestRunDRBGOutput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping 10^6 bits in short mode")
+	}
+	d, err := drbg.NewHashDRBG(&drbg.Options{
+		Entropy: bytes.NewReader(bytes.Repeat([]byte{0x5a}, 64)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample := make([]byte, 1000000/8)
+	if _, err := d.Read(sample); err != nil {
+		t.Fatal(err)
+	}
+	results := Run(BitsFromBytes(sample), nil)
+	if len(results) != 22 {
+		t.Errorf("TestRunDRBGOutput 失败: %d 个结果", len(results))
+	}
+	for _, r := range results {
+		t.Log(r)
+		if r.Skipped {
+			t.Errorf("TestRunDRBGOutput 失败: %s 被跳过", r.Name)
+		}
+	}
+	if !Passed(results) {
+		t.Error("TestRunDRBGOutput 失败: DRBG 输出未通过检测")
+	}
+}
+
+func TestRunBiased(t *testing.T) {
+	sample := bytes.Repeat([]byte{0xf7}, 1000000/8)
+	results := Run(BitsFromBytes(sample), &Config{Alpha: 0.001})
+	if Passed(results) {
+		t.Error("TestRunBiased 失败: 有偏序列通过检测")
+	}
+	if results[0].Passed {
+		t.Errorf("TestRunBiased 失败: %s", results[0])
+	}
+}
+
+func TestRunShortSample(t *testing.T) {
+	results := Run(BitsFromBytes(make([]byte, 2)), nil)
+	for _, r := range results {
+		if !r.Skipped {
+			t.Errorf("TestRunShortSample 失败: %s 未被跳过", r.Name)
+		}
+	}
+	if Passed(results) {
+		t.Error("TestRunShortSample 失败: 全部跳过时 Passed 返回 true")
+	}
+}
@@ -0,0 +1,312 @@
+package registry
+
+import (
+	"crypto"
+	"crypto/cipher"
+	"crypto/hmac"
+	"encoding/asn1"
+	"errors"
+	"hash"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/t1anchen/gogmlib/sm2"
+	"github.com/t1anchen/gogmlib/sm3"
+	"github.com/t1anchen/gogmlib/sm4"
+)
+
+// -----------------------------------------------------------------------------
+// GM/T 0006-2012 密码应用标识规范
+// -----------------------------------------------------------------------------
+
+var (
+	// OIDSM4 6.2.1 SM4 分组密码算法
+	OIDSM4 = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104}
+
+	OIDSM4ECB     = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 1}
+	OIDSM4CBC     = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 2}
+	OIDSM4OFB     = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 3}
+	OIDSM4CFB     = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 4}
+	OIDSM4CFB1    = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 5}
+	OIDSM4CFB8    = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 6}
+	OIDSM4CTR     = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 7}
+	OIDSM4GCM     = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 8}
+	OIDSM4CCM     = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 9}
+	OIDSM4XTS     = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 10}
+	OIDSM4Wrap    = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 11}
+	OIDSM4WrapPad = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 12}
+	OIDSM4OCB     = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 100}
+
+	// OIDSM2 6.2.3 SM2 椭圆曲线公钥密码算法，同时也是 sm2p256v1 曲线的标识
+	OIDSM2 = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301}
+
+	OIDSM2Sign        = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301, 1}
+	OIDSM2KeyExchange = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301, 2}
+	OIDSM2Encrypt     = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301, 3}
+
+	// OIDSM9 6.2.3 SM9 标识密码算法
+	OIDSM9 = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 302}
+
+	OIDSM9Sign        = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 302, 1}
+	OIDSM9KeyExchange = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 302, 2}
+	OIDSM9Encrypt     = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 302, 3}
+
+	// OIDSM3 6.2.4 SM3 密码杂凑算法
+	OIDSM3 = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401}
+
+	OIDSM3Keyless = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401, 1}
+	OIDHMACSM3    = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401, 2}
+
+	// OIDSM2WithSM3 6.2.5 基于 SM2 算法和 SM3 算法的签名
+	OIDSM2WithSM3 = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 501}
+)
+
+// -----------------------------------------------------------------------------
+// 数据结构
+// -----------------------------------------------------------------------------
+
+// Kind 算法类别
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	KindPublicKey
+	KindSignature
+	KindKeyExchange
+	KindEncryption
+	KindHash
+	KindMAC
+	KindBlockCipher
+)
+
+var kindNames = [...]string{
+	KindUnknown:     "unknown",
+	KindPublicKey:   "public-key",
+	KindSignature:   "signature",
+	KindKeyExchange: "key-exchange",
+	KindEncryption:  "encryption",
+	KindHash:        "hash",
+	KindMAC:         "mac",
+	KindBlockCipher: "block-cipher",
+}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return kindNames[KindUnknown]
+	}
+	return kindNames[k]
+}
+
+// Algorithm 算法标识与构造函数
+//
+// 构造函数按 Kind 选填，没有实现的算法（例如 SM9）构造函数为 nil
+type Algorithm struct {
+	Name string
+	OID  asn1.ObjectIdentifier
+	Kind Kind
+
+	// NewHash 杂凑函数，签名算法中表示所使用的杂凑
+	NewHash func() hash.Hash
+	// NewMAC 以密钥构造消息认证码
+	NewMAC func(key []byte) hash.Hash
+	// NewCipher 以密钥构造分组密码
+	NewCipher func(key []byte) (cipher.Block, error)
+	// GenerateKey 生成私钥
+	GenerateKey func(rand io.Reader) (crypto.PrivateKey, error)
+}
+
+var (
+	ErrEmptyName     = errors.New("registry: empty algorithm name")
+	ErrEmptyOID      = errors.New("registry: empty algorithm OID")
+	ErrDuplicateName = errors.New("registry: algorithm name already registered")
+	ErrDuplicateOID  = errors.New("registry: algorithm OID already registered")
+)
+
+// registry 算法表，包级函数操作 defaultRegistry
+type registry struct {
+	mu     sync.RWMutex
+	byName map[string]*Algorithm
+	byOID  map[string]*Algorithm
+}
+
+func newRegistry() *registry {
+	return &registry{
+		byName: make(map[string]*Algorithm),
+		byOID:  make(map[string]*Algorithm),
+	}
+}
+
+var defaultRegistry = newRegistry()
+
+// -----------------------------------------------------------------------------
+// 查询与注册
+//
+// 注册表保存算法的副本，查询返回的也是副本，调用者修改返回值不会影响注册表
+// -----------------------------------------------------------------------------
+
+func nameKey(name string) string {
+	return strings.ToUpper(name)
+}
+
+// clone 复制算法，OID 不与原值共享底层数组
+func (alg *Algorithm) clone() *Algorithm {
+	c := *alg
+	c.OID = append(asn1.ObjectIdentifier(nil), alg.OID...)
+	return &c
+}
+
+// Register 注册一个算法，名称（不区分大小写）和 OID 都不能和已有算法重复
+func Register(alg *Algorithm) error {
+	return defaultRegistry.register(alg)
+}
+
+func (r *registry) register(alg *Algorithm) error {
+	if alg.Name == "" {
+		return ErrEmptyName
+	}
+	if len(alg.OID) == 0 {
+		return ErrEmptyOID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byName[nameKey(alg.Name)]; ok {
+		return ErrDuplicateName
+	}
+	if _, ok := r.byOID[alg.OID.String()]; ok {
+		return ErrDuplicateOID
+	}
+	alg = alg.clone()
+	r.byName[nameKey(alg.Name)] = alg
+	r.byOID[alg.OID.String()] = alg
+	return nil
+}
+
+// ByName 按名称（不区分大小写）查询算法
+func ByName(name string) (*Algorithm, bool) {
+	return defaultRegistry.lookupName(name)
+}
+
+func (r *registry) lookupName(name string) (*Algorithm, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	alg, ok := r.byName[nameKey(name)]
+	if !ok {
+		return nil, false
+	}
+	return alg.clone(), true
+}
+
+// ByOID 按 OID 查询算法
+func ByOID(oid asn1.ObjectIdentifier) (*Algorithm, bool) {
+	return defaultRegistry.lookupOID(oid)
+}
+
+func (r *registry) lookupOID(oid asn1.ObjectIdentifier) (*Algorithm, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	alg, ok := r.byOID[oid.String()]
+	if !ok {
+		return nil, false
+	}
+	return alg.clone(), true
+}
+
+// NameOf 返回 OID 对应的算法名称，未注册时返回 OID 的点分形式
+func NameOf(oid asn1.ObjectIdentifier) string {
+	return defaultRegistry.nameOf(oid)
+}
+
+func (r *registry) nameOf(oid asn1.ObjectIdentifier) string {
+	if alg, ok := r.lookupOID(oid); ok {
+		return alg.Name
+	}
+	return oid.String()
+}
+
+// All 按 OID 顺序返回全部已注册的算法
+func All() []*Algorithm {
+	return defaultRegistry.all()
+}
+
+func (r *registry) all() []*Algorithm {
+	r.mu.RLock()
+	algs := make([]*Algorithm, 0, len(r.byOID))
+	for _, alg := range r.byOID {
+		algs = append(algs, alg.clone())
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(algs, func(i, j int) bool {
+		a, b := algs[i].OID, algs[j].OID
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return algs
+}
+
+// -----------------------------------------------------------------------------
+// 内置算法
+// -----------------------------------------------------------------------------
+
+func newHMACSM3(key []byte) hash.Hash {
+	return hmac.New(sm3.New, key)
+}
+
+func generateSM2Key(rand io.Reader) (crypto.PrivateKey, error) {
+	sk, _, err := sm2.GenKey(rand)
+	if err != nil {
+		return nil, err
+	}
+	return sk, nil
+}
+
+func builtins() []*Algorithm {
+	return []*Algorithm{
+		{Name: "SM4", OID: OIDSM4, Kind: KindBlockCipher, NewCipher: sm4.NewCipher},
+		{Name: "SM4-ECB", OID: OIDSM4ECB, Kind: KindBlockCipher},
+		{Name: "SM4-CBC", OID: OIDSM4CBC, Kind: KindBlockCipher},
+		{Name: "SM4-OFB", OID: OIDSM4OFB, Kind: KindBlockCipher},
+		{Name: "SM4-CFB", OID: OIDSM4CFB, Kind: KindBlockCipher},
+		{Name: "SM4-CFB1", OID: OIDSM4CFB1, Kind: KindBlockCipher},
+		{Name: "SM4-CFB8", OID: OIDSM4CFB8, Kind: KindBlockCipher},
+		{Name: "SM4-CTR", OID: OIDSM4CTR, Kind: KindBlockCipher},
+		{Name: "SM4-GCM", OID: OIDSM4GCM, Kind: KindBlockCipher},
+		{Name: "SM4-CCM", OID: OIDSM4CCM, Kind: KindBlockCipher},
+		{Name: "SM4-XTS", OID: OIDSM4XTS, Kind: KindBlockCipher},
+		{Name: "SM4-WRAP", OID: OIDSM4Wrap, Kind: KindBlockCipher},
+		{Name: "SM4-WRAP-PAD", OID: OIDSM4WrapPad, Kind: KindBlockCipher},
+		{Name: "SM4-OCB", OID: OIDSM4OCB, Kind: KindBlockCipher},
+
+		{Name: "SM2", OID: OIDSM2, Kind: KindPublicKey, GenerateKey: generateSM2Key},
+		{Name: "SM2-SIGN", OID: OIDSM2Sign, Kind: KindSignature, GenerateKey: generateSM2Key},
+		{Name: "SM2-KEYX", OID: OIDSM2KeyExchange, Kind: KindKeyExchange, GenerateKey: generateSM2Key},
+		{Name: "SM2-ENC", OID: OIDSM2Encrypt, Kind: KindEncryption, GenerateKey: generateSM2Key},
+
+		{Name: "SM9", OID: OIDSM9, Kind: KindPublicKey},
+		{Name: "SM9-SIGN", OID: OIDSM9Sign, Kind: KindSignature},
+		{Name: "SM9-KEYX", OID: OIDSM9KeyExchange, Kind: KindKeyExchange},
+		{Name: "SM9-ENC", OID: OIDSM9Encrypt, Kind: KindEncryption},
+
+		{Name: "SM3", OID: OIDSM3, Kind: KindHash, NewHash: sm3.New},
+		{Name: "SM3-KEYLESS", OID: OIDSM3Keyless, Kind: KindHash, NewHash: sm3.New},
+		{Name: "HMAC-SM3", OID: OIDHMACSM3, Kind: KindMAC, NewMAC: newHMACSM3},
+
+		{Name: "SM2-SM3", OID: OIDSM2WithSM3, Kind: KindSignature, NewHash: sm3.New, GenerateKey: generateSM2Key},
+	}
+}
+
+func init() {
+	for _, alg := range builtins() {
+		if err := Register(alg); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package registry
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"testing"
+)
+
+func TestByName(t *testing.T) {
+	alg, ok := ByName("sm2-sm3")
+	if !ok {
+		t.Fatal("TestByName 失败: SM2-SM3 未注册")
+	}
+	if !alg.OID.Equal(OIDSM2WithSM3) {
+		t.Errorf(`TestByName 失败
+期望值=%s
+实际值=%s`, OIDSM2WithSM3, alg.OID)
+	}
+}
+
+func TestByOID(t *testing.T) {
+	alg, ok := ByOID(asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401})
+	if !ok {
+		t.Fatal("TestByOID 失败: SM3 未注册")
+	}
+	h := alg.NewHash()
+	h.Write([]byte("abc"))
+	actual := fmt.Sprintf("%x", h.Sum(nil))
+	expected := "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"
+	if actual != expected {
+		t.Errorf(`TestByOID 失败
+期望值=%s
+实际值=%s`, expected, actual)
+	}
+}
+
+func TestHMACSM3(t *testing.T) {
+	alg, _ := ByName("HMAC-SM3")
+	mac := alg.NewMAC([]byte("key"))
+	mac.Write([]byte("abc"))
+	actual := fmt.Sprintf("%x", mac.Sum(nil))
+	// openssl dgst -sm3 -hmac key
+	expected := "28e63256e7c5a087b1f073265dc53092163f7b82729735d06f28f10af9d52393"
+	if actual != expected {
+		t.Errorf(`TestHMACSM3 失败
+期望值=%s
+实际值=%s`, expected, actual)
+	}
+}
+
+// TestRegister 在独立的注册表上测试，不影响全局注册表，可以重复运行
+func TestRegister(t *testing.T) {
+	r := newRegistry()
+	for _, alg := range builtins() {
+		if err := r.register(alg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oid := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}
+	if err := r.register(&Algorithm{Name: "TEST-ALG", OID: oid, Kind: KindHash}); err != nil {
+		t.Fatal(err)
+	}
+	if r.nameOf(oid) != "TEST-ALG" {
+		t.Errorf("TestRegister 失败: NameOf = %s", r.nameOf(oid))
+	}
+	if _, ok := ByOID(oid); ok {
+		t.Error("TestRegister 失败: 注册到了全局注册表")
+	}
+	if err := r.register(&Algorithm{Name: "test-alg", OID: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 2}}); err != ErrDuplicateName {
+		t.Errorf("TestRegister 失败: 重名注册 err = %v", err)
+	}
+	if err := r.register(&Algorithm{Name: "TEST-ALG-2", OID: OIDSM3}); err != ErrDuplicateOID {
+		t.Errorf("TestRegister 失败: 重复 OID 注册 err = %v", err)
+	}
+}
+
+// TestLookupCopy 修改查询结果不影响注册表
+func TestLookupCopy(t *testing.T) {
+	alg, _ := ByName("SM3")
+	alg.Name = "MD5"
+	alg.NewHash = nil
+	alg.OID[0] = 2
+	if again, _ := ByName("SM3"); again.Name != "SM3" || again.NewHash == nil || !again.OID.Equal(OIDSM3) {
+		t.Errorf("TestLookupCopy 失败\n期望值=%s %s\n实际值=%s %s", "SM3", OIDSM3, again.Name, again.OID)
+	}
+	All()[0].OID[0] = 2
+	if _, ok := ByOID(OIDSM4); !ok {
+		t.Error("TestLookupCopy 失败: All 返回的 OID 与注册表共享")
+	}
+}
+
+func TestAll(t *testing.T) {
+	algs := All()
+	if len(algs) == 0 || !algs[0].OID.Equal(OIDSM4) {
+		t.Errorf("TestAll 失败: algs[0] = %v", algs[0])
+	}
+	for _, alg := range algs {
+		t.Logf("%-16s %-12s %s", alg.Name, alg.Kind, alg.OID)
+	}
+}
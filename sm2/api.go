@@ -1,11 +1,124 @@
 package sm2
 
-// Sign 用私钥生成基于 ASN.1 DER-encoded 的签名
-func (sk *PrivKey) Sign(userID []byte, data []byte) ([]byte, error) {
-	return sk.SignToASN1DER(userID, data)
+import (
+	"crypto"
+	"crypto/rand"
+	"errors"
+	"hash"
+	"io"
+	"math/big"
+)
+
+// -----------------------------------------------------------------------------
+// golang/crypto 接口
+// -----------------------------------------------------------------------------
+
+var (
+	ErrUnsupportedHash     = errors.New("sm2: unsupported hash function, SM2 signs e = SM3(Z || M)")
+	ErrInvalidDigestLength = errors.New("sm2: prehashed e has the wrong length")
+)
+
+// SignerOpts 签名选项，实现 crypto.SignerOpts
+type SignerOpts struct {
+	// UserID 签名者的用户身份标识，为空时使用默认值 1234567812345678
+	UserID []byte
+	// Prehashed 为 true 时 Sign 的 digest 参数是已经计算好的 e = H(Z || M)，
+	// 否则 digest 参数是原始消息 M
+	Prehashed bool
+	// Hash 计算 Z 和 e 的杂凑函数 H，为空时使用 SM3
+	Hash func() hash.Hash
+	// Deterministic 为 true 时按 RFC 6979 以 HMAC-SM3 从私钥和 e 派生 k，
+	// 不读取随机源，同一私钥对同一消息总是生成相同的签名
+	Deterministic bool
+	// ExtraEntropy 确定性模式下额外混入的数据（RFC 6979 3.6），可以为空
+	ExtraEntropy []byte
+}
+
+// HashFunc 实现 crypto.SignerOpts，SM2 签名自行计算杂凑，因此返回 0
+func (opts *SignerOpts) HashFunc() crypto.Hash {
+	return 0
+}
+
+// Public 实现 crypto.Signer 和 crypto.Decrypter，返回 *PubKey
+func (sk *PrivKey) Public() crypto.PublicKey {
+	return sk.GenPubKey()
+}
+
+// Sign 实现 crypto.Signer，输出 ASN.1 DER 编码的签名
+//
+// opts 为 *SignerOpts 时按其中的用户身份标识和 Prehashed 处理 digest；opts 为 nil、
+// (*SignerOpts)(nil) 或 crypto.Hash(0) 时 digest 是原始消息，使用默认用户身份标识。
+//
+// k 从 random 读取，可以传入经过认证的 DRBG，random 为 nil 时使用 crypto/rand.Reader；
+// 确定性模式下不使用 random
+func (sk *PrivKey) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	signerOpts := &SignerOpts{}
+	switch o := opts.(type) {
+	case *SignerOpts:
+		if o != nil {
+			signerOpts = o
+		}
+	case nil:
+	default:
+		if o.HashFunc() != 0 {
+			return nil, ErrUnsupportedHash
+		}
+	}
+
+	newHash := hashOrDefault([]func() hash.Hash{signerOpts.Hash})
+	hashSize := newHash().Size()
+	var e *big.Int
+	if signerOpts.Prehashed {
+		if len(digest) != hashSize {
+			return nil, ErrInvalidDigestLength
+		}
+		e = new(big.Int).SetBytes(digest)
+	} else {
+		userID := signerOpts.UserID
+		if userID == nil {
+			userID = sm2SignDefaultUserID
+		}
+		pk := sk.GenPubKey()
+		e = genE(newHash(), &sk.Curve, pk.X, pk.Y, userID, digest)
+	}
+
+	var nextK func() (*big.Int, error)
+	if signerOpts.Deterministic {
+		g := newRFC6979(newHash, sk.Curve.N, sk.D, int2octets(e, hashSize), signerOpts.ExtraEntropy)
+		nextK = g.next
+	} else {
+		if random == nil {
+			random = rand.Reader
+		}
+		nextK = randomK(random, sk.Curve.N)
+	}
+	r, s, err := sk.signE(nextK, e)
+	if err != nil {
+		return nil, err
+	}
+	return (&Signature{R: r, S: s}).MarshalDER()
+}
+
+// Decrypt 实现 crypto.Decrypter，opts 为 *DecrypterOpts 时按其中的格式解密，否则
+// 按 C1C2C3 格式解密，random 未使用
+func (sk *PrivKey) Decrypt(random io.Reader, msg []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	mode := C1C2C3
+	if o, ok := opts.(*DecrypterOpts); ok && o != nil {
+		mode = o.Mode
+	}
+	return sk.DecryptWithMode(msg, mode)
+}
+
+// Equal 比较两个公钥的曲线和坐标
+func (pk *PubKey) Equal(x crypto.PublicKey) bool {
+	other, ok := x.(*PubKey)
+	if !ok {
+		return false
+	}
+	return pk.X.Cmp(other.X) == 0 && pk.Y.Cmp(other.Y) == 0 && pk.Curve.Equal(&other.Curve)
 }
 
-// Verify 用公钥验证基于 ASN.1 DER-encoded 的签名
-func (pk *PubKey) Verify(userID []byte, data []byte, sig []byte) bool {
-	return pk.VerifyFromASN1DER(userID, data, sig)
+// Verify 用公钥验证基于 ASN.1 DER-encoded 的签名，hashFunc 可选，缺省为 SM3
+func (pk *PubKey) Verify(userID []byte, data []byte, sig []byte, hashFunc ...func() hash.Hash) bool {
+	return pk.VerifyFromASN1DER(userID, data, sig, hashFunc...)
 }
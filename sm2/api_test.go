@@ -0,0 +1,199 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/drbg"
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+var (
+	_ crypto.Signer    = (*PrivKey)(nil)
+	_ crypto.Decrypter = (*PrivKey)(nil)
+)
+
+func TestSignerInterface(t *testing.T) {
+	sk, pk, err := GenKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var signer crypto.Signer = sk
+	if !pk.Equal(signer.Public()) {
+		t.Error("TestSignerInterface 失败: Public")
+	}
+
+	msg := []byte("message digest")
+	userID := []byte("ALICE123@YAHOO.COM")
+	sig, err := signer.Sign(rand.Reader, msg, &SignerOpts{UserID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.Verify(userID, msg, sig) {
+		t.Error("TestSignerInterface 失败: 验证")
+	}
+	if pk.Verify(nil, msg, sig) {
+		t.Error("TestSignerInterface 失败: 用户身份标识不符通过验证")
+	}
+
+	sig, err = signer.Sign(nil, msg, crypto.Hash(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.Verify(nil, msg, sig) {
+		t.Error("TestSignerInterface 失败: 默认用户身份标识")
+	}
+	// 值为 nil 的 *SignerOpts 等同于不传选项
+	sig, err = signer.Sign(nil, msg, (*SignerOpts)(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.Verify(nil, msg, sig) {
+		t.Error("TestSignerInterface 失败: (*SignerOpts)(nil)")
+	}
+}
+
+func TestSignerPrehashed(t *testing.T) {
+	sk, pk, err := GenKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("message digest")
+	h := sm3.New()
+	h.Write(getZ(sm3.New(), &pk.Curve, pk.X, pk.Y, sm2SignDefaultUserID))
+	h.Write(msg)
+	e := h.Sum(nil)
+
+	sig, err := sk.Sign(rand.Reader, e, &SignerOpts{Prehashed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.Verify(nil, msg, sig) {
+		t.Error("TestSignerPrehashed 失败")
+	}
+
+	if _, err := sk.Sign(rand.Reader, e[1:], &SignerOpts{Prehashed: true}); err != ErrInvalidDigestLength {
+		t.Errorf("TestSignerPrehashed 失败: %v", err)
+	}
+	if _, err := sk.Sign(rand.Reader, e, crypto.SHA256); err != ErrUnsupportedHash {
+		t.Errorf("TestSignerPrehashed 失败: %v", err)
+	}
+}
+
+func TestDecrypterInterface(t *testing.T) {
+	sk, pk, err := GenKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("encryption standard")
+	cipherText, err := pk.Encrypt(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decrypter crypto.Decrypter = sk
+	plain, err := decrypter.Decrypt(rand.Reader, cipherText, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(msg, plain) {
+		t.Errorf(`TestDecrypterInterface 失败
+期望值=%x
+实际值=%x`, msg, plain)
+	}
+}
+
+func TestPubKeyEqual(t *testing.T) {
+	sk, pk, err := GenKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.Equal(sk.GenPubKey()) {
+		t.Error("TestPubKeyEqual 失败: 同一公钥")
+	}
+	_, other, _ := GenKey(rand.Reader)
+	if pk.Equal(other) {
+		t.Error("TestPubKeyEqual 失败: 不同公钥")
+	}
+	if pk.Equal(*pk) {
+		t.Error("TestPubKeyEqual 失败: 非指针类型")
+	}
+}
+
+func TestSignerDeterministic(t *testing.T) {
+	sk, pk, err := GenKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("message digest")
+	opts := &SignerOpts{Deterministic: true}
+	sig1, err := sk.Sign(nil, msg, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig2, _ := sk.Sign(rand.Reader, msg, opts)
+	if !bytes.Equal(sig1, sig2) {
+		t.Errorf(`TestSignerDeterministic 失败
+期望值=%x
+实际值=%x`, sig1, sig2)
+	}
+	if !pk.Verify(nil, msg, sig1) {
+		t.Error("TestSignerDeterministic 失败: 验证")
+	}
+
+	sig3, _ := sk.Sign(nil, msg, &SignerOpts{Deterministic: true, ExtraEntropy: []byte("nonce")})
+	if bytes.Equal(sig1, sig3) || !pk.Verify(nil, msg, sig3) {
+		t.Error("TestSignerDeterministic 失败: 额外熵")
+	}
+	sig4, _ := sk.Sign(nil, []byte("other message"), opts)
+	if bytes.Equal(sig1, sig4) {
+		t.Error("TestSignerDeterministic 失败: 不同消息")
+	}
+}
+
+func TestSignerInjectedRand(t *testing.T) {
+	sk, pk, err := GenKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDRBG := func() *drbg.HashDRBG {
+		d, err := drbg.NewHashDRBG(&drbg.Options{
+			Entropy: bytes.NewReader(bytes.Repeat([]byte{0x5a}, 64)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	msg := []byte("message digest")
+	sig1, err := sk.Sign(newDRBG(), msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig2, _ := sk.Sign(newDRBG(), msg, nil)
+	if !bytes.Equal(sig1, sig2) {
+		t.Errorf(`TestSignerInjectedRand 失败
+期望值=%x
+实际值=%x`, sig1, sig2)
+	}
+	if !pk.Verify(nil, msg, sig1) {
+		t.Error("TestSignerInjectedRand 失败: 验证")
+	}
+	// 其他签名函数的 WithRand 版本同样从注入的随机源读取 k
+	der, err := sk.SignToASN1DERWithRand(newDRBG(), nil, msg)
+	if err != nil || !bytes.Equal(der, sig1) {
+		t.Errorf("TestSignerInjectedRand SignToASN1DERWithRand 失败\n期望值=%x\n实际值=%x", sig1, der)
+	}
+	raw, err := sk.SignWithFormatWithRand(newDRBG(), nil, msg, SignatureRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if converted, _ := ConvertSignature(raw, SignatureRaw, SignatureDER); !bytes.Equal(converted, sig1) {
+		t.Errorf("TestSignerInjectedRand SignWithFormatWithRand 失败\n期望值=%x\n实际值=%x", sig1, converted)
+	}
+	r, s, err := sk.SignToBigIntWithRand(newDRBG(), nil, msg)
+	if err != nil || !pk.VerifyFromBigInt(nil, msg, r, s) {
+		t.Errorf("TestSignerInjectedRand SignToBigIntWithRand 失败: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package sm2
+
+import (
+	"context"
+	"fmt"
+	"hash"
+	"math/big"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+// -----------------------------------------------------------------------------
+// 批量验证
+//
+// SM2 签名只包含 r = (e + x1) mod n，不含 (x1, y1) 的 y1，由 r 恢复的点有 ±R 两种
+// 可能，随机线性组合 Σ zi([si]G + [ti]Pi - Ri) = O 需要逐个猜测符号，不再是一次
+// 多倍点检查，所以这里不做随机化合并，而是逐个验证：
+//
+//   - 推荐曲线上同一公钥和用户身份标识的 Z 只计算一次
+//   - [s]G + [t]P 在推荐曲线上只做一次求逆
+//   - 多个签名由工作协程并发验证
+// -----------------------------------------------------------------------------
+
+// BatchItem 批量验证中的一个签名，UserID 为空时使用默认值，Hash 为空时使用 SM3
+type BatchItem struct {
+	PubKey *PubKey
+	UserID []byte
+	Msg    []byte
+	R, S   *big.Int
+	Hash   func() hash.Hash
+}
+
+// BatchError 批量验证失败的签名序号，按升序排列
+type BatchError struct {
+	Indices []int
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("sm2: %d signature(s) failed verification: %v", len(e.Indices), e.Indices)
+}
+
+// zKey 区分推荐曲线上不同的公钥和用户身份标识。Z 还依赖曲线参数 a、b、xG、
+// yG，坐标相同的公钥在其他曲线上的 Z 不同，所以只有推荐曲线使用缓存
+func zKey(pk *PubKey, userID []byte) string {
+	return string(pk.Curve.marshalUncompressed(pk.X, pk.Y)) + string(userID)
+}
+
+// VerifyBatch 并发验证多个签名，全部通过时返回 nil，有签名未通过时返回
+// *BatchError，ctx 取消时返回 ctx.Err()
+func VerifyBatch(ctx context.Context, items []BatchItem) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// 预先计算每个公钥和用户身份标识对应的 Z
+	zs := make([][]byte, len(items))
+	cache := make(map[string][]byte)
+	digest := sm3.New()
+	for i, item := range items {
+		if item.PubKey == nil || item.R == nil || item.S == nil {
+			continue
+		}
+		userID := item.UserID
+		if userID == nil {
+			userID = sm2SignDefaultUserID
+		}
+		// 函数值不能比较，指定了杂凑函数或不在推荐曲线上的签名不使用缓存
+		if item.Hash != nil || !isRecommended(&item.PubKey.Curve) {
+			zs[i] = getZ(hashOrDefault([]func() hash.Hash{item.Hash})(), &item.PubKey.Curve, item.PubKey.X, item.PubKey.Y, userID)
+			continue
+		}
+		key := zKey(item.PubKey, userID)
+		z, ok := cache[key]
+		if !ok {
+			z = getZ(digest, &item.PubKey.Curve, item.PubKey.X, item.PubKey.Y, userID)
+			cache[key] = z
+		}
+		zs[i] = z
+	}
+
+	jobs := make(chan int)
+	var mu sync.Mutex
+	var failed []int
+	var wg sync.WaitGroup
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(items) {
+		workers = len(items)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			digest := sm3.New()
+			for i := range jobs {
+				if !verifyBatchItem(digest, &items[i], zs[i]) {
+					mu.Lock()
+					failed = append(failed, i)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for i := range items {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	// 两个 case 同时就绪时 select 随机选择，验证期间取消的 ctx 也可能已把所有
+	// 签名分发出去，所以统一在结束后检查
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		sort.Ints(failed)
+		return &BatchError{Indices: failed}
+	}
+	return nil
+}
+
+func verifyBatchItem(digest hash.Hash, item *BatchItem, z []byte) bool {
+	if z == nil {
+		return false
+	}
+	if item.Hash != nil {
+		digest = item.Hash()
+	}
+	digest.Reset()
+	digest.Write(z)
+	digest.Write(item.Msg)
+	e := new(big.Int).SetBytes(digest.Sum(nil))
+	return item.PubKey.verifyE(e, item.R, item.S) == nil
+}
@@ -0,0 +1,137 @@
+package sm2
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"hash"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+func batchItems(n, keys int) []BatchItem {
+	sks := make([]*PrivKey, keys)
+	pks := make([]*PubKey, keys)
+	for i := range sks {
+		sks[i], pks[i], _ = GenKey(rand.Reader)
+	}
+	items := make([]BatchItem, n)
+	for i := range items {
+		msg := []byte(fmt.Sprintf("message %d", i))
+		var userID []byte
+		if i%2 == 1 {
+			userID = []byte("ALICE123@YAHOO.COM")
+		}
+		r, s, _ := sks[i%keys].SignToBigInt(userID, msg)
+		items[i] = BatchItem{PubKey: pks[i%keys], UserID: userID, Msg: msg, R: r, S: s}
+	}
+	return items
+}
+
+func TestVerifyBatch(t *testing.T) {
+	items := batchItems(24, 3)
+	if err := VerifyBatch(context.Background(), items); err != nil {
+		t.Fatalf("TestVerifyBatch 失败: %v", err)
+	}
+
+	items[3].Msg = []byte("tampered")
+	items[7].S = new(big.Int).Add(items[7].S, big.NewInt(1))
+	items[10].UserID = []byte("someone else")
+	items[20].PubKey = items[0].PubKey
+	items[22].R = nil
+	err := VerifyBatch(context.Background(), items)
+	batchErr, ok := err.(*BatchError)
+	if !ok {
+		t.Fatalf("TestVerifyBatch 失败: %v", err)
+	}
+	expected := []int{3, 7, 10, 20, 22}
+	if !reflect.DeepEqual(batchErr.Indices, expected) {
+		t.Errorf(`TestVerifyBatch 失败
+期望值=%v
+实际值=%v`, expected, batchErr.Indices)
+	}
+}
+
+func TestVerifyBatchEmpty(t *testing.T) {
+	if err := VerifyBatch(context.Background(), nil); err != nil {
+		t.Errorf("TestVerifyBatchEmpty 失败: %v", err)
+	}
+}
+
+func TestVerifyBatchCancel(t *testing.T) {
+	items := batchItems(8, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// 已取消的 ctx 不做任何验证，重复多次排除 select 随机选择的影响
+	for i := 0; i < 100; i++ {
+		if err := VerifyBatch(ctx, items); err != context.Canceled {
+			t.Fatalf(`TestVerifyBatchCancel 失败
+期望值=%v
+实际值=%v`, context.Canceled, err)
+		}
+	}
+
+	// 验证期间取消：第一个签名的杂凑函数被调用时取消 ctx
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	items[0].Hash = func() hash.Hash {
+		cancel()
+		return sm3.New()
+	}
+	if err := VerifyBatch(ctx, items); err != context.Canceled {
+		t.Errorf(`TestVerifyBatchCancel 失败
+期望值=%v
+实际值=%v`, context.Canceled, err)
+	}
+}
+
+func BenchmarkVerifyBatch(b *testing.B) {
+	items := batchItems(64, 4)
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			VerifyBatch(context.Background(), items)
+		}
+	})
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, item := range items {
+				item.PubKey.VerifyFromBigInt(item.UserID, item.Msg, item.R, item.S)
+			}
+		}
+	})
+}
+
+// TestVerifyBatchCurves 坐标相同的公钥在不同曲线上的 Z 不同，不能共用缓存
+func TestVerifyBatchCurves(t *testing.T) {
+	// curve2 与 exampleCurve 只有基点不同：G' = [2]G，同一个点 P = [d]G = [d/2]G'
+	curve1 := exampleCurve()
+	gx, gy := curve1.Double(curve1.Gx, curve1.Gy)
+	params := *curve1.CurveParams
+	params.Gx, params.Gy = gx, gy
+	curve2 := *Init(curve1.A, nil, &params)
+
+	sk1, pk1, err := GenKeyWithCurve(curve1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	half := new(big.Int).ModInverse(big.NewInt(2), curve1.N)
+	sk2 := &PrivKey{D: half.Mul(half, sk1.D).Mod(half, curve1.N), Curve: curve2}
+	pk2 := sk2.GenPubKey()
+	if pk1.X.Cmp(pk2.X) != 0 || pk1.Y.Cmp(pk2.Y) != 0 {
+		t.Fatal("TestVerifyBatchCurves 失败: 公钥坐标不同")
+	}
+
+	msg := []byte("message digest")
+	r1, s1, _ := sk1.SignToBigInt(nil, msg)
+	r2, s2, _ := sk2.SignToBigInt(nil, msg)
+	items := []BatchItem{
+		{PubKey: pk1, Msg: msg, R: r1, S: s1},
+		{PubKey: pk2, Msg: msg, R: r2, S: s2},
+	}
+	if err := VerifyBatch(context.Background(), items); err != nil {
+		t.Errorf("TestVerifyBatchCurves 失败: %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package cert
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+
+	"github.com/t1anchen/gogmlib/sm2"
+)
+
+// -----------------------------------------------------------------------------
+// 密钥编码
+//
+// 与 OpenSSL 3 一致：
+//
+//   - 公钥：RFC 5480 SubjectPublicKeyInfo，算法为 id-ecPublicKey，参数为 SM2 曲线
+//     OID 1.2.156.10197.1.301
+//   - 私钥：RFC 5915 ECPrivateKey（SEC1），包含曲线 OID 和公钥
+//   - PKCS#8：RFC 5208 PrivateKeyInfo，算法同公钥，内部的 ECPrivateKey 不重复
+//     曲线 OID
+//
+// 解析时另外接受以 SM2 OID 作为算法标识的公钥和私钥
+// -----------------------------------------------------------------------------
+
+const ecPrivKeyVersion = 1
+
+// PEM 类型
+const (
+	PEMTypePublicKey    = "PUBLIC KEY"
+	PEMTypePrivateKey   = "PRIVATE KEY"
+	PEMTypeECPrivateKey = "EC PRIVATE KEY"
+	// PEMTypeSM2PrivateKey OpenSSL 3 输出 SEC1 私钥时使用的类型
+	PEMTypeSM2PrivateKey = "SM2 PRIVATE KEY"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("x509: unsupported public key algorithm")
+	ErrInvalidPrivateKey    = errors.New("x509: invalid SM2 private key")
+	ErrPublicKeyMismatch    = errors.New("x509: SM2 private key does not match the embedded public key")
+	ErrInvalidPEM           = errors.New("x509: failed to decode PEM block")
+	ErrUnsupportedPEMType   = errors.New("x509: unsupported PEM block type")
+)
+
+// ecPrivateKey RFC 5915 3 ECPrivateKey
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// pkcs8 RFC 5208 5 PrivateKeyInfo，不处理 attributes
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// isSM2Curve 判断密钥是否使用推荐曲线，证书和密钥编码中的曲线 OID
+// 1.2.156.10197.1.301 只表示推荐曲线，其他曲线的密钥无法编码
+func isSM2Curve(c *sm2.Curve) bool {
+	recommended := sm2.GetSm2P256()
+	return c.Equal(&recommended)
+}
+
+// isSM2Algorithm 判断算法标识是否表示 SM2 公钥
+func isSM2Algorithm(algo pkix.AlgorithmIdentifier) bool {
+	if algo.Algorithm.Equal(oidSM2P256V1) {
+		return true
+	}
+	if !algo.Algorithm.Equal(oidPublicKeyECDSA) {
+		return false
+	}
+	var namedCurveOID asn1.ObjectIdentifier
+	rest, err := asn1.Unmarshal(algo.Parameters.FullBytes, &namedCurveOID)
+	return err == nil && len(rest) == 0 && namedCurveOID.Equal(oidSM2P256V1)
+}
+
+// ParsePKIXPublicKey 解析 DER 编码的 SubjectPublicKeyInfo
+func ParsePKIXPublicKey(der []byte) (*sm2.PubKey, error) {
+	var pki publicKeyInfo
+	rest, err := asn1.Unmarshal(der, &pki)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("x509: trailing data after ASN.1 of public-key")
+	}
+	if !isSM2Algorithm(pki.Algorithm) {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	pub, err := sm2.ParsePubKey(pki.PublicKey.RightAlign())
+	if err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// MarshalSM2PrivateKey 将私钥序列化为 SEC1 ECPrivateKey
+func MarshalSM2PrivateKey(sk *sm2.PrivKey) ([]byte, error) {
+	return marshalECPrivateKey(sk, oidSM2P256V1)
+}
+
+func marshalECPrivateKey(sk *sm2.PrivKey, oid asn1.ObjectIdentifier) ([]byte, error) {
+	if !isSM2Curve(&sk.Curve) {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if sk.Validate() != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	pub := sk.GenPubKey().ToUncompressedBytes()
+	return asn1.Marshal(ecPrivateKey{
+		Version:       ecPrivKeyVersion,
+		PrivateKey:    sk.ToBytes(),
+		NamedCurveOID: oid,
+		PublicKey:     asn1.BitString{Bytes: pub, BitLength: 8 * len(pub)},
+	})
+}
+
+// ParseSM2PrivateKey 解析 SEC1 ECPrivateKey，曲线 OID 可以省略，存在时必须为 SM2。
+// 私钥必须在 [1, n-2] 内，内嵌的公钥按 GB/T 32918.1-2016 6.2.1 验证。SEC1 要求
+// privateKey 为定长 ⌈log2(n)/8⌉ 字节，部分实现省略了前导零，这里接受较短的编码，
+// 只拒绝超过定长的编码
+func ParseSM2PrivateKey(der []byte) (*sm2.PrivKey, error) {
+	var key ecPrivateKey
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("x509: trailing data after ASN.1 of private-key")
+	}
+	if key.Version != ecPrivKeyVersion {
+		return nil, ErrInvalidPrivateKey
+	}
+	if len(key.NamedCurveOID) != 0 && !key.NamedCurveOID.Equal(oidSM2P256V1) {
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	curve := sm2.GetSm2P256()
+	if len(key.PrivateKey) > curve.ScalarSize() {
+		return nil, ErrInvalidPrivateKey
+	}
+	sk := &sm2.PrivKey{D: new(big.Int).SetBytes(key.PrivateKey), Curve: curve}
+	if sk.Validate() != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	if key.PublicKey.BitLength != 0 {
+		pub, err := sm2.ParsePubKey(key.PublicKey.RightAlign())
+		if err != nil {
+			return nil, err
+		}
+		if !pub.Equal(sk.GenPubKey()) {
+			return nil, ErrPublicKeyMismatch
+		}
+	}
+	return sk, nil
+}
+
+// MarshalPKCS8PrivateKey 将私钥序列化为 PKCS#8 PrivateKeyInfo
+func MarshalPKCS8PrivateKey(sk *sm2.PrivKey) ([]byte, error) {
+	_, algo, err := marshalPublicKey(sk.GenPubKey())
+	if err != nil {
+		return nil, err
+	}
+	inner, err := marshalECPrivateKey(sk, nil)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs8{Version: 0, Algo: algo, PrivateKey: inner})
+}
+
+// ParsePKCS8PrivateKey 解析 PKCS#8 PrivateKeyInfo
+func ParsePKCS8PrivateKey(der []byte) (*sm2.PrivKey, error) {
+	var key pkcs8
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("x509: trailing data after ASN.1 of private-key")
+	}
+	if !isSM2Algorithm(key.Algo) {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	return ParseSM2PrivateKey(key.PrivateKey)
+}
+
+// -----------------------------------------------------------------------------
+// PEM
+// -----------------------------------------------------------------------------
+
+// MarshalPublicKeyToPEM 输出 PUBLIC KEY 类型的 PEM
+func MarshalPublicKeyToPEM(pub *sm2.PubKey) ([]byte, error) {
+	der, err := MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PEMTypePublicKey, Bytes: der}), nil
+}
+
+// ParsePublicKeyFromPEM 解析 PUBLIC KEY 类型的 PEM
+func ParsePublicKeyFromPEM(data []byte) (*sm2.PubKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
+	if block.Type != PEMTypePublicKey {
+		return nil, ErrUnsupportedPEMType
+	}
+	return ParsePKIXPublicKey(block.Bytes)
+}
+
+// MarshalPrivateKeyToPEM 输出 PKCS#8 格式、PRIVATE KEY 类型的 PEM
+func MarshalPrivateKeyToPEM(sk *sm2.PrivKey) ([]byte, error) {
+	der, err := MarshalPKCS8PrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PEMTypePrivateKey, Bytes: der}), nil
+}
+
+// MarshalSM2PrivateKeyToPEM 输出 SEC1 格式、EC PRIVATE KEY 类型的 PEM
+func MarshalSM2PrivateKeyToPEM(sk *sm2.PrivKey) ([]byte, error) {
+	der, err := MarshalSM2PrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PEMTypeECPrivateKey, Bytes: der}), nil
+}
+
+// ParsePrivateKeyFromPEM 解析 PRIVATE KEY、EC PRIVATE KEY 或 SM2 PRIVATE KEY 类型
+// 的 PEM
+func ParsePrivateKeyFromPEM(data []byte) (*sm2.PrivKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
+	switch block.Type {
+	case PEMTypePrivateKey:
+		return ParsePKCS8PrivateKey(block.Bytes)
+	case PEMTypeECPrivateKey, PEMTypeSM2PrivateKey:
+		return ParseSM2PrivateKey(block.Bytes)
+	}
+	return nil, ErrUnsupportedPEMType
+}
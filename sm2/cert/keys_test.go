@@ -0,0 +1,206 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/sm2"
+)
+
+// testdata 下的文件由 OpenSSL 3.0 生成：
+//
+//	openssl genpkey -algorithm EC -pkeyopt ec_paramgen_curve:SM2 \
+//	    -pkeyopt ec_param_enc:named_curve -out sm2_pkcs8.pem
+//	openssl ec -in sm2_pkcs8.pem -out sm2_sec1.pem
+//	openssl pkey -in sm2_pkcs8.pem -pubout -out sm2_pub.pem
+const (
+	fixtureD   = "3407082cbd3f4b06e5b9ac6141449979c52cab005037081573a6dbc2ed33923b"
+	fixturePub = "0464859e7ffc2010c9896c8010e53ea01b50184df0edae72b0b3bdf583e488657c" +
+		"f9b8023d9b0c7a155147f26eea0fa17e48dde054daf12b010f104fe3460b75c9"
+)
+
+func readPEM(t *testing.T, name string) (*pem.Block, []byte) {
+	data, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatalf("%s: 无法解析 PEM", name)
+	}
+	return block, data
+}
+
+func checkFixtureKey(t *testing.T, name string, sk *sm2.PrivKey) {
+	if actual := hex.EncodeToString(sk.ToBytes()); actual != fixtureD {
+		t.Errorf("%s 失败\n期望值=%s\n实际值=%s", name, fixtureD, actual)
+	}
+}
+
+func TestPKCS8OpenSSL(t *testing.T) {
+	block, data := readPEM(t, "sm2_pkcs8.pem")
+	sk, err := ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFixtureKey(t, "TestPKCS8OpenSSL", sk)
+
+	der, err := MarshalPKCS8PrivateKey(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(der, block.Bytes) {
+		t.Errorf("TestPKCS8OpenSSL 失败\n期望值=%x\n实际值=%x", block.Bytes, der)
+	}
+	out, _ := MarshalPrivateKeyToPEM(sk)
+	if !bytes.Equal(out, data) {
+		t.Errorf("TestPKCS8OpenSSL 失败\n期望值=%s\n实际值=%s", data, out)
+	}
+}
+
+func TestSEC1OpenSSL(t *testing.T) {
+	block, data := readPEM(t, "sm2_sec1.pem")
+	if block.Type != PEMTypeSM2PrivateKey {
+		t.Fatalf("TestSEC1OpenSSL 失败: %s", block.Type)
+	}
+	sk, err := ParseSM2PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFixtureKey(t, "TestSEC1OpenSSL", sk)
+
+	der, err := MarshalSM2PrivateKey(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(der, block.Bytes) {
+		t.Errorf("TestSEC1OpenSSL 失败\n期望值=%x\n实际值=%x", block.Bytes, der)
+	}
+	sk, err = ParsePrivateKeyFromPEM(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFixtureKey(t, "TestSEC1OpenSSL", sk)
+}
+
+func TestPKIXOpenSSL(t *testing.T) {
+	block, data := readPEM(t, "sm2_pub.pem")
+	pub, err := ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := hex.EncodeToString(pub.ToUncompressedBytes()); actual != fixturePub {
+		t.Errorf("TestPKIXOpenSSL 失败\n期望值=%s\n实际值=%s", fixturePub, actual)
+	}
+	out, _ := MarshalPublicKeyToPEM(pub)
+	if !bytes.Equal(out, data) {
+		t.Errorf("TestPKIXOpenSSL 失败\n期望值=%s\n实际值=%s", data, out)
+	}
+}
+
+func TestPrivateKeyPEMRoundTrip(t *testing.T) {
+	sk, pk, _ := sm2.GenKey(rand.Reader)
+	for _, marshal := range []func(*sm2.PrivKey) ([]byte, error){MarshalPrivateKeyToPEM, MarshalSM2PrivateKeyToPEM} {
+		data, err := marshal(sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParsePrivateKeyFromPEM(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.D.Cmp(sk.D) != 0 {
+			t.Errorf("TestPrivateKeyPEMRoundTrip 失败\n期望值=%x\n实际值=%x", sk.D, got.D)
+		}
+	}
+	data, _ := MarshalPublicKeyToPEM(pk)
+	got, err := ParsePublicKeyFromPEM(data)
+	if err != nil || !got.Equal(pk) {
+		t.Errorf("TestPrivateKeyPEMRoundTrip 失败: %v", err)
+	}
+}
+
+func TestParsePrivateKeyInvalid(t *testing.T) {
+	block, _ := readPEM(t, "sm2_sec1.pem")
+	// 替换内嵌的公钥
+	sk, other, _ := sm2.GenKey(rand.Reader)
+	der := append([]byte(nil), block.Bytes...)
+	copy(der[len(der)-65:], other.ToUncompressedBytes())
+	if _, err := ParseSM2PrivateKey(der); err != ErrPublicKeyMismatch {
+		t.Errorf("TestParsePrivateKeyInvalid 失败: %v", err)
+	}
+
+	zero, _ := MarshalSM2PrivateKey(sk)
+	copy(zero[7:39], make([]byte, 32))
+	if _, err := ParseSM2PrivateKey(zero); err != ErrInvalidPrivateKey {
+		t.Errorf("TestParsePrivateKeyInvalid 失败: %v", err)
+	}
+
+	// d = n - 1 不在 [1, n-2] 内
+	nMinusOne := new(big.Int).Sub(sk.Curve.N, big.NewInt(1))
+	copy(zero[7:39], nMinusOne.Bytes())
+	if _, err := ParseSM2PrivateKey(zero); err != ErrInvalidPrivateKey {
+		t.Errorf("TestParsePrivateKeyInvalid 失败: %v", err)
+	}
+	if _, err := MarshalSM2PrivateKey(&sm2.PrivKey{D: nMinusOne, Curve: sk.Curve}); err != ErrInvalidPrivateKey {
+		t.Errorf("TestParsePrivateKeyInvalid 失败: %v", err)
+	}
+
+	if _, err := ParsePrivateKeyFromPEM([]byte("-----BEGIN CERTIFICATE-----\nAA==\n-----END CERTIFICATE-----\n")); err != ErrUnsupportedPEMType {
+		t.Errorf("TestParsePrivateKeyInvalid 失败: %v", err)
+	}
+}
+
+// TestParsePrivateKeyShort privateKey 省略前导零时按定长左补零，超过定长时拒绝
+func TestParsePrivateKeyShort(t *testing.T) {
+	curve := sm2.GetSm2P256()
+	sk := &sm2.PrivKey{D: new(big.Int).Lsh(big.NewInt(0x1234), 200), Curve: curve}
+	pub := sk.GenPubKey().ToUncompressedBytes()
+	encode := func(d []byte) []byte {
+		der, err := asn1.Marshal(ecPrivateKey{
+			Version:       ecPrivKeyVersion,
+			PrivateKey:    d,
+			NamedCurveOID: oidSM2P256V1,
+			PublicKey:     asn1.BitString{Bytes: pub, BitLength: 8 * len(pub)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+
+	short := sk.D.Bytes()
+	actual, err := ParseSM2PrivateKey(encode(short))
+	if err != nil {
+		t.Fatalf("TestParsePrivateKeyShort 失败: %d 字节 %v", len(short), err)
+	}
+	if actual.D.Cmp(sk.D) != 0 {
+		t.Errorf("TestParsePrivateKeyShort 失败\n期望值=%x\n实际值=%x", sk.D, actual.D)
+	}
+	if _, err := ParseSM2PrivateKey(encode(append(make([]byte, 33-len(short)), short...))); err != ErrInvalidPrivateKey {
+		t.Errorf("TestParsePrivateKeyShort 失败: 33 字节 %v", err)
+	}
+}
+
+// TestMarshalOtherCurve SM2 曲线 OID 只表示推荐曲线，其他曲线的密钥不能编码
+func TestMarshalOtherCurve(t *testing.T) {
+	params := *sm2.GetSm2P256().CurveParams
+	params.B = new(big.Int).Add(params.B, big.NewInt(1))
+	curve := *sm2.Init(sm2.GetSm2P256().A, nil, &params)
+	sk := &sm2.PrivKey{D: big.NewInt(2), Curve: curve}
+	if _, err := MarshalPKIXPublicKey(sk.GenPubKey()); err != ErrUnsupportedAlgorithm {
+		t.Errorf("TestMarshalOtherCurve 公钥失败: %v", err)
+	}
+	if _, err := MarshalSM2PrivateKey(sk); err != ErrUnsupportedAlgorithm {
+		t.Errorf("TestMarshalOtherCurve SEC1 失败: %v", err)
+	}
+	if _, err := MarshalPKCS8PrivateKey(sk); err != ErrUnsupportedAlgorithm {
+		t.Errorf("TestMarshalOtherCurve PKCS#8 失败: %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package cert
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"hash"
+
+	"github.com/t1anchen/gogmlib/pbkdf2"
+	"github.com/t1anchen/gogmlib/registry"
+	"github.com/t1anchen/gogmlib/sm2"
+	"github.com/t1anchen/gogmlib/sm3"
+	"github.com/t1anchen/gogmlib/sm4"
+)
+
+// -----------------------------------------------------------------------------
+// 口令加密的私钥
+//
+// RFC 5208 6 EncryptedPrivateKeyInfo，加密方案为 RFC 8018 6.2 PBES2：
+//
+//   - 密钥派生：PBKDF2，伪随机函数默认为 HMAC-SM3（1.2.156.10197.1.401.2），
+//     也支持 OpenSSL 3 可以生成的 hmacWithSHA256
+//   - 加密：SM4-CBC（1.2.156.10197.1.104.2），参数为 16 字节 IV
+// -----------------------------------------------------------------------------
+
+// PEMTypeEncryptedPrivateKey 加密的 PKCS#8 私钥的 PEM 类型
+const PEMTypeEncryptedPrivateKey = "ENCRYPTED PRIVATE KEY"
+
+const (
+	defaultPBES2SaltSize   = 16
+	defaultPBES2Iterations = 10000
+	// maxPBES2Iterations 迭代次数上限，防止恶意文件以巨大的迭代次数耗尽 CPU
+	maxPBES2Iterations = 10000000
+)
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+
+	// OIDHMACWithSM3 PBKDF2 伪随机函数 HMAC-SM3
+	OIDHMACWithSM3 = registry.OIDHMACSM3
+	// OIDHMACWithSHA256 PBKDF2 伪随机函数 hmacWithSHA256
+	OIDHMACWithSHA256 = oidHMACWithSHA256
+)
+
+var (
+	ErrUnsupportedEncryption = errors.New("x509: unsupported private key encryption scheme")
+	ErrIncorrectPassword     = errors.New("x509: decryption password incorrect")
+	ErrTooManyIterations     = errors.New("x509: PBKDF2 iteration count exceeds limit")
+)
+
+// PBES2Params 加密私钥时的参数，零值字段使用默认值：16 字节盐、10000 次迭代、
+// HMAC-SM3。迭代次数不能超过 10^7
+type PBES2Params struct {
+	SaltSize   int
+	Iterations int
+	PRF        asn1.ObjectIdentifier
+}
+
+// encryptedPrivateKeyInfo RFC 5208 6
+type encryptedPrivateKeyInfo struct {
+	Algo          pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params RFC 8018 A.4 PBES2-params
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params RFC 8018 A.2 PBKDF2-params，salt 只支持 specified 形式
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// prfHash 返回 PBKDF2 伪随机函数对应的杂凑函数，PRF 省略时为 hmacWithSHA1
+func prfHash(prf pkix.AlgorithmIdentifier) (func() hash.Hash, error) {
+	switch {
+	case len(prf.Algorithm) == 0, prf.Algorithm.Equal(oidHMACWithSHA1):
+		return sha1.New, nil
+	case prf.Algorithm.Equal(OIDHMACWithSM3):
+		return sm3.New, nil
+	case prf.Algorithm.Equal(oidHMACWithSHA256):
+		return sha256.New, nil
+	}
+	return nil, ErrUnsupportedEncryption
+}
+
+// EncryptPrivateKey 用口令加密私钥，输出 DER 编码的 EncryptedPrivateKeyInfo，
+// params 为空时使用默认参数
+func EncryptPrivateKey(sk *sm2.PrivKey, password []byte, params *PBES2Params) ([]byte, error) {
+	var p PBES2Params
+	if params != nil {
+		p = *params
+	}
+	if p.SaltSize <= 0 {
+		p.SaltSize = defaultPBES2SaltSize
+	}
+	if p.Iterations <= 0 {
+		p.Iterations = defaultPBES2Iterations
+	}
+	if p.Iterations > maxPBES2Iterations {
+		return nil, ErrTooManyIterations
+	}
+	if len(p.PRF) == 0 {
+		p.PRF = OIDHMACWithSM3
+	}
+	prf := pkix.AlgorithmIdentifier{Algorithm: p.PRF, Parameters: asn1.NullRawValue}
+	h, err := prfHash(prf)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := MarshalPKCS8PrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, p.SaltSize)
+	iv := make([]byte, sm4.BlockSizeInByte)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	key := pbkdf2.Key(password, salt, p.Iterations, sm4.KeySizeInByte, h)
+	encrypted, err := sm4.EncryptCBC(key, iv, der)
+	if err != nil {
+		return nil, err
+	}
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{Salt: salt, IterationCount: p.Iterations, PRF: prf})
+	if err != nil {
+		return nil, err
+	}
+	ivParams, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	schemeParams, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: registry.OIDSM4CBC, Parameters: asn1.RawValue{FullBytes: ivParams}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algo:          pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: schemeParams}},
+		EncryptedData: encrypted,
+	})
+}
+
+// DecryptPrivateKey 用口令解密 DER 编码的 EncryptedPrivateKeyInfo，口令错误时
+// 返回 ErrIncorrectPassword，迭代次数超过 10^7 时不派生密钥，返回
+// ErrTooManyIterations
+func DecryptPrivateKey(der []byte, password []byte) (*sm2.PrivKey, error) {
+	var info encryptedPrivateKeyInfo
+	if err := unmarshalStrict(der, &info); err != nil {
+		return nil, err
+	}
+	if !info.Algo.Algorithm.Equal(oidPBES2) {
+		return nil, ErrUnsupportedEncryption
+	}
+	var scheme pbes2Params
+	if err := unmarshalStrict(info.Algo.Parameters.FullBytes, &scheme); err != nil {
+		return nil, err
+	}
+	if !scheme.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) ||
+		!scheme.EncryptionScheme.Algorithm.Equal(registry.OIDSM4CBC) {
+		return nil, ErrUnsupportedEncryption
+	}
+
+	var kdf pbkdf2Params
+	if err := unmarshalStrict(scheme.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, err
+	}
+	if kdf.IterationCount <= 0 || (kdf.KeyLength != 0 && kdf.KeyLength != sm4.KeySizeInByte) {
+		return nil, ErrUnsupportedEncryption
+	}
+	if kdf.IterationCount > maxPBES2Iterations {
+		return nil, ErrTooManyIterations
+	}
+	h, err := prfHash(kdf.PRF)
+	if err != nil {
+		return nil, err
+	}
+	var iv []byte
+	if err := unmarshalStrict(scheme.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	if len(iv) != sm4.BlockSizeInByte {
+		return nil, ErrUnsupportedEncryption
+	}
+
+	key := pbkdf2.Key(password, kdf.Salt, kdf.IterationCount, sm4.KeySizeInByte, h)
+	plaintext, err := sm4.DecryptCBC(key, iv, info.EncryptedData)
+	if err != nil {
+		return nil, ErrIncorrectPassword
+	}
+	sk, err := ParsePKCS8PrivateKey(plaintext)
+	if err != nil {
+		// 填充恰好合法但口令错误时，明文不是有效的 PrivateKeyInfo
+		return nil, ErrIncorrectPassword
+	}
+	return sk, nil
+}
+
+// unmarshalStrict 解析 ASN.1 并拒绝尾随数据
+func unmarshalStrict(der []byte, v interface{}) error {
+	rest, err := asn1.Unmarshal(der, v)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return errors.New("x509: trailing data after ASN.1")
+	}
+	return nil
+}
+
+// EncryptPrivateKeyToPEM 输出 ENCRYPTED PRIVATE KEY 类型的 PEM
+func EncryptPrivateKeyToPEM(sk *sm2.PrivKey, password []byte, params *PBES2Params) ([]byte, error) {
+	der, err := EncryptPrivateKey(sk, password, params)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PEMTypeEncryptedPrivateKey, Bytes: der}), nil
+}
+
+// DecryptPrivateKeyFromPEM 解析并解密 ENCRYPTED PRIVATE KEY 类型的 PEM
+func DecryptPrivateKeyFromPEM(data []byte, password []byte) (*sm2.PrivKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
+	if block.Type != PEMTypeEncryptedPrivateKey {
+		return nil, ErrUnsupportedPEMType
+	}
+	return DecryptPrivateKey(block.Bytes, password)
+}
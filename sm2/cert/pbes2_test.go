@@ -0,0 +1,145 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"os/exec"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/sm2"
+)
+
+// sm2_pkcs8_enc_sha256.pem 由 OpenSSL 3.0 生成：
+//
+//	openssl pkcs8 -topk8 -in sm2_pkcs8.pem -v2 sm4-cbc -v2prf hmacWithSHA256 \
+//	    -iter 2048 -passout pass:password -out sm2_pkcs8_enc_sha256.pem
+//
+// OpenSSL 3.0 的 PBES2 不支持 HMAC-SM3 伪随机函数（openssl pkcs8 报告
+// unsupported prf），sm2_pkcs8_enc_sm3.pem 不是其他实现生成的文件，只是独立于本库
+// 计算的回归向量：由 Python hashlib.pbkdf2_hmac('sm3', ...) 派生密钥、
+// openssl enc -sm4-cbc 加密 sm2_pkcs8.pem 后按 RFC 8018 手工组装，盐和迭代次数分别
+// 为 5a1c0e7f3b9d24a86e11c0ffee123456 和 2048。它只验证 PBKDF2-HMAC-SM3 和 SM4-CBC
+// 的计算，不说明与 GmSSL、铜锁等实现生成的文件互通
+var encryptedFixtures = []string{
+	"sm2_pkcs8_enc_sha256.pem",
+	"sm2_pkcs8_enc_sm3.pem",
+}
+
+var testPassword = []byte("password")
+
+func TestDecryptPrivateKeyFixtures(t *testing.T) {
+	for _, name := range encryptedFixtures {
+		_, data := readPEM(t, name)
+		sk, err := DecryptPrivateKeyFromPEM(data, testPassword)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		checkFixtureKey(t, "TestDecryptPrivateKeyFixtures "+name, sk)
+
+		if _, err := DecryptPrivateKeyFromPEM(data, []byte("wrong")); err != ErrIncorrectPassword {
+			t.Errorf("%s: 错误口令未报错, err=%v", name, err)
+		}
+	}
+}
+
+func TestEncryptPrivateKeyRoundTrip(t *testing.T) {
+	sk, _, err := sm2.GenKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, params := range []*PBES2Params{
+		nil,
+		{SaltSize: 8, Iterations: 2048, PRF: OIDHMACWithSHA256},
+	} {
+		data, err := EncryptPrivateKeyToPEM(sk, testPassword, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := DecryptPrivateKeyFromPEM(data, testPassword)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.D.Cmp(sk.D) != 0 {
+			t.Errorf("TestEncryptPrivateKeyRoundTrip 失败\n期望值=%x\n实际值=%x", sk.D, actual.D)
+		}
+	}
+
+	// 默认参数下伪随机函数为 HMAC-SM3
+	der, _ := EncryptPrivateKey(sk, testPassword, nil)
+	if !bytes.Contains(der, mustMarshal(t, OIDHMACWithSM3)) {
+		t.Error("TestEncryptPrivateKeyRoundTrip 失败: 默认伪随机函数不是 HMAC-SM3")
+	}
+}
+
+// TestPBES2IterationLimit 迭代次数超过上限时加密和解密都报错，解密时不派生密钥
+func TestPBES2IterationLimit(t *testing.T) {
+	sk, _, _ := sm2.GenKey(rand.Reader)
+	if _, err := EncryptPrivateKey(sk, testPassword, &PBES2Params{Iterations: maxPBES2Iterations + 1}); err != ErrTooManyIterations {
+		t.Errorf("TestPBES2IterationLimit 失败\n期望值=%v\n实际值=%v", ErrTooManyIterations, err)
+	}
+
+	// 把迭代次数改为 2^31 - 1，解密应立即返回
+	der, err := EncryptPrivateKey(sk, testPassword, &PBES2Params{Iterations: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info encryptedPrivateKeyInfo
+	var scheme pbes2Params
+	var kdf pbkdf2Params
+	asn1.Unmarshal(der, &info)
+	asn1.Unmarshal(info.Algo.Parameters.FullBytes, &scheme)
+	asn1.Unmarshal(scheme.KeyDerivationFunc.Parameters.FullBytes, &kdf)
+	kdf.IterationCount = 1<<31 - 1
+	scheme.KeyDerivationFunc.Parameters = asn1.RawValue{FullBytes: mustMarshal(t, kdf)}
+	info.Algo.Parameters = asn1.RawValue{FullBytes: mustMarshal(t, scheme)}
+	if _, err := DecryptPrivateKey(mustMarshal(t, info), testPassword); err != ErrTooManyIterations {
+		t.Errorf("TestPBES2IterationLimit 失败\n期望值=%v\n实际值=%v", ErrTooManyIterations, err)
+	}
+}
+
+func TestEncryptPrivateKeyUnsupportedPRF(t *testing.T) {
+	sk, _, _ := sm2.GenKey(rand.Reader)
+	params := &PBES2Params{PRF: asn1.ObjectIdentifier{1, 2, 3}}
+	if _, err := EncryptPrivateKey(sk, testPassword, params); err != ErrUnsupportedEncryption {
+		t.Errorf("TestEncryptPrivateKeyUnsupportedPRF 失败: err=%v", err)
+	}
+}
+
+// TestEncryptPrivateKeyOpenSSL 用 OpenSSL 解密 hmacWithSHA256 参数下的输出，
+// 环境中没有 openssl 或 openssl 不支持 SM2、SM4 时跳过
+func TestEncryptPrivateKeyOpenSSL(t *testing.T) {
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl not found")
+	}
+	// 先确认 openssl 能解密 OpenSSL 自己生成的加密私钥，之后的失败都是错误
+	probe := exec.Command("openssl", "pkcs8", "-in", "testdata/sm2_pkcs8_enc_sha256.pem", "-passin", "pass:password")
+	if err := probe.Run(); err != nil {
+		t.Skipf("openssl does not support SM2 PBES2 keys: %v", err)
+	}
+	block, _ := readPEM(t, "sm2_pkcs8.pem")
+	sk, _ := ParsePKCS8PrivateKey(block.Bytes)
+	data, err := EncryptPrivateKeyToPEM(sk, testPassword, &PBES2Params{PRF: OIDHMACWithSHA256})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("openssl", "pkcs8", "-passin", "pass:password")
+	cmd.Stdin = bytes.NewReader(data)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("TestEncryptPrivateKeyOpenSSL 失败: openssl pkcs8 %v\n%s", err, data)
+	}
+	_, expected := readPEM(t, "sm2_pkcs8.pem")
+	if !bytes.Equal(out, expected) {
+		t.Errorf("TestEncryptPrivateKeyOpenSSL 失败\n期望值=%s\n实际值=%s", expected, out)
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	b, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
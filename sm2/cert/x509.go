@@ -2,7 +2,8 @@ package cert
 
 import (
 	"bytes"
-	"crypto/elliptic"
+	"crypto"
+	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
@@ -16,14 +17,15 @@ import (
 	"time"
 	"unicode/utf8"
 
+	"github.com/t1anchen/gogmlib/registry"
 	"github.com/t1anchen/gogmlib/sm2"
 	"golang.org/x/crypto/cryptobyte"
 	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
 )
 
 var (
-	oidSM2P256V1           = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301}
-	oidSignatureSM3WithSM2 = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 501}
+	oidSM2P256V1           = registry.OIDSM2
+	oidSignatureSM3WithSM2 = registry.OIDSM2WithSM3
 
 	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
 
@@ -101,7 +103,7 @@ func MarshalPKIXPublicKey(pub *sm2.PubKey) ([]byte, error) {
 }
 
 func CreateCertificateRequest(template *x509.CertificateRequest, pub *sm2.PubKey,
-	pri *sm2.PrivKey, userId []byte) (csr []byte, err error) {
+	pri crypto.Signer, userId []byte) (csr []byte, err error) {
 	var publicKeyBytes []byte
 	var publicKeyAlgorithm pkix.AlgorithmIdentifier
 	publicKeyBytes, publicKeyAlgorithm, err = marshalPublicKey(pub)
@@ -215,7 +217,7 @@ func CreateCertificateRequest(template *x509.CertificateRequest, pub *sm2.PubKey
 	tbsCSR.Raw = tbsCSRContents
 
 	var signature []byte
-	signature, err = pri.Sign(userId, tbsCSRContents)
+	signature, err = pri.Sign(rand.Reader, tbsCSRContents, &sm2.SignerOpts{UserID: userId})
 	if err != nil {
 		return
 	}
@@ -269,6 +271,11 @@ func oidInExtensions(oid asn1.ObjectIdentifier, extensions []pkix.Extension) boo
 }
 
 func marshalPublicKey(pub *sm2.PubKey) (publicKeyBytes []byte, publicKeyAlgorithm pkix.AlgorithmIdentifier, err error) {
+	// SM2 曲线 OID 只表示推荐曲线
+	if !isSM2Curve(&pub.Curve) {
+		err = ErrUnsupportedAlgorithm
+		return
+	}
 	publicKeyBytes = pub.ToUncompressedBytes()
 
 	publicKeyAlgorithm.Algorithm = oidPublicKeyECDSA
@@ -330,7 +337,7 @@ func parseCertificateRequest(in *certificateRequest) (*x509.CertificateRequest,
 	}
 
 	if !oidSignatureSM3WithSM2.Equal(in.SignatureAlgorithm.Algorithm) {
-		return nil, errors.New("x509: illegal signature algorithm OID")
+		return nil, fmt.Errorf("x509: illegal signature algorithm %s", registry.NameOf(in.SignatureAlgorithm.Algorithm))
 	}
 	if !oidPublicKeyECDSA.Equal(in.TBSCSR.PublicKey.Algorithm.Algorithm) {
 		return nil, errors.New("x509: illegal publick key algorithm OID")
@@ -378,18 +385,12 @@ func parsePublicKey(keyData *publicKeyInfo) (interface{}, error) {
 		return nil, errors.New("x509: trailing data after SM2 parameters")
 	}
 	if !oidSM2P256V1.Equal(*namedCurveOID) {
-		return nil, errors.New("x509: CurveOID is not the OID of SM2P256V1")
+		return nil, fmt.Errorf("x509: CurveOID %s is not the OID of SM2P256V1", registry.NameOf(*namedCurveOID))
 	}
 
-	curve := sm2.GetSm2P256()
-	x, y := elliptic.Unmarshal(curve, keyData.PublicKey.RightAlign())
-	if x == nil || y == nil {
-		return nil, errors.New("x509: Unmarshal PublicKey failed")
-	}
-	pub := &sm2.PubKey{
-		Curve: curve,
-		X:     x,
-		Y:     y,
+	pub, err := sm2.ParsePubKey(keyData.PublicKey.RightAlign())
+	if err != nil {
+		return nil, fmt.Errorf("x509: Unmarshal PublicKey failed: %v", err)
 	}
 	return pub, nil
 }
@@ -1065,8 +1066,8 @@ func CreateCertificateInfo(template, parent *x509.Certificate, csr *x509.Certifi
 	return &c, nil
 }
 
-func IssueCertificateBySoftCAKey(cinfo *TBSCertificate, caPri *sm2.PrivKey, userId []byte) ([]byte, error) {
-	signature, err := caPri.Sign(userId, cinfo.Raw)
+func IssueCertificateBySoftCAKey(cinfo *TBSCertificate, caPri crypto.Signer, userId []byte) ([]byte, error) {
+	signature, err := caPri.Sign(rand.Reader, cinfo.Raw, &sm2.SignerOpts{UserID: userId})
 	if err != nil {
 		return nil, err
 	}
@@ -1111,7 +1112,7 @@ func parseCertificate(in *certificate) (*x509.Certificate, error) {
 	out.PublicKeyAlgorithm = 0
 
 	if !oidSignatureSM3WithSM2.Equal(in.SignatureAlgorithm.Algorithm) {
-		return nil, errors.New("x509: illegal signature algorithm OID")
+		return nil, fmt.Errorf("x509: illegal signature algorithm %s", registry.NameOf(in.SignatureAlgorithm.Algorithm))
 	}
 	if !oidPublicKeyECDSA.Equal(in.TBSCertificate.PublicKey.Algorithm.Algorithm) {
 		return nil, errors.New("x509: illegal publick key algorithm OID")
@@ -6,6 +6,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -151,7 +152,7 @@ func TestCreateCertificate(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	sign, err := pri.Sign(nil, cinfo.Raw)
+	sign, err := pri.Sign(rand.Reader, cinfo.Raw, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -168,3 +169,21 @@ func TestCreateCertificate(t *testing.T) {
 	}
 	fmt.Println(certificate.DNSNames)
 }
+
+// TestParseCompressedPublicKey OpenSSL 生成的压缩形式 SubjectPublicKeyInfo
+func TestParseCompressedPublicKey(t *testing.T) {
+	der, _ := hex.DecodeString("3039301306072a8648ce3d020106082a811ccf5501822d03220003" +
+		"4cba42a0d4ead914acc1284b6b1a7a0f20566b6ccb232788350d01706f218523")
+	var info publicKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		t.Fatal(err)
+	}
+	pub, err := parsePublicKey(&info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "3876dee9bf182920c147ca76f19f097856d7b785c6346d9454fab8e065ce21d5"
+	if actual := fmt.Sprintf("%064x", pub.(*sm2.PubKey).Y); actual != expected {
+		t.Errorf("TestParseCompressedPublicKey 失败\n期望值=%s\n实际值=%s", expected, actual)
+	}
+}
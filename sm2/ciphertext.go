@@ -0,0 +1,164 @@
+package sm2
+
+import (
+	"encoding/asn1"
+	"errors"
+
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+// -----------------------------------------------------------------------------
+// 密文格式
+//
+// GB/T 32918.4-2016 6.1 A8 规定密文为 C = C1 || C3 || C2，其中 C1 为椭圆曲线点，
+// C3 为 32 字节杂凑值，C2 与明文等长。早期草案和本库原有的 Encrypt 使用
+// C1 || C2 || C3。GM/T 0009-2012 7.2 另外规定了 ASN.1 结构：
+//
+//	SM2Cipher ::= SEQUENCE {
+//	    XCoordinate INTEGER,
+//	    YCoordinate INTEGER,
+//	    HASH        OCTET STRING SIZE(32),
+//	    CipherText  OCTET STRING
+//	}
+//
+// 在字节串和 ASN.1 之间转换时坐标按曲线字节长度补齐前导 0
+// -----------------------------------------------------------------------------
+
+// CiphertextMode 字节串密文中 C1、C2、C3 的排列顺序
+type CiphertextMode int
+
+const (
+	// C1C2C3 早期草案的格式，也是 Encrypt 和 Decrypt 的默认格式
+	C1C2C3 CiphertextMode = iota
+	// C1C3C2 GB/T 32918.4-2016 的格式，GmSSL、BouncyCastle 等默认使用
+	C1C3C2
+)
+
+var (
+	ErrInvalidCiphertext     = errors.New("sm2: invalid ciphertext")
+	ErrInvalidCiphertextMode = errors.New("sm2: invalid ciphertext mode")
+)
+
+func (mode CiphertextMode) valid() bool {
+	return mode == C1C2C3 || mode == C1C3C2
+}
+
+func (mode CiphertextMode) String() string {
+	switch mode {
+	case C1C2C3:
+		return "C1C2C3"
+	case C1C3C2:
+		return "C1C3C2"
+	}
+	return "CiphertextMode(invalid)"
+}
+
+// DecrypterOpts 解密选项，实现 crypto.DecrypterOpts
+type DecrypterOpts struct {
+	Mode CiphertextMode
+}
+
+// joinCiphertext 按 mode 拼接 C1、C2、C3
+func joinCiphertext(c1, c2, c3 []byte, mode CiphertextMode) []byte {
+	out := make([]byte, 0, len(c1)+len(c2)+len(c3))
+	out = append(out, c1...)
+	if mode == C1C3C2 {
+		return append(append(out, c3...), c2...)
+	}
+	return append(append(out, c2...), c3...)
+}
+
+// splitCiphertext 按 mode 拆分密文，C1 的长度由首字节的编码形式决定
+func (c Curve) splitCiphertext(in []byte, mode CiphertextMode) (c1, c2, c3 []byte, err error) {
+	if !mode.valid() {
+		return nil, nil, nil, ErrInvalidCiphertextMode
+	}
+	if len(in) == 0 {
+		return nil, nil, nil, ErrInvalidCiphertext
+	}
+	// C2 至少 1 字节
+	c1Len := c.pointLen(in[0])
+	if c1Len == 0 || len(in) <= c1Len+sm3.DigestSizeInByte {
+		return nil, nil, nil, ErrInvalidCiphertext
+	}
+	c1, rest := in[:c1Len], in[c1Len:]
+	if mode == C1C3C2 {
+		return c1, rest[sm3.DigestSizeInByte:], rest[:sm3.DigestSizeInByte], nil
+	}
+	c2Len := len(rest) - sm3.DigestSizeInByte
+	return c1, rest[:c2Len], rest[c2Len:], nil
+}
+
+// ConvertCiphertext 在 C1C2C3 和 C1C3C2 之间转换推荐曲线上的密文，C1 保持原有的
+// 编码形式
+func ConvertCiphertext(in []byte, from, to CiphertextMode) ([]byte, error) {
+	return sm2P256.ConvertCiphertext(in, from, to)
+}
+
+// ConvertCiphertext 在 C1C2C3 和 C1C3C2 之间转换本曲线上的密文
+func (c Curve) ConvertCiphertext(in []byte, from, to CiphertextMode) ([]byte, error) {
+	if !to.valid() {
+		return nil, ErrInvalidCiphertextMode
+	}
+	c1, c2, c3, err := c.splitCiphertext(in, from)
+	if err != nil {
+		return nil, err
+	}
+	return joinCiphertext(c1, c2, c3, to), nil
+}
+
+// MarshalCiphertextToASN1DER 将推荐曲线上 mode 格式的密文转换为 GM/T 0009 的
+// ASN.1 DER 编码
+func MarshalCiphertextToASN1DER(in []byte, mode CiphertextMode) ([]byte, error) {
+	return sm2P256.MarshalCiphertextToASN1DER(in, mode)
+}
+
+// MarshalCiphertextToASN1DER 将本曲线上 mode 格式的密文转换为 ASN.1 DER 编码
+func (c Curve) MarshalCiphertextToASN1DER(in []byte, mode CiphertextMode) ([]byte, error) {
+	c1, c2, c3, err := c.splitCiphertext(in, mode)
+	if err != nil {
+		return nil, err
+	}
+	x, y, err := c.unmarshalPoint(c1)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ASN1DERCipher{X: x, Y: y, C3: c3, C2: c2})
+}
+
+// UnmarshalCiphertextFromASN1DER 将 GM/T 0009 的 ASN.1 DER 编码转换为推荐曲线上
+// mode 格式的密文，C1 为未压缩形式
+func UnmarshalCiphertextFromASN1DER(der []byte, mode CiphertextMode) ([]byte, error) {
+	return sm2P256.UnmarshalCiphertextFromASN1DER(der, mode)
+}
+
+// UnmarshalCiphertextFromASN1DER 将 ASN.1 DER 编码转换为本曲线上 mode 格式的
+// 密文，坐标按曲线的 ByteSize 补齐
+func (c Curve) UnmarshalCiphertextFromASN1DER(der []byte, mode CiphertextMode) ([]byte, error) {
+	if !mode.valid() {
+		return nil, ErrInvalidCiphertextMode
+	}
+	var cipher ASN1DERCipher
+	rest, err := asn1.Unmarshal(der, &cipher)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 || len(cipher.C3) != sm3.DigestSizeInByte || len(cipher.C2) == 0 {
+		return nil, ErrInvalidCiphertext
+	}
+	if cipher.X.Sign() < 0 || cipher.Y.Sign() < 0 || !c.IsOnCurve(cipher.X, cipher.Y) {
+		return nil, ErrPointNotOnCurve
+	}
+	c1 := c.marshalUncompressed(cipher.X, cipher.Y)
+	return joinCiphertext(c1, cipher.C2, cipher.C3, mode), nil
+}
+
+// MarshalEncryptedToASN1DER 将 C1C2C3 格式的密文转换为 ASN.1 DER 编码
+func MarshalEncryptedToASN1DER(in []byte) ([]byte, error) {
+	return MarshalCiphertextToASN1DER(in, C1C2C3)
+}
+
+// UnmarshalEncryptedFromASN1DER 将 ASN.1 DER 编码转换为 C1C2C3 格式的密文
+func UnmarshalEncryptedFromASN1DER(in []byte) ([]byte, error) {
+	return UnmarshalCiphertextFromASN1DER(in, C1C2C3)
+}
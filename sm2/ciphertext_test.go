@@ -0,0 +1,241 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// OpenSSL 3.0 对 "encryption standard" 加密的结果（GM/T 0009 ASN.1，C1C3C2），
+// 私钥为 cert/testdata/sm2_pkcs8.pem：
+//
+//	openssl pkeyutl -encrypt -inkey sm2_pkcs8.pem -in msg.txt
+//
+// 三个密文分别覆盖 C1 的 y 坐标、(x2, y2) 的 y2 和 x2 有前导 0 字节的情况
+var (
+	opensslCipherKey = "3407082cbd3f4b06e5b9ac6141449979c52cab005037081573a6dbc2ed33923b"
+	opensslCipherMsg = "encryption standard"
+	opensslCiphers   = []string{
+		"307c022100ce2160830e4cbeb13e4e1c20ebcabbcaa0062e0c69223d40dd8b6e72af363673022000cdd1e63166ddcd3de8d298" +
+			"cb3168ec88ffa5d7585be1ecfad3a7fec7cc7aac04208ef1b068ec94281c9a1792eec9e599d6599345db9a31c22372d05c39" +
+			"bfd58d890413eaed89a48a63bb60ab10f527f562d40e05f45b",
+		"307d022100bb8dc5046d6323f09751b2aa360d4284ea50348daa8637dab9e7432a14d0de8e022100844bdbc84a82b3438a7d80" +
+			"c4ee4d3f1cb4a2bde5f567fbf9135ec283a72338e404209608f1e512e8c6b4b3a2f8102b3da45217ac5d3e6098f411bf7867" +
+			"b281b3a8bc041325e041020234defe3022cf8454f3442df6a66f",
+		"307c0221009bc8e1caf14b178b7581d003eccc0ed417e5980aa3ef84d31233dc4289b213a302207e05b45c68254e770b95dd31" +
+			"eac53020668d1bf59f93119d324d89420a5fee7c0420da1fdf1691c2bfe8b9eb5efbdbdf0a1a5f8e209783900f71b6c8f9d3" +
+			"a35a10160413d55617eb739b806d1c9fc32111ef8de70d0a1a",
+	}
+)
+
+// OpenSSL 3.0 对 93 字节明文加密的结果，KDF 跨 3 个 SM3 输出，覆盖每个计数器取
+// 32 字节的情况
+var (
+	opensslLongMsg    = "The SM2 KDF expands SM3(x2||y2||ct) 32 bytes per counter; this message spans four KDF blocks."
+	opensslLongCipher = "3081c5022069a4dd95b2c96571b61e04c7d8e647027d0b4c3c656ae15000b3aa95492efcf502203f67c3987b243f4125" +
+		"130bbe6593b99f07553d83fbf6bf37cb6e5940b5c9ba63042041c27875559587ab412fc8434348810be4035ba8422a8b00d8" +
+		"f0e9973e9fe063045de0ba75bdec51c5a2201d01f594d17816eb8ce452493e8c675b7defccfc536c18df5f09c13b76667b46" +
+		"23b539b488a8e62ca10ef060d53bbd64ed9341f22d766899bf1bff9fd802524e0b92b65e5f7c0f5378c116194bb3789fc402561a"
+)
+
+func opensslCipherPrivKey() *PrivKey {
+	d, _ := new(big.Int).SetString(opensslCipherKey, 16)
+	return &PrivKey{D: d, Curve: sm2P256}
+}
+
+func TestDecryptOpenSSL(t *testing.T) {
+	sk := opensslCipherPrivKey()
+	for i, c := range opensslCiphers {
+		der, _ := hex.DecodeString(c)
+		ct, err := UnmarshalCiphertextFromASN1DER(der, C1C3C2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ct) != 1+2*KeyBytes+32+len(opensslCipherMsg) {
+			t.Errorf("TestDecryptOpenSSL %d 失败: 密文长度 %d", i, len(ct))
+		}
+		pt, err := sk.Decrypt(nil, ct, &DecrypterOpts{Mode: C1C3C2})
+		if err != nil || string(pt) != opensslCipherMsg {
+			t.Errorf("TestDecryptOpenSSL %d 失败\n期望值=%s\n实际值=%s\n错误=%v", i, opensslCipherMsg, pt, err)
+		}
+
+		// 转换回 ASN.1 应与原始编码相同
+		actual, err := MarshalCiphertextToASN1DER(ct, C1C3C2)
+		if err != nil || !bytes.Equal(actual, der) {
+			t.Errorf("TestDecryptOpenSSL %d 失败\n期望值=%x\n实际值=%x", i, der, actual)
+		}
+	}
+}
+
+// TestDecryptOpenSSLMultiBlock 多个 KDF 分组的密文，检查 KDF 与 OpenSSL 一致
+func TestDecryptOpenSSLMultiBlock(t *testing.T) {
+	der, _ := hex.DecodeString(opensslLongCipher)
+	ct, err := UnmarshalCiphertextFromASN1DER(der, C1C3C2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := opensslCipherPrivKey().DecryptWithMode(ct, C1C3C2)
+	if err != nil || string(pt) != opensslLongMsg {
+		t.Errorf("TestDecryptOpenSSLMultiBlock 失败\n期望值=%s\n实际值=%s\n错误=%v", opensslLongMsg, pt, err)
+	}
+}
+
+// TestCiphertextRoundTrip 随机明文在两种格式、格式转换和 ASN.1 编码之间往返
+func TestCiphertextRoundTrip(t *testing.T) {
+	sk, pk, _ := GenKey(rand.Reader)
+	rounds := 300
+	if testing.Short() {
+		rounds = 50
+	}
+	modes := []CiphertextMode{C1C2C3, C1C3C2}
+	for i := 0; i < rounds; i++ {
+		msg := make([]byte, 1+i%100)
+		rand.Read(msg)
+		mode := modes[i%2]
+		other := modes[(i+1)%2]
+
+		ct, err := pk.EncryptWithMode(rand.Reader, msg, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ct) != 1+2*KeyBytes+32+len(msg) {
+			t.Fatalf("TestCiphertextRoundTrip %s 失败: 密文长度 %d", mode, len(ct))
+		}
+		pt, err := sk.DecryptWithMode(ct, mode)
+		if err != nil || !bytes.Equal(pt, msg) {
+			t.Fatalf("TestCiphertextRoundTrip %s 解密失败\n期望值=%x\n实际值=%x\n错误=%v", mode, msg, pt, err)
+		}
+
+		converted, err := ConvertCiphertext(ct, mode, other)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pt, err := sk.DecryptWithMode(converted, other); err != nil || !bytes.Equal(pt, msg) {
+			t.Fatalf("TestCiphertextRoundTrip %s 转换后解密失败: %v", other, err)
+		}
+		if back, _ := ConvertCiphertext(converted, other, mode); !bytes.Equal(back, ct) {
+			t.Fatalf("TestCiphertextRoundTrip 转换不可逆\n期望值=%x\n实际值=%x", ct, back)
+		}
+
+		der, err := MarshalCiphertextToASN1DER(ct, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range modes {
+			expected, _ := ConvertCiphertext(ct, mode, m)
+			actual, err := UnmarshalCiphertextFromASN1DER(der, m)
+			if err != nil || !bytes.Equal(actual, expected) {
+				t.Fatalf("TestCiphertextRoundTrip ASN.1 往返失败\n期望值=%x\n实际值=%x\n错误=%v", expected, actual, err)
+			}
+		}
+	}
+}
+
+func TestCiphertextModeMismatch(t *testing.T) {
+	sk, pk, _ := GenKey(rand.Reader)
+	ct, _ := pk.EncryptWithMode(rand.Reader, []byte("mode mismatch"), C1C3C2)
+	if _, err := sk.DecryptWithMode(ct, C1C2C3); err != ErrInvalidCiphertext {
+		t.Errorf("TestCiphertextModeMismatch 失败: err=%v", err)
+	}
+	if _, err := pk.EncryptWithMode(rand.Reader, []byte("x"), CiphertextMode(2)); err != ErrInvalidCiphertextMode {
+		t.Errorf("TestCiphertextModeMismatch 失败: err=%v", err)
+	}
+	if _, err := sk.DecryptWithMode(ct, CiphertextMode(-1)); err != ErrInvalidCiphertextMode {
+		t.Errorf("TestCiphertextModeMismatch 失败: err=%v", err)
+	}
+}
+
+func TestUnmarshalCiphertextInvalid(t *testing.T) {
+	der, _ := hex.DecodeString(opensslCiphers[0])
+	ct, _ := UnmarshalCiphertextFromASN1DER(der, C1C2C3)
+	x, y, _ := sm2P256.unmarshalPoint(ct[:1+2*KeyBytes])
+
+	for _, c := range []ASN1DERCipher{
+		{X: x, Y: new(big.Int).Add(y, big.NewInt(1)), C3: make([]byte, 32), C2: []byte{1}},
+		{X: x, Y: y, C3: make([]byte, 31), C2: []byte{1}},
+	} {
+		in, _ := asn1.Marshal(c)
+		if _, err := UnmarshalCiphertextFromASN1DER(in, C1C2C3); err == nil {
+			t.Errorf("TestUnmarshalCiphertextInvalid 失败: %x 未报错", in)
+		}
+	}
+	if _, err := UnmarshalCiphertextFromASN1DER(append(der, 0), C1C2C3); err == nil {
+		t.Error("TestUnmarshalCiphertextInvalid 失败: 尾随数据未报错")
+	}
+}
+
+// TestEncryptOpenSSL 用 OpenSSL 解密 C1C3C2 格式转换得到的 ASN.1 密文，环境中没有
+// openssl 时跳过
+func TestEncryptOpenSSL(t *testing.T) {
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl not found")
+	}
+	// 先确认 openssl 支持 SM2 加密，之后的解密失败都是错误
+	probe := exec.Command("openssl", "pkeyutl", "-encrypt", "-inkey", "cert/testdata/sm2_pkcs8.pem")
+	probe.Stdin = strings.NewReader(opensslCipherMsg)
+	if err := probe.Run(); err != nil {
+		t.Skipf("openssl does not support SM2 encryption: %v", err)
+	}
+
+	sk := opensslCipherPrivKey()
+	for i := 0; i < 8; i++ {
+		ct, err := sk.GenPubKey().EncryptWithMode(rand.Reader, []byte(opensslCipherMsg), C1C3C2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := MarshalCiphertextToASN1DER(ct, C1C3C2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmd := exec.Command("openssl", "pkeyutl", "-decrypt", "-inkey", "cert/testdata/sm2_pkcs8.pem")
+		cmd.Stdin = bytes.NewReader(der)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Errorf("TestEncryptOpenSSL %d 失败: openssl pkeyutl %v\n密文=%x", i, err, der)
+			continue
+		}
+		if string(out) != opensslCipherMsg {
+			t.Errorf("TestEncryptOpenSSL 失败\n期望值=%s\n实际值=%s", opensslCipherMsg, out)
+		}
+	}
+}
+
+// TestDecryptInvalidInput 截断或篡改的密文返回错误而不是 panic
+func TestDecryptInvalidInput(t *testing.T) {
+	sk, pk, _ := GenKey(rand.Reader)
+	ct, _ := pk.EncryptWithMode(rand.Reader, []byte("invalid input"), C1C3C2)
+	c1Len := 1 + 2*KeyBytes
+	// C1 不在曲线上
+	offCurve := append([]byte(nil), ct...)
+	offCurve[c1Len-1] ^= 1
+	// 篡改 C3
+	badHash := append([]byte(nil), ct...)
+	badHash[c1Len] ^= 1
+
+	cases := []struct {
+		in  []byte
+		err error
+	}{
+		{nil, ErrInvalidCiphertext},
+		{[]byte{UnCompressed}, ErrInvalidCiphertext},
+		{[]byte{0x05, 1, 2, 3}, ErrInvalidCiphertext},
+		{ct[:c1Len], ErrInvalidCiphertext},
+		{ct[:c1Len+32], ErrInvalidCiphertext},
+		{ct[:len(ct)-1], ErrInvalidCiphertext},
+		{offCurve, ErrPointNotOnCurve},
+		{badHash, ErrInvalidCiphertext},
+	}
+	for i, c := range cases {
+		if _, err := sk.DecryptWithMode(c.in, C1C3C2); err != c.err {
+			t.Errorf("TestDecryptInvalidInput %d 失败\n期望值=%v\n实际值=%v", i, c.err, err)
+		}
+	}
+	if _, err := pk.EncryptWithMode(rand.Reader, nil, C1C3C2); err != ErrEmptyPlaintext {
+		t.Errorf("TestDecryptInvalidInput 失败: 空明文 err=%v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/utils"
+)
+
+// -----------------------------------------------------------------------------
+// GB/T 32918 附录示例的一致性测试
+//
+// 各部分附录 A.2 使用同一条素域 256 位示例曲线 exampleCurve，k 通过 random 参数
+// 注入，结果必须与附录逐字节一致：
+//
+//   - 第 2 部分 数字签名：TestConformanceSign，Z 和 e 见 TestComputeZExample
+//   - 第 3 部分 密钥交换：TestKeyExchangeExample
+//   - 第 4 部分 公钥加密：TestConformanceEncrypt
+//
+// 附录 A.3 的二元扩域示例本库不支持
+// -----------------------------------------------------------------------------
+
+// GB/T 32918.4-2016 附录 A.2 Fp-256 公钥加密示例
+var (
+	exampleEncD   = "1649AB77A00637BD5E2EFE283FBF353534AA7F7CB89463F208DDBC2920BB0DA0"
+	exampleEncK   = "4C62EEFD6ECFC2B95B92FD6C3D9575148AFA17425546D49018E5388D49DD7B4F"
+	exampleEncMsg = []byte("encryption standard")
+	exampleEncPub = "04" +
+		"435B39CCA8F3B508C1488AFC67BE491A0F7BA07E581A0E4849A5CF70628A7E0A" +
+		"75DDBA78F15FEECB4C7895E2C1CDF5FE01DEBB2CDBADF45399CCF77BBA076A42"
+	exampleEncC1 = "04" +
+		"245C26FB68B1DDDDB12C4B6BF9F2B6D5FE60A383B0D18D1C4144ABF17F6252E7" +
+		"76CB9264C2A7E88E52B19903FDC47378F605E36811F5C07423A24B84400F01B8"
+	exampleEncC2 = "650053A89B41C418B0C3AAD00D886C00286467"
+	exampleEncC3 = "9C3D7360C30156FAB7C80A0276712DA9D8094A634B766D3A285E07480653426D"
+)
+
+func TestConformanceSign(t *testing.T) {
+	curve := exampleCurve()
+	sk := &PrivKey{D: utils.NewBigIntFromHexString(exampleSignD), Curve: curve}
+	pk := sk.GenPubKey()
+
+	k := mustHex(exampleSignK)
+	der, err := sk.Sign(bytes.NewReader(k), exampleSignMsg, &SignerOpts{UserID: exampleSignID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := curve.ConvertSignature(der, SignatureDER, SignatureRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.ToLower(exampleSignR + exampleSignS)
+	if actual := hex.EncodeToString(raw); actual != expected {
+		t.Errorf("TestConformanceSign 失败\n期望值=%s\n实际值=%s", expected, actual)
+	}
+	if err := pk.CheckSignatureWithFormat(exampleSignID, exampleSignMsg, raw, SignatureRaw); err != nil {
+		t.Errorf("TestConformanceSign 验证失败: %v", err)
+	}
+}
+
+func TestConformanceEncrypt(t *testing.T) {
+	curve := exampleCurve()
+	sk := &PrivKey{D: utils.NewBigIntFromHexString(exampleEncD), Curve: curve}
+	pk, err := ParsePubKeyWithCurve(mustHex(exampleEncPub), curve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sk.GenPubKey().Equal(pk) {
+		t.Fatalf("TestConformanceEncrypt 公钥失败\n期望值=%s\n实际值=%x", exampleEncPub, sk.GenPubKey().ToUncompressedBytes())
+	}
+
+	in, err := pk.EncryptWithMode(bytes.NewReader(mustHex(exampleEncK)), exampleEncMsg, C1C3C2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.ToLower(exampleEncC1 + exampleEncC3 + exampleEncC2)
+	if actual := hex.EncodeToString(in); actual != expected {
+		t.Errorf("TestConformanceEncrypt 失败\n期望值=%s\n实际值=%s", expected, actual)
+	}
+	msg, err := sk.DecryptWithMode(in, C1C3C2)
+	if err != nil || !bytes.Equal(msg, exampleEncMsg) {
+		t.Errorf("TestConformanceEncrypt 解密失败\n期望值=%s\n实际值=%s err=%v", exampleEncMsg, msg, err)
+	}
+}
+
+// p192Curve 192 位曲线（NIST P-192 的参数），检查与长度有关的编码都使用曲线自身的
+// 长度
+func p192Curve() Curve {
+	return *Init(
+		utils.NewBigIntFromHexString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFFFFFFFFFFFC"),
+		nil,
+		&elliptic.CurveParams{
+			Name:    "P-192",
+			P:       utils.NewBigIntFromHexString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFFFFFFFFFFFF"),
+			N:       utils.NewBigIntFromHexString("FFFFFFFFFFFFFFFFFFFFFFFF99DEF836146BC9B1B4D22831"),
+			B:       utils.NewBigIntFromHexString("64210519E59C80E70FA7E9AB72243049FEB8DEECC146B9B1"),
+			Gx:      utils.NewBigIntFromHexString("188DA80EB03090F67CBF20EB43A18800F4FF0AFD82FF1012"),
+			Gy:      utils.NewBigIntFromHexString("07192B95FFC8DA78631011ED6B24CDD573F977A11E794811"),
+			BitSize: 192})
+}
+
+func TestCustomCurve(t *testing.T) {
+	curve := p192Curve()
+	if curve.ByteSize() != 24 || curve.ScalarSize() != 24 {
+		t.Fatalf("TestCustomCurve 失败: ByteSize=%d ScalarSize=%d", curve.ByteSize(), curve.ScalarSize())
+	}
+	g := &PubKey{X: curve.Gx, Y: curve.Gy, Curve: curve}
+	if err := g.Validate(); err != nil {
+		t.Fatalf("TestCustomCurve 失败: G 未通过验证 %v", err)
+	}
+
+	sk, pk, err := GenKeyWithCurve(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.Curve.Equal(&curve) || len(sk.ToBytes()) != 24 || len(pk.ToUncompressedBytes()) != 49 {
+		t.Fatalf("TestCustomCurve 失败: 私钥 %d 字节，公钥 %d 字节", len(sk.ToBytes()), len(pk.ToUncompressedBytes()))
+	}
+	for _, data := range [][]byte{pk.ToUncompressedBytes(), pk.ToCompressedBytes(), pk.ToHybridBytes()} {
+		parsed, err := ParsePubKeyWithCurve(data, curve)
+		if err != nil || !parsed.Equal(pk) {
+			t.Errorf("TestCustomCurve 解析公钥失败: %x err=%v", data, err)
+		}
+	}
+	if _, err := ParsePubKey(pk.ToUncompressedBytes()); err == nil {
+		t.Error("TestCustomCurve 失败: 推荐曲线接受了 192 位曲线的公钥")
+	}
+
+	msg := []byte("custom curve")
+	for _, format := range []SignatureFormat{SignatureDER, SignatureRaw} {
+		sig, err := sk.SignWithFormat(nil, msg, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format == SignatureRaw && len(sig) != 48 {
+			t.Errorf("TestCustomCurve 失败: 原始格式签名 %d 字节", len(sig))
+		}
+		if err := pk.CheckSignatureWithFormat(nil, msg, sig, format); err != nil {
+			t.Errorf("TestCustomCurve %v 签名验证失败: %v", format, err)
+		}
+		if pk.Precompute(nil).CheckSignatureWithFormat(msg, sig, format) != nil {
+			t.Errorf("TestCustomCurve %v Verifier 验证失败", format)
+		}
+	}
+
+	for _, mode := range []CiphertextMode{C1C2C3, C1C3C2} {
+		in, err := pk.EncryptWithMode(rand.Reader, msg, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(in) != 49+32+len(msg) {
+			t.Errorf("TestCustomCurve 失败: 密文 %d 字节", len(in))
+		}
+		der, err := curve.MarshalCiphertextToASN1DER(in, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := curve.UnmarshalCiphertextFromASN1DER(der, mode)
+		if err != nil || !bytes.Equal(back, in) {
+			t.Errorf("TestCustomCurve %v ASN.1 往返失败\n期望值=%x\n实际值=%x", mode, in, back)
+		}
+		out, err := sk.DecryptWithMode(in, mode)
+		if err != nil || !bytes.Equal(out, msg) {
+			t.Errorf("TestCustomCurve %v 解密失败\n期望值=%x\n实际值=%x", mode, msg, out)
+		}
+	}
+}
@@ -12,6 +12,8 @@ type Curve struct {
 	*elliptic.CurveParams
 	RInverse *big.Int
 	A        *big.Int
+	// p256 为 true 时使用推荐曲线的专用实现
+	p256 bool
 }
 
 var sm2P256 Curve
@@ -22,6 +24,7 @@ func Init(A *big.Int, RInverse *big.Int, givenCurveParams *elliptic.CurveParams)
 	sm2P256.A = A
 	sm2P256.CurveParams = givenCurveParams
 	sm2P256.RInverse = RInverse
+	sm2P256.p256 = isRecommended(&sm2P256)
 	return &sm2P256
 }
 
@@ -30,14 +33,29 @@ func InitWithRecommendedParams() *Curve {
 	return Init(
 		utils.NewBigIntFromHexString("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF00000000FFFFFFFFFFFFFFFC"),
 		utils.NewBigIntFromHexString("7ffffffd80000002fffffffe000000017ffffffe800000037ffffffc80000002"),
-		&elliptic.CurveParams{
-			Name:    "SM2-P-256",
-			P:       utils.NewBigIntFromHexString("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF00000000FFFFFFFFFFFFFFFF"),
-			N:       utils.NewBigIntFromHexString("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFF7203DF6B21C6052B53BBF40939D54123"),
-			B:       utils.NewBigIntFromHexString("28E9FA9E9D9F5E344D5A9E4BCF6509A7F39789F515AB8F92DDBCBD414D940E93"),
-			Gx:      utils.NewBigIntFromHexString("32C4AE2C1F1981195F9904466A39C9948FE30BBFF2660BE1715A4589334C74C7"),
-			Gy:      utils.NewBigIntFromHexString("BC3736A2F4F6779C59BDCEE36B692153D0A9877CC62A474002DF32E52139F0A0"),
-			BitSize: 256})
+		recommendedParams())
+}
+
+func recommendedParams() *elliptic.CurveParams {
+	return &elliptic.CurveParams{
+		Name:    "SM2-P-256",
+		P:       utils.NewBigIntFromHexString("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF00000000FFFFFFFFFFFFFFFF"),
+		N:       utils.NewBigIntFromHexString("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFF7203DF6B21C6052B53BBF40939D54123"),
+		B:       utils.NewBigIntFromHexString("28E9FA9E9D9F5E344D5A9E4BCF6509A7F39789F515AB8F92DDBCBD414D940E93"),
+		Gx:      utils.NewBigIntFromHexString("32C4AE2C1F1981195F9904466A39C9948FE30BBFF2660BE1715A4589334C74C7"),
+		Gy:      utils.NewBigIntFromHexString("BC3736A2F4F6779C59BDCEE36B692153D0A9877CC62A474002DF32E52139F0A0"),
+		BitSize: 256}
+}
+
+// isRecommended 判断曲线参数是否为 GB/T 32918.5-2017 推荐参数
+func isRecommended(c *Curve) bool {
+	if c.CurveParams == nil || c.A == nil {
+		return false
+	}
+	params := recommendedParams()
+	return c.P.Cmp(params.P) == 0 && c.N.Cmp(params.N) == 0 && c.B.Cmp(params.B) == 0 &&
+		c.Gx.Cmp(params.Gx) == 0 && c.Gy.Cmp(params.Gy) == 0 &&
+		new(big.Int).Sub(c.P, c.A).Cmp(big.NewInt(3)) == 0
 }
 
 func init() {
@@ -48,9 +66,254 @@ func GetSm2P256() Curve {
 	return sm2P256
 }
 
+// IsPointInfinity 以 (0, 0) 表示无穷远点 O
 func IsPointInfinity(x, y *big.Int) bool {
 	if x.Sign() == 0 && y.Sign() == 0 {
 		return true
 	}
 	return false
 }
+
+// Equal 比较两条曲线的参数
+func (c *Curve) Equal(other *Curve) bool {
+	if c.CurveParams == other.CurveParams {
+		return true
+	}
+	if c.CurveParams == nil || other.CurveParams == nil || c.A == nil || other.A == nil {
+		return false
+	}
+	return c.P.Cmp(other.P) == 0 && c.N.Cmp(other.N) == 0 && c.A.Cmp(other.A) == 0 &&
+		c.B.Cmp(other.B) == 0 && c.Gx.Cmp(other.Gx) == 0 && c.Gy.Cmp(other.Gy) == 0
+}
+
+// ByteSize 域元素的字节长度 ⌈log2(p) / 8⌉，点和密文中 C1 的编码长度由它决定
+func (c Curve) ByteSize() int {
+	return (c.BitSize + 7) / 8
+}
+
+// ScalarSize 标量的字节长度 ⌈log2(n) / 8⌉，私钥和原始格式签名中 r、s 的编码长度
+// 由它决定
+func (c Curve) ScalarSize() int {
+	return (c.N.BitLen() + 7) / 8
+}
+
+// fieldBytes 4.2.5 域元素按曲线字节长度转换为定长字节串
+func (c Curve) fieldBytes(x *big.Int) []byte {
+	return fixedBytes(x, c.ByteSize())
+}
+
+// scalarBytes 标量按 n 的字节长度转换为定长字节串
+func (c Curve) scalarBytes(k *big.Int) []byte {
+	return fixedBytes(k, c.ScalarSize())
+}
+
+// fixedBytes 转换为 size 字节的大端字节串，超出时只保留低位
+func fixedBytes(x *big.Int, size int) []byte {
+	out := make([]byte, size)
+	b := x.Bytes()
+	if len(b) > size {
+		b = b[len(b)-size:]
+	}
+	copy(out[size-len(b):], b)
+	return out
+}
+
+// -----------------------------------------------------------------------------
+// GB/T 32918.1-2016 附录 A 素域上的椭圆曲线运算
+//
+// crypto/elliptic.CurveParams 的运算假设 a = -3，这里按一般的 a 实现，推荐曲线
+// 的 a = p - 3 同样适用。内部使用 Jacobian 坐标 (X, Y, Z)，对应仿射坐标
+// (X/Z^2, Y/Z^3)，Z = 0 表示无穷远点
+// -----------------------------------------------------------------------------
+
+// IsOnCurve 判断点是否满足 y^2 = x^3 + ax + b
+func (c Curve) IsOnCurve(x, y *big.Int) bool {
+	if x.Sign() < 0 || x.Cmp(c.P) >= 0 || y.Sign() < 0 || y.Cmp(c.P) >= 0 {
+		return false
+	}
+	y2 := new(big.Int).Mul(y, y)
+	y2.Mod(y2, c.P)
+
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Add(rhs, c.A)
+	rhs.Mul(rhs, x)
+	rhs.Add(rhs, c.B)
+	rhs.Mod(rhs, c.P)
+	return y2.Cmp(rhs) == 0
+}
+
+func (c Curve) toJacobian(x, y *big.Int) (*big.Int, *big.Int, *big.Int) {
+	if IsPointInfinity(x, y) {
+		return new(big.Int), new(big.Int), new(big.Int)
+	}
+	return new(big.Int).Set(x), new(big.Int).Set(y), big.NewInt(1)
+}
+
+func (c Curve) fromJacobian(x, y, z *big.Int) (*big.Int, *big.Int) {
+	if z.Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+	zinv := new(big.Int).ModInverse(z, c.P)
+	zinv2 := new(big.Int).Mul(zinv, zinv)
+	xOut := new(big.Int).Mul(x, zinv2)
+	xOut.Mod(xOut, c.P)
+	zinv2.Mul(zinv2, zinv)
+	yOut := new(big.Int).Mul(y, zinv2)
+	yOut.Mod(yOut, c.P)
+	return xOut, yOut
+}
+
+// doubleJacobian 倍点
+func (c Curve) doubleJacobian(x, y, z *big.Int) (*big.Int, *big.Int, *big.Int) {
+	if z.Sign() == 0 || y.Sign() == 0 {
+		return new(big.Int), new(big.Int), new(big.Int)
+	}
+	p := c.P
+	yy := new(big.Int).Mul(y, y)
+	yy.Mod(yy, p)
+	// S = 4XY^2
+	s := new(big.Int).Mul(x, yy)
+	s.Lsh(s, 2)
+	s.Mod(s, p)
+	// M = 3X^2 + aZ^4
+	zz := new(big.Int).Mul(z, z)
+	zz.Mod(zz, p)
+	m := new(big.Int).Mul(zz, zz)
+	m.Mul(m, c.A)
+	xx := new(big.Int).Mul(x, x)
+	xx.Mul(xx, big.NewInt(3))
+	m.Add(m, xx)
+	m.Mod(m, p)
+	// X' = M^2 - 2S
+	x3 := new(big.Int).Mul(m, m)
+	x3.Sub(x3, new(big.Int).Lsh(s, 1))
+	x3.Mod(x3, p)
+	// Y' = M(S - X') - 8Y^4
+	y3 := new(big.Int).Sub(s, x3)
+	y3.Mul(y3, m)
+	yy.Mul(yy, yy)
+	yy.Lsh(yy, 3)
+	y3.Sub(y3, yy)
+	y3.Mod(y3, p)
+	// Z' = 2YZ
+	z3 := new(big.Int).Mul(y, z)
+	z3.Lsh(z3, 1)
+	z3.Mod(z3, p)
+	return x3, y3, z3
+}
+
+// addJacobian 点加
+func (c Curve) addJacobian(x1, y1, z1, x2, y2, z2 *big.Int) (*big.Int, *big.Int, *big.Int) {
+	if z1.Sign() == 0 {
+		return new(big.Int).Set(x2), new(big.Int).Set(y2), new(big.Int).Set(z2)
+	}
+	if z2.Sign() == 0 {
+		return new(big.Int).Set(x1), new(big.Int).Set(y1), new(big.Int).Set(z1)
+	}
+	p := c.P
+	z1z1 := new(big.Int).Mul(z1, z1)
+	z1z1.Mod(z1z1, p)
+	z2z2 := new(big.Int).Mul(z2, z2)
+	z2z2.Mod(z2z2, p)
+	u1 := new(big.Int).Mul(x1, z2z2)
+	u1.Mod(u1, p)
+	u2 := new(big.Int).Mul(x2, z1z1)
+	u2.Mod(u2, p)
+	s1 := new(big.Int).Mul(y1, z2)
+	s1.Mul(s1, z2z2)
+	s1.Mod(s1, p)
+	s2 := new(big.Int).Mul(y2, z1)
+	s2.Mul(s2, z1z1)
+	s2.Mod(s2, p)
+
+	h := new(big.Int).Sub(u2, u1)
+	h.Mod(h, p)
+	r := new(big.Int).Sub(s2, s1)
+	r.Mod(r, p)
+	if h.Sign() == 0 {
+		if r.Sign() == 0 {
+			return c.doubleJacobian(x1, y1, z1)
+		}
+		return new(big.Int), new(big.Int), new(big.Int)
+	}
+
+	hh := new(big.Int).Mul(h, h)
+	hh.Mod(hh, p)
+	hhh := new(big.Int).Mul(hh, h)
+	hhh.Mod(hhh, p)
+	v := new(big.Int).Mul(u1, hh)
+	v.Mod(v, p)
+	// X3 = R^2 - H^3 - 2U1H^2
+	x3 := new(big.Int).Mul(r, r)
+	x3.Sub(x3, hhh)
+	x3.Sub(x3, new(big.Int).Lsh(v, 1))
+	x3.Mod(x3, p)
+	// Y3 = R(U1H^2 - X3) - S1H^3
+	y3 := new(big.Int).Sub(v, x3)
+	y3.Mul(y3, r)
+	s1.Mul(s1, hhh)
+	y3.Sub(y3, s1)
+	y3.Mod(y3, p)
+	// Z3 = H Z1 Z2
+	z3 := new(big.Int).Mul(z1, z2)
+	z3.Mul(z3, h)
+	z3.Mod(z3, p)
+	return x3, y3, z3
+}
+
+// Add 点加，实现 elliptic.Curve
+func (c Curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if c.p256 {
+		return new(p256Point).add(p256PointFromAffine(x1, y1), p256PointFromAffine(x2, y2)).affine()
+	}
+	jx1, jy1, jz1 := c.toJacobian(x1, y1)
+	jx2, jy2, jz2 := c.toJacobian(x2, y2)
+	return c.fromJacobian(c.addJacobian(jx1, jy1, jz1, jx2, jy2, jz2))
+}
+
+// Double 倍点，实现 elliptic.Curve
+func (c Curve) Double(x, y *big.Int) (*big.Int, *big.Int) {
+	if c.p256 {
+		return new(p256Point).double(p256PointFromAffine(x, y)).affine()
+	}
+	return c.fromJacobian(c.doubleJacobian(c.toJacobian(x, y)))
+}
+
+// ScalarMult 多倍点 [k](x, y)，k 为大端字节串，实现 elliptic.Curve
+func (c Curve) ScalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	if c.p256 {
+		return new(p256Point).scalarMult(p256PointFromAffine(x, y), p256Scalar(k)).affine()
+	}
+	bx, by, bz := c.toJacobian(x, y)
+	rx, ry, rz := new(big.Int), new(big.Int), new(big.Int)
+	for _, b := range k {
+		for bit := 0; bit < 8; bit++ {
+			rx, ry, rz = c.doubleJacobian(rx, ry, rz)
+			if b&0x80 == 0x80 {
+				rx, ry, rz = c.addJacobian(rx, ry, rz, bx, by, bz)
+			}
+			b <<= 1
+		}
+	}
+	return c.fromJacobian(rx, ry, rz)
+}
+
+// ScalarBaseMult 多倍点 [k]G，实现 elliptic.Curve
+func (c Curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	if c.p256 {
+		return new(p256Point).scalarBaseMult(p256Scalar(k)).affine()
+	}
+	return c.ScalarMult(c.Gx, c.Gy, k)
+}
+
+// combinedMult [s]G + [t](x, y)，用于签名验证
+func (c Curve) combinedMult(x, y *big.Int, s, t []byte) (*big.Int, *big.Int) {
+	if c.p256 {
+		var table p256Table
+		table.init(p256PointFromAffine(x, y))
+		return p256CombinedMult(&table, p256Scalar(s), p256Scalar(t)).affine()
+	}
+	sgx, sgy := c.ScalarBaseMult(s)
+	tpx, tpy := c.ScalarMult(x, y, t)
+	return c.Add(sgx, sgy, tpx, tpy)
+}
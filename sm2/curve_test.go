@@ -12,3 +12,22 @@ func TestGetSm2P256(t *testing.T) {
 	t.Logf("Gx:%s\n", curve.Params().Gx.Text(16))
 	t.Logf("Gy:%s\n", curve.Params().Gy.Text(16))
 }
+
+// TestCurveGenericA a ≠ -3 的曲线上 [n]G 为无穷远点
+func TestCurveGenericA(t *testing.T) {
+	curve := exampleCurve()
+	if !curve.IsOnCurve(curve.Gx, curve.Gy) {
+		t.Fatal("TestCurveGenericA 失败: G 不在曲线上")
+	}
+	x, y := curve.ScalarBaseMult(curve.N.Bytes())
+	if !IsPointInfinity(x, y) {
+		t.Errorf("TestCurveGenericA 失败: [n]G = (%x, %x)", x, y)
+	}
+	x1, y1 := curve.Double(curve.Gx, curve.Gy)
+	x2, y2 := curve.ScalarBaseMult([]byte{2})
+	if x1.Cmp(x2) != 0 || y1.Cmp(y2) != 0 {
+		t.Errorf(`TestCurveGenericA 失败
+期望值=%x
+实际值=%x`, x1, x2)
+	}
+}
@@ -0,0 +1,90 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"hash"
+	"io"
+	"math/big"
+
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+// -----------------------------------------------------------------------------
+// GB/T 32918.2-2016 5.5 用户的杂凑值和 6.1 A1-A2 消息的杂凑值
+//
+// Z = H(ENTL || ID || a || b || xG || yG || xA || yA)，e = H(Z || M)。H 默认为
+// SM3，签名和验证的入口都可以通过可选参数换成其他杂凑函数，例如部分国际实现使用的
+// SHA-256，双方必须使用相同的 H。杂凑值已经由其他系统（如对大文件流式计算的密码
+// 机）算好时，可以直接用 SignDigest 和 VerifyDigest
+// -----------------------------------------------------------------------------
+
+// hashOrDefault 返回可选参数中的杂凑函数，缺省或为 nil 时为 SM3
+func hashOrDefault(hashFunc []func() hash.Hash) func() hash.Hash {
+	if len(hashFunc) > 0 && hashFunc[0] != nil {
+		return hashFunc[0]
+	}
+	return sm3.New
+}
+
+// ComputeZ 计算用户的杂凑值 Z，userID 为空时使用默认值，hashFunc 为 nil 时使用 SM3
+func ComputeZ(pub *PubKey, userID []byte, hashFunc func() hash.Hash) []byte {
+	if userID == nil {
+		userID = sm2SignDefaultUserID
+	}
+	return getZ(hashOrDefault([]func() hash.Hash{hashFunc})(), &pub.Curve, pub.X, pub.Y, userID)
+}
+
+// ComputeDigest 计算消息的杂凑值 e = H(Z || M)，参数含义同 ComputeZ
+func ComputeDigest(pub *PubKey, userID []byte, msg []byte, hashFunc func() hash.Hash) []byte {
+	h := hashOrDefault([]func() hash.Hash{hashFunc})()
+	z := ComputeZ(pub, userID, hashFunc)
+	h.Write(z)
+	h.Write(msg)
+	return h.Sum(nil)
+}
+
+// SignDigest 对已经计算好的杂凑值 e 签名，输出 ASN.1 DER 编码，random 为 nil 时
+// 使用 crypto/rand.Reader
+func (sk *PrivKey) SignDigest(random io.Reader, e []byte) ([]byte, error) {
+	return sk.SignDigestWithFormat(random, e, SignatureDER)
+}
+
+// SignDigestWithFormat 与 SignDigest 相同，输出 format 编码的签名
+func (sk *PrivKey) SignDigestWithFormat(random io.Reader, e []byte, format SignatureFormat) ([]byte, error) {
+	if len(e) == 0 {
+		return nil, ErrInvalidDigestLength
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+	r, s, err := sk.signE(randomK(random, sk.Curve.N), new(big.Int).SetBytes(e))
+	if err != nil {
+		return nil, err
+	}
+	return sk.Curve.MarshalSignature(&Signature{R: r, S: s}, format)
+}
+
+// VerifyDigest 以已经计算好的杂凑值 e 验证 ASN.1 DER 编码的签名
+func (pk *PubKey) VerifyDigest(e []byte, sig []byte) bool {
+	return pk.CheckDigestSignature(e, sig) == nil
+}
+
+// VerifyDigestWithFormat 以杂凑值 e 验证 format 编码的签名
+func (pk *PubKey) VerifyDigestWithFormat(e []byte, sig []byte, format SignatureFormat) bool {
+	return pk.CheckDigestSignatureWithFormat(e, sig, format) == nil
+}
+
+// CheckDigestSignature 以杂凑值 e 验证 ASN.1 DER 编码的签名，返回签名无效的原因
+func (pk *PubKey) CheckDigestSignature(e []byte, sig []byte) error {
+	return pk.CheckDigestSignatureWithFormat(e, sig, SignatureDER)
+}
+
+// CheckDigestSignatureWithFormat 以杂凑值 e 验证 format 编码的签名，返回签名无效
+// 的原因
+func (pk *PubKey) CheckDigestSignatureWithFormat(e []byte, sig []byte, format SignatureFormat) error {
+	parsed, err := pk.Curve.ParseSignature(sig, format)
+	if err != nil {
+		return err
+	}
+	return pk.verifyE(new(big.Int).SetBytes(e), parsed.R, parsed.S)
+}
@@ -0,0 +1,146 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/utils"
+)
+
+// GB/T 32918.2-2016 附录 A.2 Fp-256 示例，曲线与 exampleCurve 相同
+var (
+	exampleSignD    = "128B2FA8BD433C6C068C8D803DFF79792A519A55171B1B650C23661D15897263"
+	exampleSignID   = []byte("ALICE123@YAHOO.COM")
+	exampleSignMsg  = []byte("message digest")
+	exampleSignZ    = "F4A38489E32B45B6F876E3AC2168CA392362DC8F23459C1D1146FC3DBFB7BC9A"
+	exampleSignE    = "B524F552CD82B8B028476E005C377FB19A87E6FC682D48BB5D42E3D9B9EFFE76"
+	exampleSignK    = "6CB28D99385C175C94F94E934817663FC176D925DD72B727260DBAAE1FB2F96F"
+	exampleSignR    = "40F1EC59F793D9F49E09DCEF49130D4194F79FB1EED2CAA55BACDB49C4E755D1"
+	exampleSignS    = "6FC6DAC32C5D5CF10C77DFB20F7C2EB667A457872FB09EC56327A67EC7DEEBE7"
+	exampleSignPubX = "0AE4C7798AA0F119471BEE11825BE46202BB79E2A5844495E97C04FF4DF2548A"
+	exampleSignPubY = "7C0240F88F1CD4E16352A73C17B7F16F07353E53A176D684A9FE0C6BB798E857"
+)
+
+func TestComputeZExample(t *testing.T) {
+	sk := &PrivKey{D: utils.NewBigIntFromHexString(exampleSignD), Curve: exampleCurve()}
+	pk := sk.GenPubKey()
+	if pk.X.Cmp(utils.NewBigIntFromHexString(exampleSignPubX)) != 0 ||
+		pk.Y.Cmp(utils.NewBigIntFromHexString(exampleSignPubY)) != 0 {
+		t.Fatalf("TestComputeZExample 公钥失败\n期望值=%s%s\n实际值=%x", exampleSignPubX, exampleSignPubY, pk.ToBytes())
+	}
+
+	z := ComputeZ(pk, exampleSignID, nil)
+	if actual := hex.EncodeToString(z); actual != strings.ToLower(exampleSignZ) {
+		t.Errorf("TestComputeZExample Z 失败\n期望值=%s\n实际值=%s", exampleSignZ, actual)
+	}
+	e := ComputeDigest(pk, exampleSignID, exampleSignMsg, nil)
+	if actual := hex.EncodeToString(e); actual != strings.ToLower(exampleSignE) {
+		t.Errorf("TestComputeZExample e 失败\n期望值=%s\n实际值=%s", exampleSignE, actual)
+	}
+
+	// 以示例中的 k 签名
+	k := utils.NewBigIntFromHexString(exampleSignK)
+	r, s, err := sk.signE(func() (*big.Int, error) { return k, nil }, new(big.Int).SetBytes(e))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Cmp(utils.NewBigIntFromHexString(exampleSignR)) != 0 || s.Cmp(utils.NewBigIntFromHexString(exampleSignS)) != 0 {
+		t.Errorf("TestComputeZExample 签名失败\n期望值=%s %s\n实际值=%X %X", exampleSignR, exampleSignS, r, s)
+	}
+	sig, _ := asn1.Marshal(Signature{r, s})
+	if !pk.VerifyDigest(e, sig) {
+		t.Error("TestComputeZExample 失败: VerifyDigest 未通过")
+	}
+	if !pk.Verify(exampleSignID, exampleSignMsg, sig) {
+		t.Error("TestComputeZExample 失败: Verify 未通过")
+	}
+}
+
+func TestSignDigest(t *testing.T) {
+	sk, pk, _ := GenKey(rand.Reader)
+	msg := []byte("external digest")
+	e := ComputeDigest(pk, nil, msg, nil)
+	sig, err := sk.SignDigest(nil, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.Verify(nil, msg, sig) || !pk.VerifyDigest(e, sig) {
+		t.Error("TestSignDigest 失败: 签名未通过验证")
+	}
+	e[0] ^= 1
+	if err := pk.CheckDigestSignature(e, sig); err != ErrVerificationFailed {
+		t.Errorf("TestSignDigest 失败: 篡改 e 后 err=%v", err)
+	}
+	if _, err := sk.SignDigest(nil, nil); err != ErrInvalidDigestLength {
+		t.Errorf("TestSignDigest 失败: 空 e 未报错, err=%v", err)
+	}
+}
+
+func TestSignDigestWithFormat(t *testing.T) {
+	sk, pk, _ := GenKey(rand.Reader)
+	msg := []byte("external digest")
+	e := ComputeDigest(pk, nil, msg, nil)
+	sig, err := sk.SignDigestWithFormat(nil, e, SignatureRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sig) != RawSignatureSize {
+		t.Errorf("TestSignDigestWithFormat 失败\n期望值=%d\n实际值=%d", RawSignatureSize, len(sig))
+	}
+	if !pk.VerifyDigestWithFormat(e, sig, SignatureRaw) || !pk.VerifyWithFormat(nil, msg, sig, SignatureRaw) {
+		t.Error("TestSignDigestWithFormat 失败: 签名未通过验证")
+	}
+	if err := pk.CheckDigestSignatureWithFormat(e, sig, SignatureDER); err != ErrInvalidSignatureEncoding {
+		t.Errorf("TestSignDigestWithFormat 失败\n期望值=%v\n实际值=%v", ErrInvalidSignatureEncoding, err)
+	}
+	der, _ := ConvertSignature(sig, SignatureRaw, SignatureDER)
+	if !pk.VerifyDigest(e, der) {
+		t.Error("TestSignDigestWithFormat 失败: 转换为 DER 后未通过验证")
+	}
+	if _, err := sk.SignDigestWithFormat(nil, e, SignatureFormat(-1)); err != ErrInvalidSignatureEncoding {
+		t.Errorf("TestSignDigestWithFormat 失败\n期望值=%v\n实际值=%v", ErrInvalidSignatureEncoding, err)
+	}
+}
+
+// TestSignWithSHA256 SM2 with SHA-256：各入口使用同一个杂凑函数时互相兼容，
+// 与 SM3 不兼容
+func TestSignWithSHA256(t *testing.T) {
+	sk, pk, _ := GenKey(rand.Reader)
+	msg := []byte("SM2 with SHA-256")
+
+	sig, err := sk.SignToASN1DER(nil, msg, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.Verify(nil, msg, sig, sha256.New) {
+		t.Error("TestSignWithSHA256 失败: Verify 未通过")
+	}
+	if pk.Verify(nil, msg, sig) {
+		t.Error("TestSignWithSHA256 失败: 以 SM3 验证通过")
+	}
+	if !pk.VerifyDigest(ComputeDigest(pk, nil, msg, sha256.New), sig) {
+		t.Error("TestSignWithSHA256 失败: VerifyDigest 未通过")
+	}
+	if !pk.Precompute(nil, sha256.New).VerifyFromASN1DER(msg, sig) {
+		t.Error("TestSignWithSHA256 失败: Verifier 未通过")
+	}
+
+	opts := &SignerOpts{Hash: sha256.New, Deterministic: true}
+	sig2, err := sk.Sign(nil, msg, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pk.CheckSignature(nil, msg, sig2, sha256.New); err != nil {
+		t.Errorf("TestSignWithSHA256 失败: %v", err)
+	}
+	e := ComputeDigest(pk, nil, msg, sha256.New)
+	sig3, _ := sk.Sign(nil, e, &SignerOpts{Hash: sha256.New, Deterministic: true, Prehashed: true})
+	if string(sig3) != string(sig2) {
+		t.Errorf("TestSignWithSHA256 Prehashed 失败\n期望值=%x\n实际值=%x", sig2, sig3)
+	}
+}
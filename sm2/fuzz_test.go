@@ -0,0 +1,97 @@
+//go:build go1.18
+// +build go1.18
+
+package sm2
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+// -----------------------------------------------------------------------------
+// 模糊测试
+//
+// go test -fuzz=FuzzXxx 运行，go test 只运行种子和 testdata/fuzz 下保存的用例，
+// 确认任意输入都只返回错误而不会 panic。testing.F 需要 Go 1.18，更早的版本不编译
+// 本文件
+// -----------------------------------------------------------------------------
+
+func fuzzKey() (*PrivKey, *PubKey) {
+	d, _ := new(big.Int).SetString(opensslCipherKey, 16)
+	sk := &PrivKey{D: d, Curve: sm2P256}
+	return sk, sk.GenPubKey()
+}
+
+func FuzzDecrypt(f *testing.F) {
+	sk, pk := fuzzKey()
+	for _, mode := range []CiphertextMode{C1C2C3, C1C3C2} {
+		ct, _ := pk.EncryptWithMode(rand.Reader, []byte("fuzz"), mode)
+		f.Add(ct, byte(mode))
+		f.Add(ct[:1+2*KeyBytes+32], byte(mode))
+	}
+	f.Add([]byte{}, byte(0))
+	f.Add([]byte{UnCompressed}, byte(0))
+	f.Add([]byte{CompressedEven, 0}, byte(1))
+	f.Fuzz(func(t *testing.T, in []byte, mode byte) {
+		pt, err := sk.DecryptWithMode(in, CiphertextMode(mode))
+		if err == nil && len(pt) == 0 {
+			t.Errorf("FuzzDecrypt 失败: 解密 %x 得到空明文", in)
+		}
+	})
+}
+
+func FuzzUnmarshalCiphertextFromASN1DER(f *testing.F) {
+	for _, c := range opensslCiphers {
+		der, _ := hex.DecodeString(c)
+		f.Add(der)
+	}
+	f.Add([]byte{0x30, 0x00})
+	f.Fuzz(func(t *testing.T, der []byte) {
+		ct, err := UnmarshalCiphertextFromASN1DER(der, C1C3C2)
+		if err != nil {
+			return
+		}
+		if _, err := MarshalCiphertextToASN1DER(ct, C1C3C2); err != nil {
+			t.Errorf("FuzzUnmarshalCiphertextFromASN1DER 失败: %x 无法重新编码: %v", der, err)
+		}
+	})
+}
+
+func FuzzCheckSignature(f *testing.F) {
+	sk, pk := fuzzKey()
+	msg := []byte("fuzz")
+	sig, _ := sk.SignToASN1DER(nil, msg)
+	f.Add(sig)
+	f.Add([]byte{0x30, 0x06, 0x02, 0x01, 0x00, 0x02, 0x01, 0x01})
+	f.Add([]byte{0x30, 0x06, 0x02, 0x01, 0xff, 0x02, 0x01, 0x01})
+	f.Add([]byte{0x30, 0x00})
+	v := pk.Precompute(nil)
+	f.Fuzz(func(t *testing.T, sig []byte) {
+		err := pk.CheckSignature(nil, msg, sig)
+		if err != nil && err != ErrInvalidSignatureEncoding && err != ErrVerificationFailed {
+			t.Errorf("FuzzCheckSignature 失败: 未定义的错误 %v", err)
+		}
+		if verr := v.CheckSignature(msg, sig); verr != err {
+			t.Errorf("FuzzCheckSignature 失败: Verifier 结果不一致\n期望值=%v\n实际值=%v", err, verr)
+		}
+	})
+}
+
+func FuzzParsePubKey(f *testing.F) {
+	_, pk := fuzzKey()
+	f.Add(pk.ToUncompressedBytes())
+	f.Add(pk.ToCompressedBytes())
+	f.Add(pk.ToHybridBytes())
+	f.Add([]byte{CompressedOdd})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		key, err := ParsePubKey(data)
+		if err != nil {
+			return
+		}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			t.Errorf("FuzzParsePubKey 失败: %x 不在曲线上", data)
+		}
+	})
+}
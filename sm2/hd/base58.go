@@ -0,0 +1,101 @@
+package hd
+
+import (
+	"crypto/subtle"
+	"errors"
+	"math/big"
+
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+// -----------------------------------------------------------------------------
+// Base58Check
+//
+// 字母表与 BIP32 相同，校验码为 SM3(SM3(payload)) 的前 4 字节
+// -----------------------------------------------------------------------------
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var (
+	ErrInvalidBase58 = errors.New("hd: invalid base58 string")
+	ErrChecksum      = errors.New("hd: checksum mismatch")
+)
+
+var base58Index = func() [256]int {
+	var index [256]int
+	for i := range index {
+		index[i] = -1
+	}
+	for i := 0; i < len(base58Alphabet); i++ {
+		index[base58Alphabet[i]] = i
+	}
+	return index
+}()
+
+func base58Encode(data []byte) string {
+	x := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for x.Sign() > 0 {
+		x.DivMod(x, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	// 每个前导 0 字节对应一个 '1'
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func base58Decode(s string) ([]byte, error) {
+	x := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		d := base58Index[s[i]]
+		if d < 0 {
+			return nil, ErrInvalidBase58
+		}
+		x.Mul(x, radix)
+		x.Add(x, big.NewInt(int64(d)))
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), x.Bytes()...), nil
+}
+
+func checksum(payload []byte) []byte {
+	h := sm3.New()
+	h.Write(payload)
+	first := h.Sum(nil)
+	h.Reset()
+	h.Write(first)
+	return h.Sum(nil)[:4]
+}
+
+func base58CheckEncode(payload []byte) string {
+	return base58Encode(append(append([]byte(nil), payload...), checksum(payload)...))
+}
+
+func base58CheckDecode(s string) ([]byte, error) {
+	data, err := base58Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, ErrInvalidBase58
+	}
+	payload, sum := data[:len(data)-4], data[len(data)-4:]
+	if subtle.ConstantTimeCompare(checksum(payload), sum) != 1 {
+		return nil, ErrChecksum
+	}
+	return payload, nil
+}
@@ -0,0 +1,65 @@
+package hd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// TestBase58 比特币 base58_encode_decode.json 中的向量
+func TestBase58(t *testing.T) {
+	tests := []struct{ in, out string }{
+		{"", ""},
+		{"61", "2g"},
+		{"626262", "a3gV"},
+		{"636363", "aPEr"},
+		{"73696d706c792061206c6f6e6720737472696e67", "2cFupjhnEsSn59qHXstmK2ffpLv2"},
+		{"00eb15231dfceb60925886b67d065299925915aeb172c06647", "1NS17iag9jJgTHD1VXjvLCEnZuQ3rJDE9L"},
+		{"516b6fcd0f", "ABnLTmg"},
+		{"bf4f89001e670274dd", "3SEo3LWLoPntC"},
+		{"572e4794", "3EFU7m"},
+		{"ecac89cad93923c02321", "EJDM8drfXA6uyA"},
+		{"10c8511e", "Rt5zm"},
+		{"00000000000000000000", "1111111111"},
+	}
+	for _, tt := range tests {
+		in := mustHex(tt.in)
+		if got := base58Encode(in); got != tt.out {
+			t.Errorf("TestBase58 编码失败\n期望值=%s\n实际值=%s", tt.out, got)
+		}
+		got, err := base58Decode(tt.out)
+		if err != nil || !bytes.Equal(got, in) {
+			t.Errorf("TestBase58 解码失败\n期望值=%x\n实际值=%x", in, got)
+		}
+	}
+	for _, s := range []string{"0", "O", "I", "l", "+"} {
+		if _, err := base58Decode(s); err != ErrInvalidBase58 {
+			t.Errorf("TestBase58 %q 失败: err=%v", s, err)
+		}
+	}
+}
+
+func TestBase58Check(t *testing.T) {
+	payload := []byte("gogmlib")
+	s := base58CheckEncode(payload)
+	got, err := base58CheckDecode(s)
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("TestBase58Check 失败\n期望值=%x\n实际值=%x", payload, got)
+	}
+	data, _ := base58Decode(s)
+	data[0] ^= 1
+	if _, err := base58CheckDecode(base58Encode(data)); err != ErrChecksum {
+		t.Errorf("TestBase58Check 失败: err=%v", err)
+	}
+	if _, err := base58CheckDecode("1"); err != ErrInvalidBase58 {
+		t.Errorf("TestBase58Check 失败: err=%v", err)
+	}
+}
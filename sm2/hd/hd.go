@@ -0,0 +1,353 @@
+// Package hd 按 BIP32 的结构由种子分层确定性地派生 SM2 密钥，HMAC-SHA512 换成
+// HMAC-SM3，派生结果是普通的 sm2.PrivKey 和 sm2.PubKey
+package hd
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/t1anchen/gogmlib/sm2"
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+// -----------------------------------------------------------------------------
+// 分层确定性密钥派生
+//
+// SM3 的输出只有 32 字节，BIP32 中 64 字节的 I = HMAC-SHA512(c, data) 改为
+//
+//	I = HMAC-SM3(c, data || 0x01) || HMAC-SM3(c, data || 0x02)
+//
+// IL 为私钥或私钥增量，IR 为链码。其余与 BIP32 相同：
+//
+//   - 主密钥：c = "SM2 seed"，data = 种子，要求 IL ∈ [1, n-2]
+//   - 强化子密钥（i >= 2^31）：data = 0x00 || ser256(kpar) || ser32(i)
+//   - 普通子密钥：data = serP(Kpar) || ser32(i)，serP 为 33 字节压缩形式
+//   - ki = IL + kpar mod n，Ki = [IL]G + Kpar，IL >= n 或 ki 不在 [1, n-2] 内
+//     时该序号无效，调用者应当使用下一个序号
+//   - 指纹为 SM3(serP(K)) 的前 4 字节
+//
+// 扩展密钥序列化为 78 字节后以 Base58Check 编码，版本号是本库自定义的，与
+// 比特币的 xprv、xpub 不同，避免与 secp256k1 的扩展密钥混用
+// -----------------------------------------------------------------------------
+
+const (
+	// HardenedKeyStart 强化子密钥的起始序号 2^31
+	HardenedKeyStart = 0x80000000
+	// MinSeedBytes 和 MaxSeedBytes 种子长度的范围，与 BIP32 相同
+	MinSeedBytes = 16
+	MaxSeedBytes = 64
+
+	serializedKeyLen = 78
+)
+
+// 扩展密钥的版本号
+var (
+	VersionPrivate = [4]byte{0x04, 0x53, 0x4d, 0x32}
+	VersionPublic  = [4]byte{0x04, 0x53, 0x4d, 0x33}
+)
+
+var masterKey = []byte("SM2 seed")
+
+var (
+	ErrInvalidSeedLength = errors.New("hd: seed length must be between 16 and 64 bytes")
+	ErrUnusableSeed      = errors.New("hd: seed does not yield a valid master key")
+	ErrInvalidChild      = errors.New("hd: child index yields an invalid key, use the next index")
+	ErrHardenedFromPub   = errors.New("hd: cannot derive a hardened key from a public key")
+	ErrNotPrivate        = errors.New("hd: extended key is public")
+	ErrDepthExceeded     = errors.New("hd: cannot derive beyond depth 255")
+	ErrInvalidKeyData    = errors.New("hd: invalid serialized extended key")
+	ErrInvalidPath       = errors.New("hd: invalid derivation path")
+)
+
+// ExtendedKey 扩展密钥，私钥和公钥共用一个结构，创建后只读
+type ExtendedKey struct {
+	depth       uint8
+	parentFP    [4]byte
+	childNumber uint32
+	chainCode   []byte
+	// d 为 nil 时是扩展公钥
+	d    *big.Int
+	x, y *big.Int
+}
+
+// NewMaster 由种子生成主密钥
+func NewMaster(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < MinSeedBytes || len(seed) > MaxSeedBytes {
+		return nil, ErrInvalidSeedLength
+	}
+	curve := sm2.GetSm2P256()
+	il, ir := hmacSM3(masterKey, seed)
+	d := new(big.Int).SetBytes(il)
+	if !inKeyRange(d, curve.N) {
+		return nil, ErrUnusableSeed
+	}
+	x, y := curve.ScalarBaseMult(il)
+	return &ExtendedKey{chainCode: ir, d: d, x: x, y: y}, nil
+}
+
+// hmacSM3 返回 I 的左右两半
+func hmacSM3(key, data []byte) (il, ir []byte) {
+	mac := hmac.New(sm3.New, key)
+	mac.Write(data)
+	mac.Write([]byte{0x01})
+	il = mac.Sum(nil)
+	mac.Reset()
+	mac.Write(data)
+	mac.Write([]byte{0x02})
+	ir = mac.Sum(nil)
+	return il, ir
+}
+
+// inKeyRange 判断 d ∈ [1, n-2]，与 sm2.PrivKey.Validate 相同
+func inKeyRange(d, n *big.Int) bool {
+	return d.Sign() > 0 && new(big.Int).Add(d, big.NewInt(1)).Cmp(n) < 0
+}
+
+// IsPrivate 是否为扩展私钥
+func (k *ExtendedKey) IsPrivate() bool {
+	return k.d != nil
+}
+
+// Depth 深度，主密钥为 0
+func (k *ExtendedKey) Depth() uint8 {
+	return k.depth
+}
+
+// ChildNumber 本密钥在父密钥下的序号
+func (k *ExtendedKey) ChildNumber() uint32 {
+	return k.childNumber
+}
+
+// ParentFingerprint 父密钥的指纹，主密钥为 0
+func (k *ExtendedKey) ParentFingerprint() [4]byte {
+	return k.parentFP
+}
+
+// ChainCode 链码
+func (k *ExtendedKey) ChainCode() []byte {
+	return append([]byte(nil), k.chainCode...)
+}
+
+// Fingerprint 本密钥的指纹
+func (k *ExtendedKey) Fingerprint() [4]byte {
+	var fp [4]byte
+	h := sm3.New()
+	h.Write(k.PubKey().ToCompressedBytes())
+	copy(fp[:], h.Sum(nil))
+	return fp
+}
+
+// PrivKey 返回 SM2 私钥，扩展公钥返回 ErrNotPrivate
+func (k *ExtendedKey) PrivKey() (*sm2.PrivKey, error) {
+	if k.d == nil {
+		return nil, ErrNotPrivate
+	}
+	return &sm2.PrivKey{D: new(big.Int).Set(k.d), Curve: sm2.GetSm2P256()}, nil
+}
+
+// PubKey 返回 SM2 公钥
+func (k *ExtendedKey) PubKey() *sm2.PubKey {
+	return &sm2.PubKey{X: new(big.Int).Set(k.x), Y: new(big.Int).Set(k.y), Curve: sm2.GetSm2P256()}
+}
+
+// Neuter 返回对应的扩展公钥，用于只能派生公钥的观察服务
+func (k *ExtendedKey) Neuter() *ExtendedKey {
+	pub := *k
+	pub.d = nil
+	return &pub
+}
+
+// Derive 派生序号为 i 的子密钥，i >= HardenedKeyStart 时为强化子密钥，只能由扩展
+// 私钥派生。返回 ErrInvalidChild 时应当使用下一个序号
+func (k *ExtendedKey) Derive(i uint32) (*ExtendedKey, error) {
+	if k.depth == 255 {
+		return nil, ErrDepthExceeded
+	}
+	hardened := i >= HardenedKeyStart
+	if hardened && k.d == nil {
+		return nil, ErrHardenedFromPub
+	}
+
+	data := make([]byte, 0, 37)
+	if hardened {
+		data = append(data, 0x00)
+		data = append(data, ser256(k.d)...)
+	} else {
+		data = append(data, k.PubKey().ToCompressedBytes()...)
+	}
+	var index [4]byte
+	binary.BigEndian.PutUint32(index[:], i)
+	data = append(data, index[:]...)
+
+	curve := sm2.GetSm2P256()
+	n := curve.N
+	il, ir := hmacSM3(k.chainCode, data)
+	t := new(big.Int).SetBytes(il)
+	if t.Cmp(n) >= 0 {
+		return nil, ErrInvalidChild
+	}
+
+	child := &ExtendedKey{
+		depth:       k.depth + 1,
+		parentFP:    k.Fingerprint(),
+		childNumber: i,
+		chainCode:   ir,
+	}
+	if k.d != nil {
+		// ki = IL + kpar mod n
+		d := t.Add(t, k.d)
+		d.Mod(d, n)
+		if !inKeyRange(d, n) {
+			return nil, ErrInvalidChild
+		}
+		child.d = d
+		child.x, child.y = curve.ScalarBaseMult(ser256(d))
+		return child, nil
+	}
+	// Ki = [IL]G + Kpar，Ki = O 或 -G 时 ki 为 0 或 n-1
+	gx, gy := curve.ScalarBaseMult(il)
+	x, y := curve.Add(gx, gy, k.x, k.y)
+	if sm2.IsPointInfinity(x, y) || (x.Cmp(curve.Gx) == 0 && y.Cmp(curve.Gy) != 0) {
+		return nil, ErrInvalidChild
+	}
+	child.x, child.y = x, y
+	return child, nil
+}
+
+// DerivePath 按 "m/44'/0'/1" 形式的路径派生，' 或 h 表示强化序号
+func (k *ExtendedKey) DerivePath(path string) (*ExtendedKey, error) {
+	indices, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	key := k
+	for _, i := range indices {
+		if key, err = key.Derive(i); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// ParsePath 解析派生路径，路径必须以 m 开头
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, ErrInvalidPath
+	}
+	indices := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		var offset uint32
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") {
+			offset = HardenedKeyStart
+			part = part[:len(part)-1]
+		}
+		i, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || i >= HardenedKeyStart {
+			return nil, ErrInvalidPath
+		}
+		indices = append(indices, uint32(i)+offset)
+	}
+	return indices, nil
+}
+
+// ser256 32 字节大端定长编码
+func ser256(d *big.Int) []byte {
+	out := make([]byte, 32)
+	b := d.Bytes()
+	copy(out[32-len(b):], b)
+	return out
+}
+
+// -----------------------------------------------------------------------------
+// 序列化
+//
+// version(4) || depth(1) || 父密钥指纹(4) || 序号(4) || 链码(32) || 密钥(33)，
+// 私钥为 0x00 || ser256(k)，公钥为压缩形式
+// -----------------------------------------------------------------------------
+
+// Serialize 返回 78 字节的序列化结果
+func (k *ExtendedKey) Serialize() []byte {
+	out := make([]byte, 0, serializedKeyLen)
+	if k.d != nil {
+		out = append(out, VersionPrivate[:]...)
+	} else {
+		out = append(out, VersionPublic[:]...)
+	}
+	out = append(out, k.depth)
+	out = append(out, k.parentFP[:]...)
+	var index [4]byte
+	binary.BigEndian.PutUint32(index[:], k.childNumber)
+	out = append(out, index[:]...)
+	out = append(out, k.chainCode...)
+	if k.d != nil {
+		out = append(out, 0x00)
+		out = append(out, ser256(k.d)...)
+	} else {
+		out = append(out, k.PubKey().ToCompressedBytes()...)
+	}
+	return out
+}
+
+// String 返回 Base58Check 编码
+func (k *ExtendedKey) String() string {
+	return base58CheckEncode(k.Serialize())
+}
+
+// ParseExtendedKey 解析 Base58Check 编码的扩展密钥
+func ParseExtendedKey(s string) (*ExtendedKey, error) {
+	data, err := base58CheckDecode(s)
+	if err != nil {
+		return nil, err
+	}
+	return Deserialize(data)
+}
+
+// Deserialize 解析 78 字节的序列化结果，并验证其中的私钥或公钥
+func Deserialize(data []byte) (*ExtendedKey, error) {
+	if len(data) != serializedKeyLen {
+		return nil, ErrInvalidKeyData
+	}
+	var version [4]byte
+	copy(version[:], data[:4])
+	k := &ExtendedKey{
+		depth:       data[4],
+		childNumber: binary.BigEndian.Uint32(data[9:13]),
+		chainCode:   append([]byte(nil), data[13:45]...),
+	}
+	copy(k.parentFP[:], data[5:9])
+	if k.depth == 0 && (k.parentFP != [4]byte{} || k.childNumber != 0) {
+		return nil, ErrInvalidKeyData
+	}
+
+	keyData := data[45:]
+	curve := sm2.GetSm2P256()
+	switch version {
+	case VersionPrivate:
+		if keyData[0] != 0x00 {
+			return nil, ErrInvalidKeyData
+		}
+		d := new(big.Int).SetBytes(keyData[1:])
+		if !inKeyRange(d, curve.N) {
+			return nil, ErrInvalidKeyData
+		}
+		k.d = d
+		k.x, k.y = curve.ScalarBaseMult(keyData[1:])
+	case VersionPublic:
+		if keyData[0] != 0x02 && keyData[0] != 0x03 {
+			return nil, ErrInvalidKeyData
+		}
+		pub, err := sm2.ParsePubKey(keyData)
+		if err != nil {
+			return nil, ErrInvalidKeyData
+		}
+		k.x, k.y = pub.X, pub.Y
+	default:
+		return nil, ErrInvalidKeyData
+	}
+	return k, nil
+}
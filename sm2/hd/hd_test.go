@@ -0,0 +1,238 @@
+package hd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/sm2"
+)
+
+// hdTestVectors 种子和派生路径与 BIP32 测试向量 1、2 相同，结果由本库和独立的
+// Python 实现分别计算并核对
+var hdTestVectors = []struct {
+	seed  string
+	chain []struct{ path, prv, pub string }
+}{
+	{
+		"000102030405060708090a0b0c0d0e0f",
+		[]struct{ path, prv, pub string }{
+			{"m",
+				"vFxVcksDhy1NKKenWq24f37sWdDeNpsR8o12n8tifx8kRPuynjbPZRWE3Aq7dT3foYu53E4Ht4DhH2d5SV5t6Zsr6WLCcSjNSsNpwcvRZpSmRUm",
+				"vFxVm4ZuSsWidKnABoyPSfFTYtw2PpYrtfU5zDZuA5mSHLBPkRX1eH1Zfs8VxNf9aDH5VrpWSKmfVqrkQCkXHKo3KrVVBudbCWeP4QXSyC3PoNd"},
+			{"m/0'",
+				"vFxVcoi8yxxKmwsLt32ckMSs82qDsucZPAU8USnTVxeCBZAVpcp7VPAjDsQW5woxhpga3mVCySNSbJqXd5e8KtnhH2xNQhWocTWZbKAFcwGPd3S",
+				"vFxVm7QpisTg5wziZ1ywXyaTAJYbtuJ192wBgXTdz6Gt3VRunJjjaEg4rZhtQt1b2sM2AsEM6rx8Yssk3z8rsNznVjsBGAqn6vf7FF4Bqm2aa8U"},
+			{"m/0'/1",
+				"vFxVcpjKHqRzb4Nmj2rKuwRdJYjdERCwMFrd1SPN7LiKNwzrfQ92ikAkTtFpKMPGxKzTbTuqWihUoEZkDjoM1CuVTmVn17gs34Hpo6JkGXaqgYq",
+				"vFxVm8S12jwLu4W9Q1oehZZDLpT1FQtP78KgDX4YbUM1EtGGd64eobg66aZCeHc5bncTYhPDKLEGvBN7QUbCVWhgTo7MfLNF7DSP2dRtxgytMVP"},
+			{"m/0'/1/2'",
+				"vFxVcs8baQCZUKTCRcqNqTaffhvf6FHCqQExJddubcCdpYTWx9euFKdzFavxsaoz8dsQpWTJ5bHAaUwwCHKsRec4Zjk3Cky9Wi6LtPJdPf9dRjW",
+				"vFxVmAqHKJhunKaa6bnhd5iFhye37ExebGi1WiK65jqKgUivuqaXLB9KtHEMCXkzkHughvwUpLwCJZhJR5zz9hLoNuheUhtCwfJBRxW8uEen9vU"},
+			{"m/0'/1/2'/2",
+				"vFxVcupgR9GJpAXQubwNaJviwDhyYdte8eFs5yViKxT6HC1QVpA1fTPqVXn9FFfsSxT86PeJZNBp3Fy83Q1BaTLcpbVL5w8a9CNDDbfkU5dY67P",
+				"vFxVmDXNA3mf8AenaathMw4JyVRMZda5tWivJ4Atp65n98GpTW5dkJuB8E5XaDcGsXNBUkp2V3Mgq3tbUMo885haakdP3cHeccc8iMiFYiwGySA"},
+			{"m/0'/1/2'/2/1000000000",
+				"vFxVcvhXBupTNdZ6u5EzHvKiLMaV4371ZASHAkXrPppLc5kYZLXpi347GoP17waogW9APwWrr2D5EegQGmVK6mapqXGL4W3gQjRggJJAUC95Dyf",
+				"vFxVmEQCvpKogdgUa4CK5YTJNdHs52nTK2uLNqD2sxT2U21xX2TSntZSuVgPSuCh6tWJLv5wQUcL45XNqbmDZbBFviWMBtQDJDVGKSY3huaNWhE"},
+		},
+	},
+	{
+		"fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542",
+		[]struct{ path, prv, pub string }{
+			{"m",
+				"vFxVcksDhy1NKKenYWwRdaMGtNWYdykwSC2wvTppqt5QofKyqVQBfUktsNAmbCcYMbtv3xU2xP8vve1pep3gsoGg4YewkgyT1qM6Br8eAFDhJGn",
+				"vFxVm4ZuSsWidKnADVtkRCUrveDveySPC4W18YW1L1i6fbbPoBKokLGEW4U9v87xm6hSMXUotZ3rrw1DX71RZnJKn5DNfYwEneVsxFmFnk12W6h"},
+			{"m/0",
+				"vFxVcoG6XwV4NSDDEa76mGaVftzfSPPZLMTQDdLxRebvrs19NHwxcfqcfsZ9X6yP4Dw9SFopEziMyLtanT6AxHPysy7go4fbbeas4wbiModx351",
+				"vFxVm6xnGqzQgSLauZ4RYti5iAi3TP516DvTRi28unEcioGZKysahXLxJZrXr3u297gLwdWrebBQSZaCE6zQorzyD8qm5q5bFjjVb1fpyxSyD2q"},
+			{"m/0/2147483647'",
+				"vFxVcqA9u58KKA9zFMwboRvKLXR8UqukVTkVwNDtAHncERZWxy8GzG2DakkdWRNaW7eKiG8F4gQWHvXzx6aEtLsGfrUEdSeNhJm9ffQfjEUyc6j",
+				"vFxVm8rqdydfdAHMvLtvb43uNo8WVqbCFLDZ9Su4eRRJ6Mpvvf3u57XZDT41qPqUcQiuZYPqfDkJwzVPsf4RxGfQ8iHP3JWWCm9BnpT4Fc9rUhm"},
+			{"m/0/2147483647'/1",
+				"vFxVcsMeuKzvSLHXp9T8g2FNrkb2RRJy3EVsQKkBSSCCLSGmr7b8VNKPVFQvb87Xqec5L4wjSr68TSJibNjjDsa2sT6ZRk2qkmeeriXytAB8Qqp",
+				"vFxVmB4LeEWGkLQuV8QTTeNxu2JQSQzQo6xvcQRMvZptCNYBooWkaDpj7wiJv3qtt912S9gCMrXvM2zwnjWS9RSBdEQb6UBsBfkbFcUhueoF4Zt"},
+			{"m/0/2147483647'/1/2147483646'",
+				"vFxVcufguBb8EAauAVRzv3g64gZSj4w1bTfrtsP5uRaXGuA4Gxzj92ZZ23cj4nqCFF8hFxRj11sHRgA1ASFDaM9rhFKzr29ToGYPXQ7VZFctLwC",
+				"vFxVmDNNe66UYAiGqUPKhfog6xGpk4cTML8v6x4GPZDD8qRUEevMDt4tejv7PkHFDtqXpB6TcQkznCkZ9GeBeS3d8UhaPhZE5U81toZo15ij7Wu"},
+			{"m/0/2147483647'/1/2147483646'/2",
+				"vFxVcvvWQpac5ipbbKZP5Rm7PCbg7zbcoB4vntwYx99Fc8XH1rKbC3Ap7Dmcd8K9AZVCceHEFF8KKEHbYFm8dXnLZbxJTvoefmKK2BdpiSDXGdm",
+				"vFxVmEdC9j5xPiwyGJWhs3thRUK48zH4Z3XyzycjSGmwU4ngyYFDGtg9jv4zx5DvJ4gRZrb5W6H6BVq9h9WHghUhz3EUzcSsEJACZp7tmtC4dq8"},
+		},
+	},
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range hdTestVectors {
+		master, err := NewMaster(mustHex(v.seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range v.chain {
+			k, err := master.DerivePath(c.path)
+			if err != nil {
+				t.Fatalf("TestVectors %s 失败: %v", c.path, err)
+			}
+			if got := k.String(); got != c.prv {
+				t.Errorf("TestVectors %s 扩展私钥失败\n期望值=%s\n实际值=%s", c.path, c.prv, got)
+			}
+			if got := k.Neuter().String(); got != c.pub {
+				t.Errorf("TestVectors %s 扩展公钥失败\n期望值=%s\n实际值=%s", c.path, c.pub, got)
+			}
+			for _, s := range []string{c.prv, c.pub} {
+				parsed, err := ParseExtendedKey(s)
+				if err != nil {
+					t.Fatalf("TestVectors %s 解析失败: %v", c.path, err)
+				}
+				if parsed.String() != s {
+					t.Errorf("TestVectors %s 解析失败\n期望值=%s\n实际值=%s", c.path, s, parsed.String())
+				}
+			}
+		}
+	}
+}
+
+// TestPublicDerivation 普通子密钥由扩展公钥派生的结果与由扩展私钥派生的相同
+func TestPublicDerivation(t *testing.T) {
+	master, err := NewMaster(mustHex(hdTestVectors[0].seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := master.DerivePath("m/44'/0'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	watch := account.Neuter()
+	for i := uint32(0); i < 8; i++ {
+		priv, err := account.DerivePath(fmt.Sprintf("m/0/%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := watch.Derive(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pub, err = pub.Derive(i); err != nil {
+			t.Fatal(err)
+		}
+		if pub.IsPrivate() || pub.String() != priv.Neuter().String() {
+			t.Errorf("TestPublicDerivation 失败\n期望值=%s\n实际值=%s", priv.Neuter(), pub)
+		}
+		if _, err := pub.PrivKey(); err != ErrNotPrivate {
+			t.Errorf("TestPublicDerivation 失败: err=%v", err)
+		}
+	}
+	if _, err := watch.Derive(HardenedKeyStart); err != ErrHardenedFromPub {
+		t.Errorf("TestPublicDerivation 失败: 由扩展公钥派生强化子密钥时 err=%v", err)
+	}
+}
+
+// TestSign 派生的密钥是普通的 SM2 密钥
+func TestSign(t *testing.T) {
+	master, err := NewMaster(mustHex(hdTestVectors[1].seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := master.DerivePath("m/1h/2/3h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, err := k.PrivKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sk.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !sk.GenPubKey().Equal(k.PubKey()) {
+		t.Fatalf("TestSign 失败: 私钥和公钥不对应")
+	}
+	msg := []byte("hd")
+	sig, err := sk.SignToASN1DER(nil, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !k.PubKey().Verify(nil, msg, sig) {
+		t.Errorf("TestSign 失败: sig=%x", sig)
+	}
+}
+
+func TestNewMasterInvalidSeed(t *testing.T) {
+	for _, n := range []int{0, MinSeedBytes - 1, MaxSeedBytes + 1} {
+		if _, err := NewMaster(make([]byte, n)); err != ErrInvalidSeedLength {
+			t.Errorf("TestNewMasterInvalidSeed 失败: 长度 %d err=%v", n, err)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	indices, err := ParsePath("m/44'/0h/1/2147483647")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint32{HardenedKeyStart + 44, HardenedKeyStart, 1, 2147483647}
+	if len(indices) != len(want) {
+		t.Fatalf("TestParsePath 失败\n期望值=%v\n实际值=%v", want, indices)
+	}
+	for i := range want {
+		if indices[i] != want[i] {
+			t.Errorf("TestParsePath 失败\n期望值=%v\n实际值=%v", want, indices)
+		}
+	}
+	for _, path := range []string{"", "n/0", "m/", "m/-1", "m/2147483648", "m/1''", "m/x"} {
+		if _, err := ParsePath(path); err != ErrInvalidPath {
+			t.Errorf("TestParsePath %q 失败: err=%v", path, err)
+		}
+	}
+}
+
+func TestDeserializeInvalid(t *testing.T) {
+	master, err := NewMaster(mustHex(hdTestVectors[0].seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := master.Derive(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := sm2.GetSm2P256().N
+	tests := []struct {
+		name   string
+		key    *ExtendedKey
+		modify func(data []byte) []byte
+	}{
+		{"长度错误", master, func(data []byte) []byte { return data[:77] }},
+		{"版本号错误", master, func(data []byte) []byte { data[3] = 0; return data }},
+		{"主密钥的指纹不为 0", master, func(data []byte) []byte { data[5] = 1; return data }},
+		{"主密钥的序号不为 0", master, func(data []byte) []byte { data[12] = 1; return data }},
+		{"私钥前缀不为 0", master, func(data []byte) []byte { data[45] = 1; return data }},
+		{"私钥为 0", master, func(data []byte) []byte { copy(data[46:], make([]byte, 32)); return data }},
+		{"私钥为 n", master, func(data []byte) []byte { copy(data[46:], ser256(n)); return data }},
+		{"公钥前缀错误", child.Neuter(), func(data []byte) []byte { data[45] = 0x04; return data }},
+		{"公钥不在曲线上", child.Neuter(), func(data []byte) []byte {
+			// x = 2 时 x^3 + ax + b 不是二次剩余
+			copy(data[46:], make([]byte, 32))
+			data[77] = 2
+			return data
+		}},
+	}
+	for _, tt := range tests {
+		data := tt.modify(tt.key.Serialize())
+		if _, err := Deserialize(data); err != ErrInvalidKeyData {
+			t.Errorf("TestDeserializeInvalid %s 失败: err=%v", tt.name, err)
+		}
+		if _, err := ParseExtendedKey(base58CheckEncode(data)); err != ErrInvalidKeyData {
+			t.Errorf("TestDeserializeInvalid %s 失败: err=%v", tt.name, err)
+		}
+	}
+
+	s := []byte(master.String())
+	s[len(s)-1] ^= 1
+	if _, err := ParseExtendedKey(string(s)); err != ErrChecksum && err != ErrInvalidBase58 {
+		t.Errorf("TestDeserializeInvalid 校验码失败: err=%v", err)
+	}
+	if !bytes.Equal(child.ChainCode(), child.Neuter().ChainCode()) || child.Depth() != 1 ||
+		child.ChildNumber() != 1 || child.ParentFingerprint() != master.Fingerprint() {
+		t.Errorf("TestDeserializeInvalid 失败: 子密钥的元数据错误")
+	}
+}
@@ -0,0 +1,238 @@
+// Package jwk 实现 SM2 密钥的 JSON Web Key（RFC 7517）编码，kty 为 "EC"，crv 为
+// "SM2"，与现有 SM2 JOSE 实现一致
+package jwk
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash"
+	"math/big"
+
+	"github.com/t1anchen/gogmlib/sm2"
+)
+
+// -----------------------------------------------------------------------------
+// SM2 JWK
+//
+// 成员与 RFC 7518 6.2 椭圆曲线密钥相同：x、y 为坐标，d 为私钥，都是 base64url
+// 编码（不带填充）的 32 字节大端定长字节串。解析时要求长度准确、公钥按
+// GB/T 32918.1-2016 6.2.1 验证、私钥在 [1, n-2] 内且与公钥对应。
+//
+// 只有推荐曲线有 crv 名称，其他曲线的密钥不能编码
+// -----------------------------------------------------------------------------
+
+const (
+	KeyType   = "EC"
+	CurveName = "SM2"
+)
+
+var (
+	ErrUnsupportedKey   = errors.New("jwk: unsupported key type or curve")
+	ErrUnsupportedCurve = errors.New("jwk: only the SM2 recommended curve can be encoded")
+	ErrInvalidKey       = errors.New("jwk: invalid key member")
+	ErrKeyMismatch      = errors.New("jwk: private key does not match the public key")
+)
+
+// JWK 一个 SM2 JSON Web Key，PrivKey 为 nil 时是公钥
+type JWK struct {
+	PubKey  *sm2.PubKey
+	PrivKey *sm2.PrivKey
+	// KeyID、Use、Algorithm 和 KeyOps 对应 kid、use、alg 和 key_ops，为空时省略
+	KeyID     string
+	Use       string
+	Algorithm string
+	KeyOps    []string
+}
+
+// rawJWK JSON 中的成员
+type rawJWK struct {
+	Kty    string   `json:"kty"`
+	Crv    string   `json:"crv,omitempty"`
+	X      string   `json:"x,omitempty"`
+	Y      string   `json:"y,omitempty"`
+	D      string   `json:"d,omitempty"`
+	Kid    string   `json:"kid,omitempty"`
+	Use    string   `json:"use,omitempty"`
+	Alg    string   `json:"alg,omitempty"`
+	KeyOps []string `json:"key_ops,omitempty"`
+}
+
+// NewPublic 由公钥生成 JWK
+func NewPublic(pub *sm2.PubKey) *JWK {
+	return &JWK{PubKey: pub}
+}
+
+// NewPrivate 由私钥生成 JWK，同时包含对应的公钥
+func NewPrivate(sk *sm2.PrivKey) *JWK {
+	return &JWK{PubKey: sk.GenPubKey(), PrivKey: sk}
+}
+
+// IsPrivate 是否包含私钥
+func (k *JWK) IsPrivate() bool {
+	return k.PrivKey != nil
+}
+
+// Public 返回只包含公钥的副本
+func (k *JWK) Public() *JWK {
+	pub := *k
+	pub.PrivKey = nil
+	return &pub
+}
+
+// MarshalJSON 实现 json.Marshaler
+func (k *JWK) MarshalJSON() ([]byte, error) {
+	x, y, err := encodePublic(k.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	raw := rawJWK{
+		Kty:    KeyType,
+		Crv:    CurveName,
+		X:      x,
+		Y:      y,
+		Kid:    k.KeyID,
+		Use:    k.Use,
+		Alg:    k.Algorithm,
+		KeyOps: k.KeyOps,
+	}
+	if k.PrivKey != nil {
+		if !isRecommended(&k.PrivKey.Curve) {
+			return nil, ErrUnsupportedCurve
+		}
+		if k.PrivKey.Validate() != nil {
+			return nil, ErrInvalidKey
+		}
+		if !k.PrivKey.GenPubKey().Equal(k.PubKey) {
+			return nil, ErrKeyMismatch
+		}
+		raw.D = base64.RawURLEncoding.EncodeToString(k.PrivKey.ToBytes())
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，kty 或 crv 不是 SM2 时返回
+// ErrUnsupportedKey
+func (k *JWK) UnmarshalJSON(data []byte) error {
+	var raw rawJWK
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Kty != KeyType || raw.Crv != CurveName {
+		return ErrUnsupportedKey
+	}
+	x, err := decodeMember(raw.X)
+	if err != nil {
+		return err
+	}
+	y, err := decodeMember(raw.Y)
+	if err != nil {
+		return err
+	}
+	pub, err := sm2.ParsePubKey(append(append([]byte{sm2.UnCompressed}, x...), y...))
+	if err != nil {
+		return ErrInvalidKey
+	}
+
+	var sk *sm2.PrivKey
+	if raw.D != "" {
+		d, err := decodeMember(raw.D)
+		if err != nil {
+			return err
+		}
+		sk = &sm2.PrivKey{D: new(big.Int).SetBytes(d), Curve: sm2.GetSm2P256()}
+		if sk.Validate() != nil {
+			return ErrInvalidKey
+		}
+		if !sk.GenPubKey().Equal(pub) {
+			return ErrKeyMismatch
+		}
+	}
+
+	*k = JWK{
+		PubKey:    pub,
+		PrivKey:   sk,
+		KeyID:     raw.Kid,
+		Use:       raw.Use,
+		Algorithm: raw.Alg,
+		KeyOps:    raw.KeyOps,
+	}
+	return nil
+}
+
+// Parse 解析一个 JWK
+func Parse(data []byte) (*JWK, error) {
+	k := new(JWK)
+	if err := json.Unmarshal(data, k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// -----------------------------------------------------------------------------
+// RFC 7638 JWK Thumbprint
+//
+// 对只含必需成员、按字典序排列、没有空白的 JSON
+//
+//	{"crv":"SM2","kty":"EC","x":"...","y":"..."}
+//
+// 计算杂凑值，私钥和公钥的指纹相同
+// -----------------------------------------------------------------------------
+
+// Thumbprint 以 hashFunc（如 sm3.New 或 sha256.New）计算指纹
+func (k *JWK) Thumbprint(hashFunc func() hash.Hash) ([]byte, error) {
+	x, y, err := encodePublic(k.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(`{"crv":"` + CurveName + `","kty":"` + KeyType + `","x":"`)
+	buf.WriteString(x)
+	buf.WriteString(`","y":"`)
+	buf.WriteString(y)
+	buf.WriteString(`"}`)
+	h := hashFunc()
+	h.Write(buf.Bytes())
+	return h.Sum(nil), nil
+}
+
+// ThumbprintString 返回 base64url 编码的指纹，常用作 kid
+func (k *JWK) ThumbprintString(hashFunc func() hash.Hash) (string, error) {
+	sum, err := k.Thumbprint(hashFunc)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(sum), nil
+}
+
+// -----------------------------------------------------------------------------
+// 编码
+// -----------------------------------------------------------------------------
+
+func isRecommended(c *sm2.Curve) bool {
+	recommended := sm2.GetSm2P256()
+	return c.Equal(&recommended)
+}
+
+// encodePublic 返回 base64url 编码的 x 和 y
+func encodePublic(pub *sm2.PubKey) (x, y string, err error) {
+	if pub == nil || pub.X == nil || pub.Y == nil {
+		return "", "", ErrInvalidKey
+	}
+	if !isRecommended(&pub.Curve) {
+		return "", "", ErrUnsupportedCurve
+	}
+	b := pub.ToBytes()
+	return base64.RawURLEncoding.EncodeToString(b[:sm2.KeyBytes]),
+		base64.RawURLEncoding.EncodeToString(b[sm2.KeyBytes:]), nil
+}
+
+// decodeMember 解码 base64url 编码、不带填充的 32 字节成员
+func decodeMember(s string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) != sm2.KeyBytes {
+		return nil, ErrInvalidKey
+	}
+	return b, nil
+}
@@ -0,0 +1,162 @@
+package jwk
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/sm2"
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+// cert/testdata/sm2_pkcs8.pem 中由 OpenSSL 生成的密钥
+const (
+	fixtureD = "3407082cbd3f4b06e5b9ac6141449979c52cab005037081573a6dbc2ed33923b"
+	// fixtureJWK 成员由 Python 独立编码
+	fixtureJWK = `{"kty":"EC","crv":"SM2",` +
+		`"x":"ZIWef_wgEMmJbIAQ5T6gG1AYTfDtrnKws731g-SIZXw",` +
+		`"y":"-bgCPZsMehVRR_Ju6g-hfkjd4FTa8SsBDxBP40YLdck",` +
+		`"d":"NAcILL0_SwbluaxhQUSZecUsqwBQNwgVc6bbwu0zkjs"}`
+	// RFC 7638 指纹，由 Python 的 hashlib 独立计算
+	fixtureThumbprintSHA256 = "c5RTW8N8CJ4wVXMx8ei5MzlukjuOlc39KNGCeKknG7g"
+	fixtureThumbprintSM3    = "HaMi_B__XqA4NK4UQIqKH-DpCFwr27jIWyP6GRZphgE"
+)
+
+func fixtureKey(t *testing.T) *sm2.PrivKey {
+	d, err := hex.DecodeString(fixtureD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &sm2.PrivKey{D: new(big.Int).SetBytes(d), Curve: sm2.GetSm2P256()}
+}
+
+func TestMarshalFixture(t *testing.T) {
+	data, err := json.Marshal(NewPrivate(fixtureKey(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != fixtureJWK {
+		t.Errorf("TestMarshalFixture 失败\n期望值=%s\n实际值=%s", fixtureJWK, data)
+	}
+
+	k, err := Parse([]byte(fixtureJWK))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !k.IsPrivate() || k.PrivKey.D.Cmp(fixtureKey(t).D) != 0 {
+		t.Errorf("TestMarshalFixture 解析失败: d=%x", k.PrivKey.D)
+	}
+	pub, err := json.Marshal(k.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(pub), `"d"`) {
+		t.Errorf("TestMarshalFixture 失败: 公钥中包含私钥 %s", pub)
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	k := NewPrivate(fixtureKey(t))
+	tests := []struct {
+		name     string
+		hashFunc func() hash.Hash
+		expected string
+	}{
+		{"SHA-256", sha256.New, fixtureThumbprintSHA256},
+		{"SM3", sm3.New, fixtureThumbprintSM3},
+	}
+	for _, tt := range tests {
+		for _, key := range []*JWK{k, k.Public()} {
+			actual, err := key.ThumbprintString(tt.hashFunc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != tt.expected {
+				t.Errorf("TestThumbprint %s 失败\n期望值=%s\n实际值=%s", tt.name, tt.expected, actual)
+			}
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	sk, _, err := sm2.GenKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := NewPrivate(sk)
+	k.KeyID, k.Use, k.Algorithm, k.KeyOps = "key-1", "sig", "SM2SM3", []string{"sign", "verify"}
+	data, err := json.Marshal(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.PrivKey.D.Cmp(sk.D) != 0 || !parsed.PubKey.Equal(k.PubKey) ||
+		parsed.KeyID != "key-1" || parsed.Use != "sig" || parsed.Algorithm != "SM2SM3" ||
+		len(parsed.KeyOps) != 2 || parsed.KeyOps[1] != "verify" {
+		t.Errorf("TestRoundTrip 失败\n期望值=%s\n实际值=%+v", data, parsed)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	x := `"x":"ZIWef_wgEMmJbIAQ5T6gG1AYTfDtrnKws731g-SIZXw"`
+	y := `"y":"-bgCPZsMehVRR_Ju6g-hfkjd4FTa8SsBDxBP40YLdck"`
+	tests := []struct {
+		name string
+		data string
+		err  error
+	}{
+		{"kty 错误", `{"kty":"RSA","crv":"SM2",` + x + `,` + y + `}`, ErrUnsupportedKey},
+		{"crv 错误", `{"kty":"EC","crv":"P-256",` + x + `,` + y + `}`, ErrUnsupportedKey},
+		{"缺少 y", `{"kty":"EC","crv":"SM2",` + x + `}`, ErrInvalidKey},
+		{"x 带填充", `{"kty":"EC","crv":"SM2","x":"ZIWef_wgEMmJbIAQ5T6gG1AYTfDtrnKws731g-SIZXw=",` + y + `}`, ErrInvalidKey},
+		{"x 长度不足", `{"kty":"EC","crv":"SM2","x":"ZIWef_wgEMmJbIAQ5T6gG1AYTfDtrnKws731g-SI",` + y + `}`, ErrInvalidKey},
+		{"点不在曲线上", `{"kty":"EC","crv":"SM2","x":"ZIWef_wgEMmJbIAQ5T6gG1AYTfDtrnKws731g-SIZXg",` + y + `}`, ErrInvalidKey},
+		{"d 为 0", `{"kty":"EC","crv":"SM2",` + x + `,` + y + `,"d":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"}`, ErrInvalidKey},
+		{"d 与公钥不对应", `{"kty":"EC","crv":"SM2",` + x + `,` + y + `,"d":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE"}`, ErrKeyMismatch},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.data)); err != tt.err {
+			t.Errorf("TestParseInvalid %s 失败\n期望值=%v\n实际值=%v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func TestMarshalInvalid(t *testing.T) {
+	sk := fixtureKey(t)
+	other, _, err := sm2.GenKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := json.Marshal(&JWK{PubKey: other.GenPubKey(), PrivKey: sk}); !isMarshalErr(err, ErrKeyMismatch) {
+		t.Errorf("TestMarshalInvalid 失败\n期望值=%v\n实际值=%v", ErrKeyMismatch, err)
+	}
+
+	// NIST P-256 的参数
+	params := elliptic.P256().Params()
+	curve := *sm2.Init(new(big.Int).Sub(params.P, big.NewInt(3)), nil, params)
+	custom, _, err := sm2.GenKeyWithCurve(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := json.Marshal(NewPrivate(custom)); !isMarshalErr(err, ErrUnsupportedCurve) {
+		t.Errorf("TestMarshalInvalid 失败\n期望值=%v\n实际值=%v", ErrUnsupportedCurve, err)
+	}
+	if _, err := NewPublic(custom.GenPubKey()).Thumbprint(sm3.New); err != ErrUnsupportedCurve {
+		t.Errorf("TestMarshalInvalid 失败\n期望值=%v\n实际值=%v", ErrUnsupportedCurve, err)
+	}
+}
+
+// isMarshalErr json.Marshal 把 MarshalJSON 返回的错误包装为 json.MarshalerError
+func isMarshalErr(err, target error) bool {
+	e, ok := err.(*json.MarshalerError)
+	return ok && e.Err == target
+}
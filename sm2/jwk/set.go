@@ -0,0 +1,87 @@
+package jwk
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// -----------------------------------------------------------------------------
+// RFC 7517 5 JWK Set
+//
+// 按 5 的要求，解析时跳过 kty 或 crv 不支持的成员（例如同一集合中的 RSA 密钥），
+// 但 SM2 成员格式错误时整个集合解析失败
+// -----------------------------------------------------------------------------
+
+var ErrInvalidSet = errors.New("jwk: JWK Set has no keys member")
+
+// Set JWK Set
+type Set struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// MarshalJSON 实现 json.Marshaler，空集合输出 {"keys":[]}
+func (s *Set) MarshalJSON() ([]byte, error) {
+	keys := s.Keys
+	if keys == nil {
+		keys = []*JWK{}
+	}
+	return json.Marshal(struct {
+		Keys []*JWK `json:"keys"`
+	}{keys})
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (s *Set) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Keys == nil {
+		return ErrInvalidSet
+	}
+	keys := make([]*JWK, 0, len(raw.Keys))
+	for _, member := range raw.Keys {
+		k := new(JWK)
+		err := k.UnmarshalJSON(member)
+		if err == ErrUnsupportedKey {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		keys = append(keys, k)
+	}
+	s.Keys = keys
+	return nil
+}
+
+// ParseSet 解析 JWK Set
+func ParseSet(data []byte) (*Set, error) {
+	s := new(Set)
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Lookup 返回 kid 相同的密钥
+func (s *Set) Lookup(kid string) []*JWK {
+	var found []*JWK
+	for _, k := range s.Keys {
+		if k.KeyID == kid {
+			found = append(found, k)
+		}
+	}
+	return found
+}
+
+// Public 返回只包含公钥的集合，用于发布
+func (s *Set) Public() *Set {
+	pub := &Set{Keys: make([]*JWK, len(s.Keys))}
+	for i, k := range s.Keys {
+		pub.Keys[i] = k.Public()
+	}
+	return pub
+}
@@ -0,0 +1,76 @@
+package jwk
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/sm2"
+)
+
+func TestParseSet(t *testing.T) {
+	// RFC 7517 A.1 中的 RSA 公钥，应被跳过
+	rsaKey := `{"kty":"RSA","n":"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw","e":"AQAB","kid":"2011-04-29"}`
+	set, err := ParseSet([]byte(`{"keys":[` + rsaKey + `,` + fixtureJWK + `]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Keys) != 1 || set.Keys[0].PrivKey.D.Cmp(fixtureKey(t).D) != 0 {
+		t.Errorf("TestParseSet 失败\n期望值=1\n实际值=%d", len(set.Keys))
+	}
+
+	// SM2 成员格式错误时整个集合失败
+	if _, err := ParseSet([]byte(`{"keys":[{"kty":"EC","crv":"SM2","x":"AA","y":"AA"}]}`)); err != ErrInvalidKey {
+		t.Errorf("TestParseSet 失败\n期望值=%v\n实际值=%v", ErrInvalidKey, err)
+	}
+	if _, err := ParseSet([]byte(`{}`)); err != ErrInvalidSet {
+		t.Errorf("TestParseSet 失败\n期望值=%v\n实际值=%v", ErrInvalidSet, err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	data, err := json.Marshal(&Set{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"keys":[]}` {
+		t.Errorf("TestSet 失败\n期望值=%s\n实际值=%s", `{"keys":[]}`, data)
+	}
+
+	set := new(Set)
+	for _, kid := range []string{"a", "b", "a"} {
+		sk, _, err := sm2.GenKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k := NewPrivate(sk)
+		k.KeyID = kid
+		set.Keys = append(set.Keys, k)
+	}
+	if n := len(set.Lookup("a")); n != 2 {
+		t.Errorf("TestSet Lookup 失败\n期望值=2\n实际值=%d", n)
+	}
+	if n := len(set.Lookup("c")); n != 0 {
+		t.Errorf("TestSet Lookup 失败\n期望值=0\n实际值=%d", n)
+	}
+
+	data, err = json.Marshal(set.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseSet(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Keys) != 3 {
+		t.Fatalf("TestSet 失败\n期望值=3\n实际值=%d", len(parsed.Keys))
+	}
+	for i, k := range parsed.Keys {
+		if k.IsPrivate() || k.KeyID != set.Keys[i].KeyID || !k.PubKey.Equal(set.Keys[i].PubKey) {
+			t.Errorf("TestSet Public 失败: %s", data)
+		}
+	}
+	if !set.Keys[0].IsPrivate() {
+		t.Errorf("TestSet Public 失败: 修改了原集合")
+	}
+}
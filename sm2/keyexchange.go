@@ -0,0 +1,295 @@
+package sm2
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+// -----------------------------------------------------------------------------
+// GB/T 32918.3-2016 6 密钥交换协议及其流程
+//
+// 协议与传输无关，双方按以下顺序调用，并自行传递各步骤的输出：
+//
+//	A: RA := a.Init(rand)                 --RA-->
+//	B: RB, SB := b.Respond(rand, RA)      <--RB, SB--
+//	A: SA := a.Confirm(RB, SB)            --SA-->
+//	B: b.Finish(SA)
+//
+// 可选的密钥确认由 SB 和 SA 完成，是否确认由双方在构造时各自指定，不由收到
+// 的消息决定。不确认时 Respond 不输出 SB，Confirm 忽略 SB 且不输出 SA，B 不调用
+// Finish
+// -----------------------------------------------------------------------------
+
+var (
+	ErrKeyExchangeState     = errors.New("sm2: key exchange step called out of order")
+	ErrInvalidEphemeralKey  = errors.New("sm2: invalid ephemeral public key")
+	ErrKeyExchangeInfinity  = errors.New("sm2: shared point is at infinity")
+	ErrKeyConfirmation      = errors.New("sm2: key confirmation failed")
+	ErrInvalidKeyLength     = errors.New("sm2: invalid shared key length")
+	ErrInvalidPeerPublicKey = errors.New("sm2: peer public key is not on the same curve")
+)
+
+type keyExchangeState int
+
+const (
+	kxNew keyExchangeState = iota
+	kxInitiated
+	kxResponded
+	kxDone
+	kxFailed // 确认失败或计算出错后不能继续
+)
+
+// KeyExchange 密钥交换一方的状态
+type KeyExchange struct {
+	initiator bool
+	confirm   bool
+	state     keyExchangeState
+	curve     Curve
+
+	sk     *PrivKey
+	pk     *PubKey
+	peer   *PubKey
+	z      []byte // 本方 Z
+	peerZ  []byte // 对方 Z
+	keyLen int
+
+	r      *big.Int // 临时私钥
+	rx, ry *big.Int // 临时公钥
+	key    []byte
+	// inner 为确认值的公共部分 Hash(xU || ZA || ZB || x1 || y1 || x2 || y2)
+	inner []byte
+	vy    []byte
+}
+
+// NewInitiator 生成发起方 A，klen 为协商密钥的字节长度，userID 为空时使用默认值，
+// confirm 为 true 时要求 SB 验证通过
+func NewInitiator(sk *PrivKey, userID []byte, peer *PubKey, peerUserID []byte, klen int, confirm bool) (*KeyExchange, error) {
+	return newKeyExchange(true, confirm, sk, userID, peer, peerUserID, klen)
+}
+
+// NewResponder 生成响应方 B，klen 为协商密钥的字节长度，userID 为空时使用默认值，
+// confirm 为 true 时输出 SB 并要求 SA 验证通过
+func NewResponder(sk *PrivKey, userID []byte, peer *PubKey, peerUserID []byte, klen int, confirm bool) (*KeyExchange, error) {
+	return newKeyExchange(false, confirm, sk, userID, peer, peerUserID, klen)
+}
+
+func newKeyExchange(initiator, confirm bool, sk *PrivKey, userID []byte, peer *PubKey, peerUserID []byte, klen int) (*KeyExchange, error) {
+	if klen <= 0 {
+		return nil, ErrInvalidKeyLength
+	}
+	if err := sk.Validate(); err != nil {
+		return nil, err
+	}
+	if peer == nil || !peer.Curve.Equal(&sk.Curve) {
+		return nil, ErrInvalidPeerPublicKey
+	}
+	if err := peer.Validate(); err != nil {
+		return nil, err
+	}
+	if userID == nil {
+		userID = sm2SignDefaultUserID
+	}
+	if peerUserID == nil {
+		peerUserID = sm2SignDefaultUserID
+	}
+	pk := sk.GenPubKey()
+	return &KeyExchange{
+		initiator: initiator,
+		confirm:   confirm,
+		curve:     sk.Curve,
+		sk:        sk,
+		pk:        pk,
+		peer:      peer,
+		z:         getZ(sm3.New(), &sk.Curve, pk.X, pk.Y, userID),
+		peerZ:     getZ(sm3.New(), &sk.Curve, peer.X, peer.Y, peerUserID),
+		keyLen:    klen,
+	}, nil
+}
+
+// ephemeral A1-A3 / B1-B3 生成临时密钥对
+func (kx *KeyExchange) ephemeral(random io.Reader) error {
+	r, err := pickK(random, kx.curve.N)
+	if err != nil {
+		return err
+	}
+	kx.r = r
+	kx.rx, kx.ry = kx.curve.ScalarBaseMult(r.Bytes())
+	return nil
+}
+
+// truncate 6.1 x̄ = 2^w + (x & (2^w - 1))，w = ⌈⌈log2(n)⌉/2⌉ - 1
+func (kx *KeyExchange) truncate(x *big.Int) *big.Int {
+	w := uint((kx.curve.N.BitLen()+1)/2 - 1)
+	mask := new(big.Int).Lsh(big.NewInt(1), w)
+	out := new(big.Int).Sub(mask, big.NewInt(1))
+	out.And(out, x)
+	return out.Add(out, mask)
+}
+
+func (kx *KeyExchange) encodePoint(x, y *big.Int) []byte {
+	out := []byte{UnCompressed}
+	out = append(out, kx.curve.fieldBytes(x)...)
+	return append(out, kx.curve.fieldBytes(y)...)
+}
+
+// decodePoint A6 / B4 解析对方的临时公钥并按 GB/T 32918.1-2016 6.2.1 验证
+func (kx *KeyExchange) decodePoint(data []byte) (*big.Int, *big.Int, error) {
+	size := (kx.curve.BitSize + 7) / 8
+	if len(data) != 1+2*size || data[0] != UnCompressed {
+		return nil, nil, ErrInvalidEphemeralKey
+	}
+	x := new(big.Int).SetBytes(data[1 : 1+size])
+	y := new(big.Int).SetBytes(data[1+size:])
+	if kx.curve.validatePoint(x, y) != nil {
+		return nil, nil, ErrInvalidEphemeralKey
+	}
+	return x, y, nil
+}
+
+// shared A4-A8 / B5-B8 计算共享点 U（或 V）并派生密钥
+//
+// t = (d + x̄ · r) mod n，U = [h · t](P + [x̄'] R')，K = KDF(xU || yU || ZA || ZB, klen)
+func (kx *KeyExchange) shared(peerRx, peerRy *big.Int) error {
+	c := kx.curve
+	t := kx.truncate(kx.rx)
+	t.Mul(t, kx.r)
+	t.Add(t, kx.sk.D)
+	t.Mod(t, c.N)
+
+	px, py := c.ScalarMult(peerRx, peerRy, kx.truncate(peerRx).Bytes())
+	px, py = c.Add(kx.peer.X, kx.peer.Y, px, py)
+	t.Mul(t, sm2H)
+	ux, uy := c.ScalarMult(px, py, t.Bytes())
+	if IsPointInfinity(ux, uy) {
+		return ErrKeyExchangeInfinity
+	}
+
+	za, zb := kx.z, kx.peerZ
+	x1, y1, x2, y2 := kx.rx, kx.ry, peerRx, peerRy
+	if !kx.initiator {
+		za, zb = zb, za
+		x1, y1, x2, y2 = x2, y2, x1, y1
+	}
+	xu, yu := c.fieldBytes(ux), c.fieldBytes(uy)
+
+	z := make([]byte, 0, 2*len(xu)+len(za)+len(zb))
+	z = append(z, xu...)
+	z = append(z, yu...)
+	z = append(z, za...)
+	z = append(z, zb...)
+	kx.key = kdfKey(z, kx.keyLen)
+
+	digest := sm3.New()
+	digest.Write(xu)
+	digest.Write(za)
+	digest.Write(zb)
+	for _, v := range []*big.Int{x1, y1, x2, y2} {
+		digest.Write(c.fieldBytes(v))
+	}
+	kx.inner = digest.Sum(nil)
+	kx.vy = yu
+	return nil
+}
+
+// confirmation 6.2 A9 / B9 / A10 / B10 确认杂凑值 Hash(prefix || yU || inner)
+func (kx *KeyExchange) confirmation(prefix byte) []byte {
+	digest := sm3.New()
+	digest.Write([]byte{prefix})
+	digest.Write(kx.vy)
+	digest.Write(kx.inner)
+	return digest.Sum(nil)
+}
+
+// fail 进入失败状态并清除密钥
+func (kx *KeyExchange) fail(err error) error {
+	kx.state = kxFailed
+	kx.key = nil
+	return err
+}
+
+// Init A1-A3 发起方生成临时公钥 RA，输出未压缩编码
+func (kx *KeyExchange) Init(random io.Reader) ([]byte, error) {
+	if !kx.initiator || kx.state != kxNew {
+		return nil, ErrKeyExchangeState
+	}
+	if err := kx.ephemeral(random); err != nil {
+		return nil, err
+	}
+	kx.state = kxInitiated
+	return kx.encodePoint(kx.rx, kx.ry), nil
+}
+
+// Respond B1-B9 响应方收到 RA 后生成临时公钥 RB，计算共享密钥，需要确认时
+// 同时输出确认值 SB
+func (kx *KeyExchange) Respond(random io.Reader, ra []byte) (rb, sb []byte, err error) {
+	if kx.initiator || kx.state != kxNew {
+		return nil, nil, ErrKeyExchangeState
+	}
+	rax, ray, err := kx.decodePoint(ra)
+	if err != nil {
+		return nil, nil, kx.fail(err)
+	}
+	if err := kx.ephemeral(random); err != nil {
+		return nil, nil, kx.fail(err)
+	}
+	if err := kx.shared(rax, ray); err != nil {
+		return nil, nil, kx.fail(err)
+	}
+	rb = kx.encodePoint(kx.rx, kx.ry)
+	if !kx.confirm {
+		kx.state = kxDone
+		return rb, nil, nil
+	}
+	kx.state = kxResponded
+	return rb, kx.confirmation(0x02), nil
+}
+
+// Confirm A4-A10 发起方收到 RB 后计算共享密钥，需要确认时验证 S1 = SB 并返回
+// 发给响应方的确认值 SA，不需要确认时忽略 sb，sa 为 nil
+func (kx *KeyExchange) Confirm(rb, sb []byte) (sa []byte, err error) {
+	if !kx.initiator || kx.state != kxInitiated {
+		return nil, ErrKeyExchangeState
+	}
+	rbx, rby, err := kx.decodePoint(rb)
+	if err != nil {
+		return nil, kx.fail(err)
+	}
+	if err := kx.shared(rbx, rby); err != nil {
+		return nil, kx.fail(err)
+	}
+	if !kx.confirm {
+		kx.state = kxDone
+		return nil, nil
+	}
+	if subtle.ConstantTimeCompare(kx.confirmation(0x02), sb) != 1 {
+		return nil, kx.fail(ErrKeyConfirmation)
+	}
+	kx.state = kxDone
+	return kx.confirmation(0x03), nil
+}
+
+// Finish B10 响应方验证 S2 = SA，只在需要确认时调用
+func (kx *KeyExchange) Finish(sa []byte) error {
+	if kx.initiator || !kx.confirm || kx.state != kxResponded {
+		return ErrKeyExchangeState
+	}
+	if subtle.ConstantTimeCompare(kx.confirmation(0x03), sa) != 1 {
+		return kx.fail(ErrKeyConfirmation)
+	}
+	kx.state = kxDone
+	return nil
+}
+
+// Key 返回协商得到的共享密钥，协商完成前返回 nil：不需要确认时发起方在 Confirm、
+// 响应方在 Respond 之后可用，需要确认时发起方在 Confirm、响应方在 Finish 验证
+// 通过之后可用。确认失败后始终返回 nil
+func (kx *KeyExchange) Key() []byte {
+	if kx.state != kxDone {
+		return nil
+	}
+	return kx.key
+}
@@ -0,0 +1,220 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/utils"
+)
+
+// exampleCurve GB/T 32918.3-2016 附录 A 使用的素域 256 位示例曲线
+func exampleCurve() Curve {
+	return *Init(
+		utils.NewBigIntFromHexString("787968B4FA32C3FD2417842E73BBFEFF2F3C848B6831D7E0EC65228B3937E498"),
+		nil,
+		&elliptic.CurveParams{
+			Name:    "GB/T 32918 Fp-256 example",
+			P:       utils.NewBigIntFromHexString("8542D69E4C044F18E8B92435BF6FF7DE457283915C45517D722EDB8B08F1DFC3"),
+			N:       utils.NewBigIntFromHexString("8542D69E4C044F18E8B92435BF6FF7DD297720630485628D5AE74EE7C32E79B7"),
+			B:       utils.NewBigIntFromHexString("63E4C6D3B23B0C849CF84241484BFE48F61D59A5B16BA06E6E12D1DA27C5249A"),
+			Gx:      utils.NewBigIntFromHexString("421DEBD61B62EAB6746434EBC3CC315E32220B3BADD50BDC4C4E6C147FEDD43D"),
+			Gy:      utils.NewBigIntFromHexString("0680512BCBB42C07D47349D2153B70C4E5D7FDFCBFA36EA1A85841B9E46E09A2"),
+			BitSize: 256})
+}
+
+// TestKeyExchangeExample GB/T 32918.3-2016 附录 A 密钥交换示例
+func TestKeyExchangeExample(t *testing.T) {
+	curve := exampleCurve()
+	skA := &PrivKey{D: utils.NewBigIntFromHexString("6FCBA2EF9AE0AB902BC3BDE3FF915D44BA4CC78F88E2F8E7F8996D3B8CCEEDEE"), Curve: curve}
+	skB := &PrivKey{D: utils.NewBigIntFromHexString("5E35D7D3F3C54DBAC72E61819E730B019A84208CA3A35E4C2E353DFCCB2A3B53"), Curve: curve}
+	pkA, pkB := skA.GenPubKey(), skB.GenPubKey()
+	if pkA.X.Cmp(utils.NewBigIntFromHexString("3099093BF3C137D8FCBBCDF4A2AE50F3B0F216C3122D79425FE03A45DBFE1655")) != 0 {
+		t.Fatalf("TestKeyExchangeExample 失败: xA=%x", pkA.X)
+	}
+	idA := []byte("ALICE123@YAHOO.COM")
+	idB := []byte("BILL456@YAHOO.COM")
+
+	a, err := NewInitiator(skA, idA, pkB, idB, 16, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewResponder(skB, idB, pkA, idA, 16, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedZA := utils.HexStringToBytes("E4D1D0C3CA4C7F11BC8FF8CB3F4C02A78F108FA098E51A668487240F75E20F31")
+	expectedZB := utils.HexStringToBytes("6B4B6D0E276691BD4A11BF72F4FB501AE309FDACB72FA6CC336E6656119ABD67")
+	if !bytes.Equal(a.z, expectedZA) || !bytes.Equal(b.z, expectedZB) {
+		t.Errorf(`TestKeyExchangeExample 失败
+期望值=%x %x
+实际值=%x %x`, expectedZA, expectedZB, a.z, b.z)
+	}
+
+	rA := utils.HexStringToBytes("83A2C9C8B96E5AF70BD480B472409A9A327257F1EBB73F5B073354B248668563")
+	ra, err := a.Init(bytes.NewReader(rA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRA := utils.HexStringToBytes("04" +
+		"6CB5633816F4DD560B1DEC458310CBCC6856C09505324A6D23150C408F162BF0" +
+		"0D6FCF62F1036C0A1B6DACCF57399223A65F7D7BF2D9637E5BBBEB857961BF1A")
+	if !bytes.Equal(ra, expectedRA) {
+		t.Errorf(`TestKeyExchangeExample 失败
+期望值=%x
+实际值=%x`, expectedRA, ra)
+	}
+
+	rB := utils.HexStringToBytes("33FE21940342161C55619C4A0C060293D543C80AF19748CE176D83477DE71C80")
+	rb, sb, err := b.Respond(bytes.NewReader(rB), ra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRB := utils.HexStringToBytes("04" +
+		"1799B2A2C778295300D9A2325C686129B8F2B5337B3DCF4514E8BBC19D900EE5" +
+		"54C9288C82733EFDF7808AE7F27D0E732F7C73A7D9AC98B7D8740A91D0DB3CF4")
+	expectedSB := utils.HexStringToBytes("284C8F198F141B502E81250F1581C7E9EEB4CA6990F9E02DF388B45471F5BC5C")
+	if !bytes.Equal(rb, expectedRB) || !bytes.Equal(sb, expectedSB) {
+		t.Errorf(`TestKeyExchangeExample 失败
+期望值=%x %x
+实际值=%x %x`, expectedRB, expectedSB, rb, sb)
+	}
+
+	if b.Key() != nil {
+		t.Error("TestKeyExchangeExample 失败: 响应方在 Finish 之前返回密钥")
+	}
+	sa, err := a.Confirm(rb, sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedSA := utils.HexStringToBytes("23444DAF8ED7534366CB901C84B3BDBB63504F4065C1116C91A4C00697E6CF7A")
+	if !bytes.Equal(sa, expectedSA) {
+		t.Errorf(`TestKeyExchangeExample 失败
+期望值=%x
+实际值=%x`, expectedSA, sa)
+	}
+	if err := b.Finish(sa); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedK := utils.HexStringToBytes("55B0AC62A6B927BA23703832C853DED4")
+	if !bytes.Equal(a.Key(), expectedK) || !bytes.Equal(b.Key(), expectedK) {
+		t.Errorf(`TestKeyExchangeExample 失败
+期望值=%x
+实际值=%x %x`, expectedK, a.Key(), b.Key())
+	}
+}
+
+func TestKeyExchange(t *testing.T) {
+	skA, pkA, _ := GenKey(rand.Reader)
+	skB, pkB, _ := GenKey(rand.Reader)
+
+	a, err := NewInitiator(skA, nil, pkB, nil, 48, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewResponder(skB, nil, pkA, nil, 48, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ra, err := a.Init(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rb, sb, err := b.Respond(rand.Reader, ra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 不使用密钥确认
+	if sb != nil || b.Finish(nil) != ErrKeyExchangeState {
+		t.Error("TestKeyExchange 失败: 不需要确认时输出 SB 或接受 Finish")
+	}
+	sa, err := a.Confirm(rb, []byte("ignored"))
+	if err != nil || sa != nil {
+		t.Fatalf("TestKeyExchange 失败: sa=%x err=%v", sa, err)
+	}
+	if len(a.Key()) != 48 || !bytes.Equal(a.Key(), b.Key()) {
+		t.Errorf(`TestKeyExchange 失败
+期望值=%x
+实际值=%x`, b.Key(), a.Key())
+	}
+}
+
+func TestKeyExchangeConfirmationFailure(t *testing.T) {
+	skA, _, _ := GenKey(rand.Reader)
+	skB, pkB, _ := GenKey(rand.Reader)
+	_, pkC, _ := GenKey(rand.Reader)
+
+	// B 以为对方是 C
+	a, _ := NewInitiator(skA, nil, pkB, nil, 16, true)
+	b, _ := NewResponder(skB, nil, pkC, nil, 16, true)
+	ra, _ := a.Init(rand.Reader)
+	rb, sb, err := b.Respond(rand.Reader, ra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Confirm(rb, sb); err != ErrKeyConfirmation {
+		t.Errorf("TestKeyExchangeConfirmationFailure 失败: %v", err)
+	}
+	if a.Key() != nil {
+		t.Error("TestKeyExchangeConfirmationFailure 失败: 确认失败后仍返回密钥")
+	}
+	// 确认失败后进入失败状态，不能重试
+	if _, err := a.Confirm(rb, sb); err != ErrKeyExchangeState {
+		t.Errorf("TestKeyExchangeConfirmationFailure 失败\n期望值=%v\n实际值=%v", ErrKeyExchangeState, err)
+	}
+	if err := b.Finish(make([]byte, 32)); err != ErrKeyConfirmation {
+		t.Errorf("TestKeyExchangeConfirmationFailure 失败: %v", err)
+	}
+	if err := b.Finish(make([]byte, 32)); err != ErrKeyExchangeState || b.Key() != nil {
+		t.Errorf("TestKeyExchangeConfirmationFailure 失败\n期望值=%v\n实际值=%v", ErrKeyExchangeState, err)
+	}
+}
+
+// TestKeyExchangeConfirmationRequired 要求确认的发起方不接受缺少 SB 的响应
+func TestKeyExchangeConfirmationRequired(t *testing.T) {
+	skA, pkA, _ := GenKey(rand.Reader)
+	skB, pkB, _ := GenKey(rand.Reader)
+	a, _ := NewInitiator(skA, nil, pkB, nil, 16, true)
+	b, _ := NewResponder(skB, nil, pkA, nil, 16, false)
+	ra, _ := a.Init(rand.Reader)
+	rb, sb, err := b.Respond(rand.Reader, ra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Confirm(rb, sb); err != ErrKeyConfirmation {
+		t.Errorf("TestKeyExchangeConfirmationRequired 失败\n期望值=%v\n实际值=%v", ErrKeyConfirmation, err)
+	}
+	if a.Key() != nil {
+		t.Error("TestKeyExchangeConfirmationRequired 失败: 确认失败后仍返回密钥")
+	}
+}
+
+func TestKeyExchangeInvalidInput(t *testing.T) {
+	skA, pkA, _ := GenKey(rand.Reader)
+	skB, pkB, _ := GenKey(rand.Reader)
+	a, _ := NewInitiator(skA, nil, pkB, nil, 16, true)
+	b, _ := NewResponder(skB, nil, pkA, nil, 16, true)
+
+	if _, err := a.Confirm(nil, nil); err != ErrKeyExchangeState {
+		t.Errorf("TestKeyExchangeInvalidInput 失败: %v", err)
+	}
+	ra, _ := a.Init(rand.Reader)
+	ra[len(ra)-1] ^= 1
+	if _, _, err := b.Respond(rand.Reader, ra); err != ErrInvalidEphemeralKey {
+		t.Errorf("TestKeyExchangeInvalidInput 失败: %v", err)
+	}
+	if _, err := NewInitiator(skA, nil, pkB, nil, 0, true); err != ErrInvalidKeyLength {
+		t.Errorf("TestKeyExchangeInvalidInput 失败: %v", err)
+	}
+	offCurve := &PubKey{X: pkB.X, Y: new(big.Int).Add(pkB.Y, big.NewInt(1)), Curve: pkB.Curve}
+	if _, err := NewInitiator(skA, nil, offCurve, nil, 16, true); err != ErrPointNotOnCurve {
+		t.Errorf("TestKeyExchangeInvalidInput 失败: %v", err)
+	}
+	if _, err := NewResponder(skB, nil, &PubKey{X: pkA.X, Y: pkA.Y, Curve: exampleCurve()}, nil, 16, true); err != ErrInvalidPeerPublicKey {
+		t.Errorf("TestKeyExchangeInvalidInput 失败: %v", err)
+	}
+}
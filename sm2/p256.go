@@ -0,0 +1,249 @@
+package sm2
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// -----------------------------------------------------------------------------
+// 推荐曲线 256 位模运算
+//
+// 域元素和标量都表示为 4 个 64 位字（低位在前）的 Montgomery 形式 aR mod m，
+// R = 2^256。乘法使用 CIOS 算法，加、减和约减都用掩码选择代替分支，运算时间与
+// 数值无关。求逆使用费马小定理 a^(m-2)，指数是公开的模数，同样不依赖秘密数据
+// -----------------------------------------------------------------------------
+
+type p256Element [4]uint64
+
+// montModulus 256 位 Montgomery 模运算的参数
+type montModulus struct {
+	m    p256Element // 模数
+	m0   uint64      // -m^-1 mod 2^64
+	rr   p256Element // R^2 mod m
+	one  p256Element // R mod m，即 Montgomery 形式的 1
+	inv  p256Element // m - 2，求逆时的指数
+	bigM *big.Int
+}
+
+var (
+	// p256P 推荐曲线的素数 p，p ≡ -1 (mod 2^64)，所以 m0 = 1
+	p256P = newMontModulus("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF00000000FFFFFFFFFFFFFFFF")
+	// p256N 推荐曲线的阶 n
+	p256N = newMontModulus("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFF7203DF6B21C6052B53BBF40939D54123")
+)
+
+func newMontModulus(hex string) *montModulus {
+	m, _ := new(big.Int).SetString(hex, 16)
+	md := &montModulus{bigM: m}
+	md.m = limbsFromBig(m)
+
+	// -m^-1 mod 2^64
+	word := new(big.Int).Lsh(big.NewInt(1), 64)
+	m0 := new(big.Int).ModInverse(new(big.Int).Mod(m, word), word)
+	m0.Sub(word, m0)
+	md.m0 = m0.Uint64()
+
+	r := new(big.Int).Lsh(big.NewInt(1), 256)
+	md.one = limbsFromBig(new(big.Int).Mod(r, m))
+	md.rr = limbsFromBig(new(big.Int).Mod(new(big.Int).Mul(r, r), m))
+	md.inv = limbsFromBig(new(big.Int).Sub(m, big.NewInt(2)))
+	return md
+}
+
+// limbsFromBig 要求 0 <= x < 2^256
+func limbsFromBig(x *big.Int) p256Element {
+	var buf [32]byte
+	b := x.Bytes()
+	copy(buf[32-len(b):], b)
+	return limbsFromBytes(buf[:])
+}
+
+// limbsFromBytes 32 字节大端字节串
+func limbsFromBytes(b []byte) p256Element {
+	var z p256Element
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			z[3-i] = z[3-i]<<8 | uint64(b[8*i+j])
+		}
+	}
+	return z
+}
+
+func (x *p256Element) bytes() []byte {
+	out := make([]byte, 32)
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			out[8*i+j] = byte(x[3-i] >> uint(56-8*j))
+		}
+	}
+	return out
+}
+
+func (x *p256Element) toBig() *big.Int {
+	return new(big.Int).SetBytes(x.bytes())
+}
+
+// isZero 在 x 为 0 时返回 1，否则返回 0
+func (x *p256Element) isZero() uint64 {
+	v := x[0] | x[1] | x[2] | x[3]
+	return 1 ^ ((v | -v) >> 63)
+}
+
+// p256Select cond 为 1 时 z = x，为 0 时 z 不变
+func p256Select(z, x *p256Element, cond uint64) {
+	mask := -cond
+	z[0] ^= (z[0] ^ x[0]) & mask
+	z[1] ^= (z[1] ^ x[1]) & mask
+	z[2] ^= (z[2] ^ x[2]) & mask
+	z[3] ^= (z[3] ^ x[3]) & mask
+}
+
+// reduce 把 (carry, t) < 2m 约减到 [0, m)
+func (md *montModulus) reduce(z *p256Element, t *p256Element, carry uint64) {
+	var r p256Element
+	var b uint64
+	r[0], b = bits.Sub64(t[0], md.m[0], 0)
+	r[1], b = bits.Sub64(t[1], md.m[1], b)
+	r[2], b = bits.Sub64(t[2], md.m[2], b)
+	r[3], b = bits.Sub64(t[3], md.m[3], b)
+	_, b = bits.Sub64(carry, 0, b)
+	// b = 1 说明 t < m，保留 t
+	*z = r
+	p256Select(z, t, b)
+}
+
+func (md *montModulus) add(z, x, y *p256Element) {
+	var t p256Element
+	var c uint64
+	t[0], c = bits.Add64(x[0], y[0], 0)
+	t[1], c = bits.Add64(x[1], y[1], c)
+	t[2], c = bits.Add64(x[2], y[2], c)
+	t[3], c = bits.Add64(x[3], y[3], c)
+	md.reduce(z, &t, c)
+}
+
+func (md *montModulus) sub(z, x, y *p256Element) {
+	var t, u p256Element
+	var b, c uint64
+	t[0], b = bits.Sub64(x[0], y[0], 0)
+	t[1], b = bits.Sub64(x[1], y[1], b)
+	t[2], b = bits.Sub64(x[2], y[2], b)
+	t[3], b = bits.Sub64(x[3], y[3], b)
+	// 有借位时加回 m
+	mask := -b
+	u[0], c = bits.Add64(t[0], md.m[0]&mask, 0)
+	u[1], c = bits.Add64(t[1], md.m[1]&mask, c)
+	u[2], c = bits.Add64(t[2], md.m[2]&mask, c)
+	u[3], _ = bits.Add64(t[3], md.m[3]&mask, c)
+	*z = u
+}
+
+// madd 返回 a · b + c + d 的高低 64 位，结果不会溢出 128 位
+func madd(a, b, c, d uint64) (hi, lo uint64) {
+	hi, lo = bits.Mul64(a, b)
+	var cc uint64
+	lo, cc = bits.Add64(lo, c, 0)
+	hi += cc
+	lo, cc = bits.Add64(lo, d, 0)
+	hi += cc
+	return
+}
+
+// mul Montgomery 乘法 z = x · y · R^-1 mod m，按字展开的 CIOS
+func (md *montModulus) mul(z, x, y *p256Element) {
+	m0, m1, m2, m3 := md.m[0], md.m[1], md.m[2], md.m[3]
+	x0, x1, x2, x3 := x[0], x[1], x[2], x[3]
+	var t0, t1, t2, t3, t4, c, u, cc uint64
+
+	for _, yi := range y {
+		// t += x · y[i]
+		c, t0 = madd(x0, yi, t0, 0)
+		c, t1 = madd(x1, yi, t1, c)
+		c, t2 = madd(x2, yi, t2, c)
+		c, t3 = madd(x3, yi, t3, c)
+		t4, cc = bits.Add64(t4, c, 0)
+
+		// t = (t + u · m) / 2^64
+		u = t0 * md.m0
+		c, _ = madd(u, m0, t0, 0)
+		c, t0 = madd(u, m1, t1, c)
+		c, t1 = madd(u, m2, t2, c)
+		c, t2 = madd(u, m3, t3, c)
+		t3, c = bits.Add64(t4, c, 0)
+		t4 = cc + c
+	}
+	r := p256Element{t0, t1, t2, t3}
+	md.reduce(z, &r, t4)
+}
+
+func (md *montModulus) square(z, x *p256Element) {
+	md.mul(z, x, x)
+}
+
+// exp z = x^e，e 为公开的指数，使用 4 比特固定窗口
+func (md *montModulus) exp(z, x *p256Element, e *p256Element) {
+	var table [16]p256Element
+	table[0] = md.one
+	table[1] = *x
+	for i := 2; i < 16; i++ {
+		md.mul(&table[i], &table[i-1], x)
+	}
+	r := md.one
+	for i := 3; i >= 0; i-- {
+		for j := 60; j >= 0; j -= 4 {
+			md.square(&r, &r)
+			md.square(&r, &r)
+			md.square(&r, &r)
+			md.square(&r, &r)
+			if w := (e[i] >> uint(j)) & 0x0f; w != 0 {
+				md.mul(&r, &r, &table[w])
+			}
+		}
+	}
+	*z = r
+}
+
+// invert z = x^-1，x = 0 时 z = 0
+func (md *montModulus) invert(z, x *p256Element) {
+	md.exp(z, x, &md.inv)
+}
+
+// toMont 把 0 <= x < 2^256 转为 Montgomery 形式，并约减到 [0, m)
+func (md *montModulus) toMont(z *p256Element, x *p256Element) {
+	md.mul(z, x, &md.rr)
+}
+
+// fromMont 由 Montgomery 形式还原
+func (md *montModulus) fromMont(z *p256Element, x *p256Element) {
+	md.mul(z, x, &p256Element{1})
+}
+
+// fromBig 大数转为 Montgomery 形式，x 可以不小于 m
+func (md *montModulus) fromBig(x *big.Int) p256Element {
+	if x.Sign() < 0 || x.BitLen() > 256 {
+		x = new(big.Int).Mod(x, md.bigM)
+	}
+	z := limbsFromBig(x)
+	md.toMont(&z, &z)
+	return z
+}
+
+// toBig 由 Montgomery 形式还原为大数
+func (md *montModulus) toBig(x *p256Element) *big.Int {
+	var z p256Element
+	md.fromMont(&z, x)
+	return z.toBig()
+}
+
+// p256SignS GB/T 32918.2-2016 6.1 A6 s = ((1 + d)^-1 · (k - r · d)) mod n
+func p256SignS(d, k, r *big.Int) *big.Int {
+	n := p256N
+	md, mk, mr := n.fromBig(d), n.fromBig(k), n.fromBig(r)
+	var inv, t p256Element
+	n.add(&inv, &md, &n.one)
+	n.invert(&inv, &inv)
+	n.mul(&t, &mr, &md)
+	n.sub(&t, &mk, &t)
+	n.mul(&t, &t, &inv)
+	return n.toBig(&t)
+}
@@ -0,0 +1,273 @@
+package sm2
+
+import (
+	"math/big"
+	"sync"
+)
+
+// -----------------------------------------------------------------------------
+// 推荐曲线上的点运算
+//
+// 点使用射影坐标 (X : Y : Z)，对应仿射坐标 (X/Z, Y/Z)，无穷远点为 (0 : 1 : 0)。
+// 点加和倍点使用 Renes, Costello, Batina 的完备公式（a = -3），对无穷远点、相同
+// 点和互逆点都不需要分支。多倍点使用 4 比特固定窗口，查表时遍历整张表按掩码
+// 选择，运算序列与标量无关
+// -----------------------------------------------------------------------------
+
+type p256Point struct {
+	x, y, z p256Element
+}
+
+var (
+	p256B    p256Element
+	p256Base p256Point
+)
+
+func init() {
+	params := InitWithRecommendedParams()
+	p256B = p256P.fromBig(params.B)
+	p256Base = p256Point{x: p256P.fromBig(params.Gx), y: p256P.fromBig(params.Gy), z: p256P.one}
+}
+
+func (p *p256Point) setInfinity() *p256Point {
+	p.x = p256Element{}
+	p.y = p256P.one
+	p.z = p256Element{}
+	return p
+}
+
+// p256PointFromAffine 仿射坐标转为射影坐标，(0, 0) 为无穷远点
+func p256PointFromAffine(x, y *big.Int) *p256Point {
+	p := new(p256Point)
+	if IsPointInfinity(x, y) {
+		return p.setInfinity()
+	}
+	p.x = p256P.fromBig(x)
+	p.y = p256P.fromBig(y)
+	p.z = p256P.one
+	return p
+}
+
+// affine 射影坐标转为仿射坐标，无穷远点为 (0, 0)
+func (p *p256Point) affine() (*big.Int, *big.Int) {
+	var zinv, x, y p256Element
+	p256P.invert(&zinv, &p.z)
+	p256P.mul(&x, &p.x, &zinv)
+	p256P.mul(&y, &p.y, &zinv)
+	return p256P.toBig(&x), p256P.toBig(&y)
+}
+
+// add 完备点加 q = p1 + p2，Renes-Costello-Batina 算法 4
+func (q *p256Point) add(p1, p2 *p256Point) *p256Point {
+	f := p256P
+	var t0, t1, t2, t3, t4, x3, y3, z3 p256Element
+	f.mul(&t0, &p1.x, &p2.x) // t0 := X1 * X2
+	f.mul(&t1, &p1.y, &p2.y) // t1 := Y1 * Y2
+	f.mul(&t2, &p1.z, &p2.z) // t2 := Z1 * Z2
+	f.add(&t3, &p1.x, &p1.y) // t3 := X1 + Y1
+	f.add(&t4, &p2.x, &p2.y) // t4 := X2 + Y2
+	f.mul(&t3, &t3, &t4)     // t3 := t3 * t4
+	f.add(&t4, &t0, &t1)     // t4 := t0 + t1
+	f.sub(&t3, &t3, &t4)     // t3 := t3 - t4
+	f.add(&t4, &p1.y, &p1.z) // t4 := Y1 + Z1
+	f.add(&x3, &p2.y, &p2.z) // X3 := Y2 + Z2
+	f.mul(&t4, &t4, &x3)     // t4 := t4 * X3
+	f.add(&x3, &t1, &t2)     // X3 := t1 + t2
+	f.sub(&t4, &t4, &x3)     // t4 := t4 - X3
+	f.add(&x3, &p1.x, &p1.z) // X3 := X1 + Z1
+	f.add(&y3, &p2.x, &p2.z) // Y3 := X2 + Z2
+	f.mul(&x3, &x3, &y3)     // X3 := X3 * Y3
+	f.add(&y3, &t0, &t2)     // Y3 := t0 + t2
+	f.sub(&y3, &x3, &y3)     // Y3 := X3 - Y3
+	f.mul(&z3, &p256B, &t2)  // Z3 := b * t2
+	f.sub(&x3, &y3, &z3)     // X3 := Y3 - Z3
+	f.add(&z3, &x3, &x3)     // Z3 := X3 + X3
+	f.add(&x3, &x3, &z3)     // X3 := X3 + Z3
+	f.sub(&z3, &t1, &x3)     // Z3 := t1 - X3
+	f.add(&x3, &t1, &x3)     // X3 := t1 + X3
+	f.mul(&y3, &p256B, &y3)  // Y3 := b * Y3
+	f.add(&t1, &t2, &t2)     // t1 := t2 + t2
+	f.add(&t2, &t1, &t2)     // t2 := t1 + t2
+	f.sub(&y3, &y3, &t2)     // Y3 := Y3 - t2
+	f.sub(&y3, &y3, &t0)     // Y3 := Y3 - t0
+	f.add(&t1, &y3, &y3)     // t1 := Y3 + Y3
+	f.add(&y3, &t1, &y3)     // Y3 := t1 + Y3
+	f.add(&t1, &t0, &t0)     // t1 := t0 + t0
+	f.add(&t0, &t1, &t0)     // t0 := t1 + t0
+	f.sub(&t0, &t0, &t2)     // t0 := t0 - t2
+	f.mul(&t1, &t4, &y3)     // t1 := t4 * Y3
+	f.mul(&t2, &t0, &y3)     // t2 := t0 * Y3
+	f.mul(&y3, &x3, &z3)     // Y3 := X3 * Z3
+	f.add(&y3, &y3, &t2)     // Y3 := Y3 + t2
+	f.mul(&x3, &t3, &x3)     // X3 := t3 * X3
+	f.sub(&x3, &x3, &t1)     // X3 := X3 - t1
+	f.mul(&z3, &t4, &z3)     // Z3 := t4 * Z3
+	f.mul(&t1, &t3, &t0)     // t1 := t3 * t0
+	f.add(&z3, &z3, &t1)     // Z3 := Z3 + t1
+	q.x, q.y, q.z = x3, y3, z3
+	return q
+}
+
+// double 完备倍点 q = 2p，Renes-Costello-Batina 算法 6
+func (q *p256Point) double(p *p256Point) *p256Point {
+	f := p256P
+	var t0, t1, t2, t3, x3, y3, z3 p256Element
+	f.square(&t0, &p.x)     // t0 := X ^ 2
+	f.square(&t1, &p.y)     // t1 := Y ^ 2
+	f.square(&t2, &p.z)     // t2 := Z ^ 2
+	f.mul(&t3, &p.x, &p.y)  // t3 := X * Y
+	f.add(&t3, &t3, &t3)    // t3 := t3 + t3
+	f.mul(&z3, &p.x, &p.z)  // Z3 := X * Z
+	f.add(&z3, &z3, &z3)    // Z3 := Z3 + Z3
+	f.mul(&y3, &p256B, &t2) // Y3 := b * t2
+	f.sub(&y3, &y3, &z3)    // Y3 := Y3 - Z3
+	f.add(&x3, &y3, &y3)    // X3 := Y3 + Y3
+	f.add(&y3, &x3, &y3)    // Y3 := X3 + Y3
+	f.sub(&x3, &t1, &y3)    // X3 := t1 - Y3
+	f.add(&y3, &t1, &y3)    // Y3 := t1 + Y3
+	f.mul(&y3, &x3, &y3)    // Y3 := X3 * Y3
+	f.mul(&x3, &x3, &t3)    // X3 := X3 * t3
+	f.add(&t3, &t2, &t2)    // t3 := t2 + t2
+	f.add(&t2, &t2, &t3)    // t2 := t2 + t3
+	f.mul(&z3, &p256B, &z3) // Z3 := b * Z3
+	f.sub(&z3, &z3, &t2)    // Z3 := Z3 - t2
+	f.sub(&z3, &z3, &t0)    // Z3 := Z3 - t0
+	f.add(&t3, &z3, &z3)    // t3 := Z3 + Z3
+	f.add(&z3, &z3, &t3)    // Z3 := Z3 + t3
+	f.add(&t3, &t0, &t0)    // t3 := t0 + t0
+	f.add(&t0, &t3, &t0)    // t0 := t3 + t0
+	f.sub(&t0, &t0, &t2)    // t0 := t0 - t2
+	f.mul(&t0, &t0, &z3)    // t0 := t0 * Z3
+	f.add(&y3, &y3, &t0)    // Y3 := Y3 + t0
+	f.mul(&t0, &p.y, &p.z)  // t0 := Y * Z
+	f.add(&t0, &t0, &t0)    // t0 := t0 + t0
+	f.mul(&z3, &t0, &z3)    // Z3 := t0 * Z3
+	f.sub(&x3, &x3, &z3)    // X3 := X3 - Z3
+	f.mul(&z3, &t0, &t1)    // Z3 := t0 * t1
+	f.add(&z3, &z3, &z3)    // Z3 := Z3 + Z3
+	f.add(&z3, &z3, &z3)    // Z3 := Z3 + Z3
+	q.x, q.y, q.z = x3, y3, z3
+	return q
+}
+
+// p256Table 窗口表，table[i] = [i]P，table[0] 为无穷远点
+type p256Table [16]p256Point
+
+func (t *p256Table) init(p *p256Point) {
+	t[0].setInfinity()
+	t[1] = *p
+	for i := 2; i < 16; i += 2 {
+		t[i].double(&t[i/2])
+		t[i+1].add(&t[i], p)
+	}
+}
+
+// lookup 常数时间取 table[idx]
+func (t *p256Table) lookup(q *p256Point, idx byte) {
+	q.setInfinity()
+	for i := 1; i < 16; i++ {
+		cond := uint64(byte(i)^idx) - 1
+		cond >>= 63
+		p256Select(&q.x, &t[i].x, cond)
+		p256Select(&q.y, &t[i].y, cond)
+		p256Select(&q.z, &t[i].z, cond)
+	}
+}
+
+// p256Scalar 把标量转为 32 字节大端串，长度超过 32 字节时先模 n
+func p256Scalar(k []byte) []byte {
+	if len(k) > 32 {
+		k = new(big.Int).Mod(new(big.Int).SetBytes(k), p256N.bigM).Bytes()
+	}
+	out := make([]byte, 32)
+	copy(out[32-len(k):], k)
+	return out
+}
+
+// scalarMult q = [k]p，k 为 32 字节大端串
+func (q *p256Point) scalarMult(p *p256Point, k []byte) *p256Point {
+	var table p256Table
+	table.init(p)
+	return q.scalarMultTable(&table, k)
+}
+
+// scalarMultTable 使用 p 的窗口表计算 q = [k]p
+func (q *p256Point) scalarMultTable(table *p256Table, k []byte) *p256Point {
+	var acc, t p256Point
+	acc.setInfinity()
+	for _, b := range k {
+		for _, w := range [2]byte{b >> 4, b & 0x0f} {
+			acc.double(&acc)
+			acc.double(&acc)
+			acc.double(&acc)
+			acc.double(&acc)
+			table.lookup(&t, w)
+			acc.add(&acc, &t)
+		}
+	}
+	*q = acc
+	return q
+}
+
+// p256CombTable comb[i] 为 [j · 16^i]P 的窗口表，i = 0, ..., 63，多倍点时每个
+// 4 比特窗口查一次表，不需要倍点
+type p256CombTable [64]p256Table
+
+func (comb *p256CombTable) init(p *p256Point) {
+	q := *p
+	for i := 0; i < 64; i++ {
+		comb[i].init(&q)
+		for j := 0; j < 4; j++ {
+			q.double(&q)
+		}
+	}
+}
+
+// combMult q = [k]P，k 为 32 字节大端串
+func (q *p256Point) combMult(comb *p256CombTable, k []byte) *p256Point {
+	var acc, t p256Point
+	acc.setInfinity()
+	for i := 0; i < 64; i++ {
+		b := k[31-i/2]
+		if i%2 == 1 {
+			b >>= 4
+		}
+		comb[i].lookup(&t, b&0x0f)
+		acc.add(&acc, &t)
+	}
+	*q = acc
+	return q
+}
+
+// p256BaseTable 基点 G 的预计算表，第一次使用时计算
+var (
+	p256BaseTable     *p256CombTable
+	p256BaseTableOnce sync.Once
+)
+
+func p256InitBaseTable() {
+	p256BaseTable = new(p256CombTable)
+	p256BaseTable.init(&p256Base)
+}
+
+// scalarBaseMult q = [k]G
+func (q *p256Point) scalarBaseMult(k []byte) *p256Point {
+	p256BaseTableOnce.Do(p256InitBaseTable)
+	return q.combMult(p256BaseTable, k)
+}
+
+// p256CombinedMult [s]G + [t]P，table 为 P 的窗口表，结果只做一次求逆
+func p256CombinedMult(table *p256Table, s, t []byte) *p256Point {
+	var sg, tp p256Point
+	sg.scalarBaseMult(s)
+	tp.scalarMultTable(table, t)
+	return sg.add(&sg, &tp)
+}
+
+// p256CombinedMultComb [s]G + [t]P，comb 为 P 的预计算表
+func p256CombinedMultComb(comb *p256CombTable, s, t []byte) *p256Point {
+	var sg, tp p256Point
+	sg.scalarBaseMult(s)
+	tp.combMult(comb, t)
+	return sg.add(&sg, &tp)
+}
@@ -0,0 +1,223 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/utils"
+)
+
+// genericCurve 推荐参数但使用通用实现，作为对照
+func genericCurve() Curve {
+	c := GetSm2P256()
+	c.p256 = false
+	return c
+}
+
+func TestP256FieldMul(t *testing.T) {
+	for _, md := range []*montModulus{p256P, p256N} {
+		for i := 0; i < 256; i++ {
+			x, _ := rand.Int(rand.Reader, md.bigM)
+			y, _ := rand.Int(rand.Reader, md.bigM)
+			mx, my := md.fromBig(x), md.fromBig(y)
+
+			var z p256Element
+			md.mul(&z, &mx, &my)
+			expected := new(big.Int).Mul(x, y)
+			expected.Mod(expected, md.bigM)
+			if actual := md.toBig(&z); actual.Cmp(expected) != 0 {
+				t.Fatalf(`TestP256FieldMul 失败
+期望值=%x
+实际值=%x`, expected, actual)
+			}
+
+			md.add(&z, &mx, &my)
+			expected.Add(x, y).Mod(expected, md.bigM)
+			if actual := md.toBig(&z); actual.Cmp(expected) != 0 {
+				t.Fatalf(`TestP256FieldMul 失败
+期望值=%x
+实际值=%x`, expected, actual)
+			}
+
+			md.sub(&z, &mx, &my)
+			expected.Sub(x, y).Mod(expected, md.bigM)
+			if actual := md.toBig(&z); actual.Cmp(expected) != 0 {
+				t.Fatalf(`TestP256FieldMul 失败
+期望值=%x
+实际值=%x`, expected, actual)
+			}
+
+			md.invert(&z, &mx)
+			expected.ModInverse(x, md.bigM)
+			if actual := md.toBig(&z); actual.Cmp(expected) != 0 {
+				t.Fatalf(`TestP256FieldMul 失败
+期望值=%x
+实际值=%x`, expected, actual)
+			}
+		}
+	}
+}
+
+// TestP256ScalarBaseMult OpenSSL 3.0 生成的密钥对
+func TestP256ScalarBaseMult(t *testing.T) {
+	d := utils.HexStringToBytes("89e9da2a97cc47abde0543953bc3b22f1cf53284f207ad6e221a9a2ec46aca58")
+	x, y := GetSm2P256().ScalarBaseMult(d)
+	actual := append(GetSm2P256().fieldBytes(x), GetSm2P256().fieldBytes(y)...)
+	if !bytes.Equal(actual, pointUncompressed[1:]) {
+		t.Errorf(`TestP256ScalarBaseMult 失败
+期望值=%x
+实际值=%x`, pointUncompressed[1:], actual)
+	}
+}
+
+func TestP256AgainstGeneric(t *testing.T) {
+	c, g := GetSm2P256(), genericCurve()
+	n := c.N
+	scalars := [][]byte{
+		{}, {0}, {1}, {2}, {15}, {16},
+		new(big.Int).Sub(n, big.NewInt(1)).Bytes(),
+		n.Bytes(),
+		new(big.Int).Add(n, big.NewInt(1)).Bytes(),
+		bytes.Repeat([]byte{0xff}, 32),
+		bytes.Repeat([]byte{0xff}, 40),
+	}
+	for i := 0; i < 16; i++ {
+		k := make([]byte, 32)
+		rand.Read(k)
+		scalars = append(scalars, k)
+	}
+	px, py := g.ScalarBaseMult([]byte{7})
+	for _, k := range scalars {
+		ex, ey := g.ScalarBaseMult(k)
+		ax, ay := c.ScalarBaseMult(k)
+		if ex.Cmp(ax) != 0 || ey.Cmp(ay) != 0 {
+			t.Errorf(`TestP256AgainstGeneric ScalarBaseMult %x 失败
+期望值=(%x, %x)
+实际值=(%x, %x)`, k, ex, ey, ax, ay)
+		}
+		ex, ey = g.ScalarMult(px, py, k)
+		ax, ay = c.ScalarMult(px, py, k)
+		if ex.Cmp(ax) != 0 || ey.Cmp(ay) != 0 {
+			t.Errorf(`TestP256AgainstGeneric ScalarMult %x 失败
+期望值=(%x, %x)
+实际值=(%x, %x)`, k, ex, ey, ax, ay)
+		}
+	}
+
+	// 点加的特殊情况：P + P，P + (-P)，P + O
+	negY := new(big.Int).Sub(c.P, py)
+	zero := new(big.Int)
+	cases := [][4]*big.Int{
+		{px, py, px, py},
+		{px, py, px, negY},
+		{px, py, zero, zero},
+		{zero, zero, px, py},
+		{zero, zero, zero, zero},
+		{px, py, c.Gx, c.Gy},
+	}
+	for i, p := range cases {
+		ex, ey := g.Add(p[0], p[1], p[2], p[3])
+		ax, ay := c.Add(p[0], p[1], p[2], p[3])
+		if ex.Cmp(ax) != 0 || ey.Cmp(ay) != 0 {
+			t.Errorf(`TestP256AgainstGeneric Add %d 失败
+期望值=(%x, %x)
+实际值=(%x, %x)`, i, ex, ey, ax, ay)
+		}
+	}
+	ex, ey := g.Double(px, py)
+	ax, ay := c.Double(px, py)
+	if ex.Cmp(ax) != 0 || ey.Cmp(ay) != 0 {
+		t.Errorf(`TestP256AgainstGeneric Double 失败
+期望值=(%x, %x)
+实际值=(%x, %x)`, ex, ey, ax, ay)
+	}
+}
+
+func TestP256SignAgainstGeneric(t *testing.T) {
+	sk, _, _ := GenKey(rand.Reader)
+	g := &PrivKey{D: sk.D, Curve: genericCurve()}
+	e := new(big.Int).SetBytes(bytes.Repeat([]byte{0xab}, 32))
+	k := utils.HexStringToBytes("59276E27D506861A16680F3AD9C02DCCEF3CC1FA3CDBE4CE6D54B80DEAC1BC21")
+	next := func() (*big.Int, error) { return new(big.Int).SetBytes(k), nil }
+	r1, s1, _ := sk.signE(next, e)
+	r2, s2, _ := g.signE(next, e)
+	if r1.Cmp(r2) != 0 || s1.Cmp(s2) != 0 {
+		t.Errorf(`TestP256SignAgainstGeneric 失败
+期望值=(%x, %x)
+实际值=(%x, %x)`, r2, s2, r1, s1)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// 基准测试，generic 为同一曲线参数下的通用 big.Int 实现
+// -----------------------------------------------------------------------------
+
+func benchmarkCurves(b *testing.B, f func(b *testing.B, c Curve)) {
+	b.Run("p256", func(b *testing.B) { f(b, GetSm2P256()) })
+	b.Run("generic", func(b *testing.B) { f(b, genericCurve()) })
+}
+
+func BenchmarkScalarBaseMult(b *testing.B) {
+	k := bytes.Repeat([]byte{0x5a}, 32)
+	benchmarkCurves(b, func(b *testing.B, c Curve) {
+		for i := 0; i < b.N; i++ {
+			c.ScalarBaseMult(k)
+		}
+	})
+}
+
+func BenchmarkScalarMult(b *testing.B) {
+	k := bytes.Repeat([]byte{0x5a}, 32)
+	benchmarkCurves(b, func(b *testing.B, c Curve) {
+		for i := 0; i < b.N; i++ {
+			c.ScalarMult(c.Gx, c.Gy, k)
+		}
+	})
+}
+
+func BenchmarkSignToBigInt(b *testing.B) {
+	sk, _, _ := GenKey(rand.Reader)
+	msg := []byte("benchmark")
+	benchmarkCurves(b, func(b *testing.B, c Curve) {
+		key := &PrivKey{D: sk.D, Curve: c}
+		for i := 0; i < b.N; i++ {
+			key.SignToBigInt(nil, msg)
+		}
+	})
+}
+
+func BenchmarkVerifyFromBigInt(b *testing.B) {
+	sk, pk, _ := GenKey(rand.Reader)
+	msg := []byte("benchmark")
+	r, s, _ := sk.SignToBigInt(nil, msg)
+	benchmarkCurves(b, func(b *testing.B, c Curve) {
+		key := &PubKey{X: pk.X, Y: pk.Y, Curve: c}
+		for i := 0; i < b.N; i++ {
+			key.VerifyFromBigInt(nil, msg, r, s)
+		}
+	})
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	_, pk, _ := GenKey(rand.Reader)
+	msg := []byte("benchmark")
+	benchmarkCurves(b, func(b *testing.B, c Curve) {
+		key := &PubKey{X: pk.X, Y: pk.Y, Curve: c}
+		for i := 0; i < b.N; i++ {
+			key.Encrypt(msg)
+		}
+	})
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	sk, pk, _ := GenKey(rand.Reader)
+	ct, _ := pk.Encrypt([]byte("benchmark"))
+	benchmarkCurves(b, func(b *testing.B, c Curve) {
+		key := &PrivKey{D: sk.D, Curve: c}
+		for i := 0; i < b.N; i++ {
+			key.DecryptWithMode(ct, C1C2C3)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package sm2
+
+import (
+	"errors"
+	"math/big"
+)
+
+// -----------------------------------------------------------------------------
+// GB/T 32918.1-2016 4.2.8 - 4.2.9 点到字节串的转换
+//
+// 压缩形式 PC = 02 或 03，后接 x；未压缩形式 PC = 04，后接 x || y；混合形式
+// PC = 06 或 07，后接 x || y。压缩和混合形式中 PC 的最低位为 y 的最右边一位
+// -----------------------------------------------------------------------------
+
+const (
+	CompressedEven = 0x02
+	CompressedOdd  = 0x03
+	HybridEven     = 0x06
+	HybridOdd      = 0x07
+)
+
+var (
+	ErrInvalidPointEncoding = errors.New("sm2: invalid point encoding")
+	ErrPointNotOnCurve      = errors.New("sm2: point is not on the curve")
+)
+
+// marshalCompressed 4.2.9 b) 压缩形式
+func (c Curve) marshalCompressed(x, y *big.Int) []byte {
+	return append([]byte{byte(CompressedEven | y.Bit(0))}, c.fieldBytes(x)...)
+}
+
+// marshalUncompressed 4.2.9 c) 未压缩形式
+func (c Curve) marshalUncompressed(x, y *big.Int) []byte {
+	out := append([]byte{UnCompressed}, c.fieldBytes(x)...)
+	return append(out, c.fieldBytes(y)...)
+}
+
+// marshalHybrid 4.2.9 d) 混合形式
+func (c Curve) marshalHybrid(x, y *big.Int) []byte {
+	out := c.marshalUncompressed(x, y)
+	out[0] = byte(HybridEven | y.Bit(0))
+	return out
+}
+
+// pointLen 根据首字节 PC 返回点编码的长度，PC 不合法时返回 0
+func (c Curve) pointLen(pc byte) int {
+	size := c.ByteSize()
+	switch pc {
+	case CompressedEven, CompressedOdd:
+		return 1 + size
+	case UnCompressed, HybridEven, HybridOdd:
+		return 1 + 2*size
+	}
+	return 0
+}
+
+// decompress 附录 B.1.4 由 x 和 y 的最右边一位恢复 y，y^2 = x^3 + ax + b
+func (c Curve) decompress(x *big.Int, yBit uint) (*big.Int, error) {
+	if x.Cmp(c.P) >= 0 {
+		return nil, ErrPointNotOnCurve
+	}
+	alpha := new(big.Int).Mul(x, x)
+	alpha.Add(alpha, c.A)
+	alpha.Mul(alpha, x)
+	alpha.Add(alpha, c.B)
+	alpha.Mod(alpha, c.P)
+	// SM2 推荐曲线 p ≡ 3 (mod 4)，ModSqrt 此时直接计算 alpha^((p+1)/4)
+	y := new(big.Int).ModSqrt(alpha, c.P)
+	if y == nil {
+		return nil, ErrPointNotOnCurve
+	}
+	if y.Bit(0) != yBit {
+		y.Sub(c.P, y)
+	}
+	return y, nil
+}
+
+// unmarshalPoint 4.2.10 字节串到点的转换，接受三种形式，并验证点在曲线上
+func (c Curve) unmarshalPoint(data []byte) (x, y *big.Int, err error) {
+	if len(data) == 0 || len(data) != c.pointLen(data[0]) {
+		return nil, nil, ErrInvalidPointEncoding
+	}
+	size := (c.BitSize + 7) / 8
+	pc := data[0]
+	x = new(big.Int).SetBytes(data[1 : 1+size])
+	if pc == CompressedEven || pc == CompressedOdd {
+		y, err = c.decompress(x, uint(pc&1))
+		if err != nil {
+			return nil, nil, err
+		}
+		return x, y, nil
+	}
+	y = new(big.Int).SetBytes(data[1+size:])
+	if (pc == HybridEven || pc == HybridOdd) && y.Bit(0) != uint(pc&1) {
+		return nil, nil, ErrInvalidPointEncoding
+	}
+	if !c.IsOnCurve(x, y) {
+		return nil, nil, ErrPointNotOnCurve
+	}
+	return x, y, nil
+}
+
+// ToCompressedBytes 公钥压缩形式字节流
+func (pk *PubKey) ToCompressedBytes() []byte {
+	return pk.Curve.marshalCompressed(pk.X, pk.Y)
+}
+
+// ToHybridBytes 公钥混合形式字节流
+func (pk *PubKey) ToHybridBytes() []byte {
+	return pk.Curve.marshalHybrid(pk.X, pk.Y)
+}
+
+// ParsePubKey 解析推荐曲线上压缩、未压缩或混合形式的公钥，并按 GB/T 32918.1-2016
+// 6.2.1 验证
+func ParsePubKey(data []byte) (*PubKey, error) {
+	return ParsePubKeyWithCurve(data, sm2P256)
+}
+
+// ParsePubKeyWithCurve 解析 curve 上的公钥，编码长度由曲线的 ByteSize 决定
+func ParsePubKeyWithCurve(data []byte, curve Curve) (*PubKey, error) {
+	x, y, err := curve.unmarshalPoint(data)
+	if err != nil {
+		return nil, err
+	}
+	pk := &PubKey{X: x, Y: y, Curve: curve}
+	if err := pk.Validate(); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
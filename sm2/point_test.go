@@ -0,0 +1,119 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/utils"
+)
+
+// 以下公钥由 OpenSSL 3.0 生成，openssl ec -pubout -conv_form compressed|hybrid
+var (
+	pointUncompressed = utils.HexStringToBytes("04" +
+		"4cba42a0d4ead914acc1284b6b1a7a0f20566b6ccb232788350d01706f218523" +
+		"3876dee9bf182920c147ca76f19f097856d7b785c6346d9454fab8e065ce21d5")
+	pointCompressed = utils.HexStringToBytes("03" +
+		"4cba42a0d4ead914acc1284b6b1a7a0f20566b6ccb232788350d01706f218523")
+	pointHybrid = utils.HexStringToBytes("07" +
+		"4cba42a0d4ead914acc1284b6b1a7a0f20566b6ccb232788350d01706f218523" +
+		"3876dee9bf182920c147ca76f19f097856d7b785c6346d9454fab8e065ce21d5")
+)
+
+func TestParsePubKey(t *testing.T) {
+	for _, data := range [][]byte{pointUncompressed, pointCompressed, pointHybrid} {
+		pk, err := ParsePubKey(data)
+		if err != nil {
+			t.Fatalf("TestParsePubKey 失败: %x: %v", data[:1], err)
+		}
+		if !bytes.Equal(pk.ToUncompressedBytes(), pointUncompressed) {
+			t.Errorf(`TestParsePubKey 失败
+期望值=%x
+实际值=%x`, pointUncompressed, pk.ToUncompressedBytes())
+		}
+		if !bytes.Equal(pk.ToCompressedBytes(), pointCompressed) {
+			t.Errorf(`TestParsePubKey 失败
+期望值=%x
+实际值=%x`, pointCompressed, pk.ToCompressedBytes())
+		}
+		if !bytes.Equal(pk.ToHybridBytes(), pointHybrid) {
+			t.Errorf(`TestParsePubKey 失败
+期望值=%x
+实际值=%x`, pointHybrid, pk.ToHybridBytes())
+		}
+	}
+}
+
+func TestParsePubKeyRoundTrip(t *testing.T) {
+	for i := 0; i < 32; i++ {
+		_, pk, _ := GenKey(rand.Reader)
+		for _, data := range [][]byte{pk.ToUncompressedBytes(), pk.ToCompressedBytes(), pk.ToHybridBytes()} {
+			got, err := ParsePubKey(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(pk) {
+				t.Errorf(`TestParsePubKeyRoundTrip 失败
+期望值=%x
+实际值=%x`, pk.ToUncompressedBytes(), got.ToUncompressedBytes())
+			}
+		}
+	}
+}
+
+func TestParsePubKeyInvalid(t *testing.T) {
+	wrongParity := append([]byte{HybridEven}, pointHybrid[1:]...)
+	notOnCurve := append([]byte(nil), pointUncompressed...)
+	notOnCurve[len(notOnCurve)-1] ^= 1
+	// x = 2 时 x^3 + ax + b 不是模 p 的二次剩余
+	noSqrt := make([]byte, 1+KeyBytes)
+	noSqrt[0] = CompressedEven
+	noSqrt[KeyBytes] = 2
+
+	cases := []struct {
+		data []byte
+		err  error
+	}{
+		{nil, ErrInvalidPointEncoding},
+		{[]byte{0x00}, ErrInvalidPointEncoding},
+		{[]byte{0x05}, ErrInvalidPointEncoding},
+		{pointCompressed[:KeyBytes], ErrInvalidPointEncoding},
+		{append([]byte{UnCompressed}, pointCompressed[1:]...), ErrInvalidPointEncoding},
+		{wrongParity, ErrInvalidPointEncoding},
+		{notOnCurve, ErrPointNotOnCurve},
+		{noSqrt, ErrPointNotOnCurve},
+	}
+	for i, c := range cases {
+		if _, err := ParsePubKey(c.data); err != c.err {
+			t.Errorf(`TestParsePubKeyInvalid %d 失败
+期望值=%v
+实际值=%v`, i, c.err, err)
+		}
+	}
+}
+
+func TestDecryptCompressedC1(t *testing.T) {
+	sk, pk, _ := GenKey(rand.Reader)
+	msg := []byte("compressed C1")
+	ct, err := pk.Encrypt(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1 := ct[:1+2*KeyBytes]
+	x, y, err := sk.Curve.unmarshalPoint(c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c1 := range [][]byte{sk.Curve.marshalCompressed(x, y), sk.Curve.marshalHybrid(x, y)} {
+		in := append(append([]byte(nil), c1...), ct[1+2*KeyBytes:]...)
+		pt, err := sk.Decrypt(nil, in, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(pt, msg) {
+			t.Errorf(`TestDecryptCompressedC1 失败
+期望值=%x
+实际值=%x`, msg, pt)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package sm2
+
+import (
+	"crypto/hmac"
+	"hash"
+	"math/big"
+)
+
+// -----------------------------------------------------------------------------
+// RFC 6979 3.2 确定性地生成 k，杂凑函数使用 SM3
+// -----------------------------------------------------------------------------
+
+// rfc6979 以 HMAC_DRBG 从私钥和消息杂凑值派生 k
+type rfc6979 struct {
+	newHash func() hash.Hash
+	q       *big.Int
+	qlen    int
+	k, v    []byte
+}
+
+// bits2int 2.3.2
+func bits2int(b []byte, qlen int) *big.Int {
+	v := new(big.Int).SetBytes(b)
+	if blen := len(b) * 8; blen > qlen {
+		v.Rsh(v, uint(blen-qlen))
+	}
+	return v
+}
+
+// int2octets 2.3.3
+func int2octets(v *big.Int, rlen int) []byte {
+	out := v.Bytes()
+	if len(out) < rlen {
+		out = append(make([]byte, rlen-len(out)), out...)
+	}
+	if len(out) > rlen {
+		out = out[len(out)-rlen:]
+	}
+	return out
+}
+
+// bits2octets 2.3.4
+func bits2octets(b []byte, q *big.Int, qlen int) []byte {
+	z := bits2int(b, qlen)
+	if z.Cmp(q) >= 0 {
+		z.Sub(z, q)
+	}
+	return int2octets(z, (qlen+7)/8)
+}
+
+func (g *rfc6979) mac(key []byte, parts ...[]byte) []byte {
+	m := hmac.New(g.newHash, key)
+	for _, p := range parts {
+		m.Write(p)
+	}
+	return m.Sum(nil)
+}
+
+// newRFC6979 3.2 步骤 a 到 f，extra 为 3.6 中的额外数据，可以为空
+func newRFC6979(newHash func() hash.Hash, q, x *big.Int, h1 []byte, extra []byte) *rfc6979 {
+	g := &rfc6979{newHash: newHash, q: q, qlen: q.BitLen()}
+	rlen := (g.qlen + 7) / 8
+	hlen := newHash().Size()
+	xo := int2octets(x, rlen)
+	ho := bits2octets(h1, q, g.qlen)
+
+	g.v = make([]byte, hlen)
+	for i := range g.v {
+		g.v[i] = 0x01
+	}
+	g.k = make([]byte, hlen)
+
+	g.k = g.mac(g.k, g.v, []byte{0x00}, xo, ho, extra)
+	g.v = g.mac(g.k, g.v)
+	g.k = g.mac(g.k, g.v, []byte{0x01}, xo, ho, extra)
+	g.v = g.mac(g.k, g.v)
+	return g
+}
+
+// next 3.2 步骤 h，每次调用返回下一个 k ∈ [1, q-1]
+func (g *rfc6979) next() (*big.Int, error) {
+	for {
+		var t []byte
+		for len(t)*8 < g.qlen {
+			g.v = g.mac(g.k, g.v)
+			t = append(t, g.v...)
+		}
+		k := bits2int(t, g.qlen)
+		// 无论 k 是否可用，都先推进状态，保证下一次调用得到新的候选值
+		g.k = g.mac(g.k, g.v, []byte{0x00})
+		g.v = g.mac(g.k, g.v)
+		if k.Sign() > 0 && k.Cmp(g.q) < 0 {
+			return k, nil
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package sm2
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/sm3"
+	"github.com/t1anchen/gogmlib/utils"
+)
+
+// TestRFC6979 以 RFC 6979 A.2.5 中 P-256 和 SHA-256 的例子验证 k 的生成过程
+func TestRFC6979(t *testing.T) {
+	q := elliptic.P256().Params().N
+	x := utils.NewBigIntFromHexString("C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721")
+	cases := []struct {
+		msg string
+		k   string
+	}{
+		{"sample", "A6E3C57DD01ABE90086538398355DD4C3B17AA873382B0F24D6129493D8AAD60"},
+		{"test", "D16B6AE827F17175E040871A1C7EC3500192C4C92677336EC2537ACAEE0008E0"},
+	}
+	for _, c := range cases {
+		h := sha256.Sum256([]byte(c.msg))
+		k, err := newRFC6979(sha256.New, q, x, h[:], nil).next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := utils.NewBigIntFromHexString(c.k)
+		if k.Cmp(expected) != 0 {
+			t.Errorf(`TestRFC6979 失败
+期望值=%x
+实际值=%x`, expected, k)
+		}
+	}
+}
+
+func TestRFC6979Next(t *testing.T) {
+	q := GetSm2P256().N
+	x := utils.NewBigIntFromHexString("3945208F7B2144B13F36E38AC6D39F95889393692860B51A42FB81EF4DF7C5B8")
+	g := newRFC6979(sm3.New, q, x, make([]byte, 32), nil)
+	k1, _ := g.next()
+	k2, _ := g.next()
+	if k1.Cmp(k2) == 0 {
+		t.Error("TestRFC6979Next 失败: 连续两次生成相同的 k")
+	}
+}
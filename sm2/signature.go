@@ -0,0 +1,202 @@
+package sm2
+
+import (
+	"hash"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// -----------------------------------------------------------------------------
+// 签名的编码
+//
+//   - DER：GM/T 0009-2012 7.3 SM2Signature ::= SEQUENCE { R INTEGER, S INTEGER }
+//   - 原始格式：r || s，各 32 字节大端，浏览器和移动端 SDK 常用
+//
+// 原始格式的长度与曲线有关，Signature 的方法和 ParseSignature 按推荐曲线处理，
+// 其他曲线使用 Curve 的同名方法，r 和 s 各占 Curve.ScalarSize 字节。
+//
+// 解析 DER 时使用严格的 DER 规则：长度和整数必须是最短编码，整数不能为负，
+// 不允许尾随数据。encoding/asn1 会接受负数，这里不使用它解析签名
+// -----------------------------------------------------------------------------
+
+// SignatureFormat 签名的编码格式
+type SignatureFormat int
+
+const (
+	// SignatureDER ASN.1 DER 编码
+	SignatureDER SignatureFormat = iota
+	// SignatureRaw r || s 定长编码
+	SignatureRaw
+)
+
+// RawSignatureSize 推荐曲线上原始格式签名的字节长度
+const RawSignatureSize = 2 * KeyBytes
+
+func (f SignatureFormat) String() string {
+	switch f {
+	case SignatureDER:
+		return "der"
+	case SignatureRaw:
+		return "raw"
+	}
+	return "SignatureFormat(invalid)"
+}
+
+// MarshalDER 输出 DER 编码
+func (sig *Signature) MarshalDER() ([]byte, error) {
+	if sig.R == nil || sig.S == nil || sig.R.Sign() < 0 || sig.S.Sign() < 0 {
+		return nil, ErrInvalidSignatureEncoding
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(sig.R)
+		b.AddASN1BigInt(sig.S)
+	})
+	return b.Bytes()
+}
+
+// ParseDER 按严格的 DER 规则解析签名
+func (sig *Signature) ParseDER(data []byte) error {
+	input := cryptobyte.String(data)
+	var inner cryptobyte.String
+	r, s := new(big.Int), new(big.Int)
+	if !input.ReadASN1(&inner, cryptobyte_asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Integer(r) || !inner.ReadASN1Integer(s) || !inner.Empty() {
+		return ErrInvalidSignatureEncoding
+	}
+	if r.Sign() < 0 || s.Sign() < 0 {
+		return ErrInvalidSignatureEncoding
+	}
+	sig.R, sig.S = r, s
+	return nil
+}
+
+// MarshalRaw 输出 r || s，r 和 s 必须在 [0, 2^256) 内
+func (sig *Signature) MarshalRaw() ([]byte, error) {
+	return sig.marshalRaw(KeyBytes)
+}
+
+func (sig *Signature) marshalRaw(size int) ([]byte, error) {
+	if sig.R == nil || sig.S == nil || sig.R.Sign() < 0 || sig.S.Sign() < 0 ||
+		sig.R.BitLen() > 8*size || sig.S.BitLen() > 8*size {
+		return nil, ErrInvalidSignatureEncoding
+	}
+	return append(fixedBytes(sig.R, size), fixedBytes(sig.S, size)...), nil
+}
+
+// ParseRaw 解析 r || s，长度必须为 RawSignatureSize
+func (sig *Signature) ParseRaw(data []byte) error {
+	return sig.parseRaw(data, KeyBytes)
+}
+
+func (sig *Signature) parseRaw(data []byte, size int) error {
+	if len(data) != 2*size {
+		return ErrInvalidSignatureEncoding
+	}
+	sig.R = new(big.Int).SetBytes(data[:size])
+	sig.S = new(big.Int).SetBytes(data[size:])
+	return nil
+}
+
+// Marshal 按 format 编码推荐曲线上的签名
+func (sig *Signature) Marshal(format SignatureFormat) ([]byte, error) {
+	return sm2P256.MarshalSignature(sig, format)
+}
+
+// MarshalSignature 按 format 编码本曲线上的签名
+func (c Curve) MarshalSignature(sig *Signature, format SignatureFormat) ([]byte, error) {
+	switch format {
+	case SignatureDER:
+		return sig.MarshalDER()
+	case SignatureRaw:
+		return sig.marshalRaw(c.ScalarSize())
+	}
+	return nil, ErrInvalidSignatureEncoding
+}
+
+// ParseSignature 按 format 解析推荐曲线上的签名
+func ParseSignature(data []byte, format SignatureFormat) (*Signature, error) {
+	return sm2P256.ParseSignature(data, format)
+}
+
+// ParseSignature 按 format 解析本曲线上的签名
+func (c Curve) ParseSignature(data []byte, format SignatureFormat) (*Signature, error) {
+	sig := new(Signature)
+	var err error
+	switch format {
+	case SignatureDER:
+		err = sig.ParseDER(data)
+	case SignatureRaw:
+		err = sig.parseRaw(data, c.ScalarSize())
+	default:
+		err = ErrInvalidSignatureEncoding
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// ConvertSignature 在两种编码之间转换推荐曲线上的签名
+func ConvertSignature(data []byte, from, to SignatureFormat) ([]byte, error) {
+	return sm2P256.ConvertSignature(data, from, to)
+}
+
+// ConvertSignature 在两种编码之间转换本曲线上的签名
+func (c Curve) ConvertSignature(data []byte, from, to SignatureFormat) ([]byte, error) {
+	sig, err := c.ParseSignature(data, from)
+	if err != nil {
+		return nil, err
+	}
+	return c.MarshalSignature(sig, to)
+}
+
+// parseSignature 按严格的 DER 规则解析签名
+func parseSignature(sigBytes []byte) (r, s *big.Int, err error) {
+	sig, err := ParseSignature(sigBytes, SignatureDER)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sig.R, sig.S, nil
+}
+
+// SignWithFormat 生成 format 编码的签名，hashFunc 可选，缺省为 SM3
+func (sk *PrivKey) SignWithFormat(userID []byte, msg []byte, format SignatureFormat, hashFunc ...func() hash.Hash) ([]byte, error) {
+	return sk.SignWithFormatWithRand(nil, userID, msg, format, hashFunc...)
+}
+
+// SignWithFormatWithRand 与 SignWithFormat 相同，k 从 random 读取，random 为 nil
+// 时使用 crypto/rand.Reader
+func (sk *PrivKey) SignWithFormatWithRand(random io.Reader, userID []byte, msg []byte, format SignatureFormat, hashFunc ...func() hash.Hash) ([]byte, error) {
+	r, s, err := sk.SignToBigIntWithRand(random, userID, msg, hashFunc...)
+	if err != nil {
+		return nil, err
+	}
+	return sk.Curve.MarshalSignature(&Signature{R: r, S: s}, format)
+}
+
+// VerifyWithFormat 验证 format 编码的签名，hashFunc 可选，缺省为 SM3
+func (pk *PubKey) VerifyWithFormat(userID []byte, msg []byte, sig []byte, format SignatureFormat, hashFunc ...func() hash.Hash) bool {
+	return pk.CheckSignatureWithFormat(userID, msg, sig, format, hashFunc...) == nil
+}
+
+// CheckSignatureWithFormat 验证 format 编码的签名，返回签名无效的原因
+func (pk *PubKey) CheckSignatureWithFormat(userID []byte, msg []byte, sig []byte, format SignatureFormat, hashFunc ...func() hash.Hash) error {
+	parsed, err := pk.Curve.ParseSignature(sig, format)
+	if err != nil {
+		return err
+	}
+	return pk.CheckSignatureFromBigInt(userID, msg, parsed.R, parsed.S, hashFunc...)
+}
+
+// CheckSignatureWithFormat 验证 format 编码的签名，返回签名无效的原因
+func (v *Verifier) CheckSignatureWithFormat(msg []byte, sig []byte, format SignatureFormat) error {
+	parsed, err := v.pk.Curve.ParseSignature(sig, format)
+	if err != nil {
+		return err
+	}
+	return v.CheckSignatureFromBigInt(msg, parsed.R, parsed.S)
+}
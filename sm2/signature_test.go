@@ -0,0 +1,128 @@
+package sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/utils"
+)
+
+func TestSignatureEncodingExample(t *testing.T) {
+	sig := &Signature{
+		R: utils.NewBigIntFromHexString(exampleSignR),
+		S: utils.NewBigIntFromHexString(exampleSignS),
+	}
+	raw, err := sig.MarshalRaw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := hex.EncodeToString(raw), strings.ToLower(exampleSignR+exampleSignS); actual != expected {
+		t.Errorf("TestSignatureEncodingExample 失败\n期望值=%s\n实际值=%s", expected, actual)
+	}
+	der, _ := sig.MarshalDER()
+	expected := "3044022040f1ec59f793d9f49e09dcef49130d4194f79fb1eed2caa55bacdb49c4e755d102206fc6dac32c5d5cf10c77dfb20f7c2eb667a457872fb09ec56327a67ec7deebe7"
+	if actual := hex.EncodeToString(der); actual != expected {
+		t.Errorf("TestSignatureEncodingExample 失败\n期望值=%s\n实际值=%s", expected, actual)
+	}
+
+	converted, err := ConvertSignature(raw, SignatureRaw, SignatureDER)
+	if err != nil || !bytes.Equal(converted, der) {
+		t.Errorf("TestSignatureEncodingExample 转换失败\n期望值=%x\n实际值=%x", der, converted)
+	}
+	converted, err = ConvertSignature(der, SignatureDER, SignatureRaw)
+	if err != nil || !bytes.Equal(converted, raw) {
+		t.Errorf("TestSignatureEncodingExample 转换失败\n期望值=%x\n实际值=%x", raw, converted)
+	}
+}
+
+// TestSignatureRawLeadingZero r、s 较短时原始格式补齐前导 0，DER 使用最短编码
+func TestSignatureRawLeadingZero(t *testing.T) {
+	sig := &Signature{R: big.NewInt(1), S: new(big.Int).Lsh(big.NewInt(1), 255)}
+	raw, _ := sig.MarshalRaw()
+	if len(raw) != RawSignatureSize || raw[KeyBytes-1] != 1 || raw[KeyBytes] != 0x80 {
+		t.Errorf("TestSignatureRawLeadingZero 失败: %x", raw)
+	}
+	der, _ := ConvertSignature(raw, SignatureRaw, SignatureDER)
+	if !bytes.HasPrefix(der, []byte{0x30, 0x26, 0x02, 0x01, 0x01, 0x02, 0x21, 0x00, 0x80}) {
+		t.Errorf("TestSignatureRawLeadingZero 失败: %x", der)
+	}
+	back, _ := ConvertSignature(der, SignatureDER, SignatureRaw)
+	if !bytes.Equal(back, raw) {
+		t.Errorf("TestSignatureRawLeadingZero 失败\n期望值=%x\n实际值=%x", raw, back)
+	}
+
+	tooLarge := &Signature{R: new(big.Int).Lsh(big.NewInt(1), 256), S: big.NewInt(1)}
+	if _, err := tooLarge.MarshalRaw(); err != ErrInvalidSignatureEncoding {
+		t.Errorf("TestSignatureRawLeadingZero 失败: 超过 256 位未报错, err=%v", err)
+	}
+}
+
+func TestParseDERStrict(t *testing.T) {
+	valid := "3006020101020102"
+	if _, err := ParseSignature(mustHex(valid), SignatureDER); err != nil {
+		t.Fatalf("TestParseDERStrict 失败: %v", err)
+	}
+	for _, c := range []string{
+		"300702020001020102",     // 整数不是最短编码
+		"30060201ff020102",       // 负数
+		"300602010102010200",     // 尾随数据
+		"3081060201010201020000", // 长度不是最短编码
+		"3009020101020102020103", // SEQUENCE 中有多余的元素
+		"3003020101",             // 缺少 s
+		"3106020101020102",       // 不是 SEQUENCE
+		"3080020101020102",       // BER 不定长
+		"",
+	} {
+		if _, err := ParseSignature(mustHex(c), SignatureDER); err != ErrInvalidSignatureEncoding {
+			t.Errorf("TestParseDERStrict %s 失败: err=%v", c, err)
+		}
+	}
+	for _, n := range []int{0, RawSignatureSize - 1, RawSignatureSize + 1} {
+		if _, err := ParseSignature(make([]byte, n), SignatureRaw); err != ErrInvalidSignatureEncoding {
+			t.Errorf("TestParseDERStrict 原始格式长度 %d 失败: err=%v", n, err)
+		}
+	}
+}
+
+func TestSignWithFormat(t *testing.T) {
+	sk, pk, _ := GenKey(rand.Reader)
+	msg := []byte("signature formats")
+	v := pk.Precompute(nil)
+	for _, format := range []SignatureFormat{SignatureDER, SignatureRaw} {
+		sig, err := sk.SignWithFormat(nil, msg, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format == SignatureRaw && len(sig) != RawSignatureSize {
+			t.Errorf("TestSignWithFormat %s 失败: 长度 %d", format, len(sig))
+		}
+		if !pk.VerifyWithFormat(nil, msg, sig, format) {
+			t.Errorf("TestSignWithFormat %s 失败: 验证未通过", format)
+		}
+		if err := v.CheckSignatureWithFormat(msg, sig, format); err != nil {
+			t.Errorf("TestSignWithFormat %s 失败: %v", format, err)
+		}
+	}
+
+	// 原始格式的签名不能按 DER 验证，反之亦然
+	raw, _ := sk.SignWithFormat(nil, msg, SignatureRaw)
+	if err := pk.CheckSignatureWithFormat(nil, msg, raw, SignatureDER); err != ErrInvalidSignatureEncoding {
+		t.Errorf("TestSignWithFormat 失败: err=%v", err)
+	}
+	der, _ := sk.SignWithFormat(nil, msg, SignatureDER)
+	if err := pk.CheckSignatureWithFormat(nil, msg, der, SignatureRaw); err != ErrInvalidSignatureEncoding {
+		t.Errorf("TestSignWithFormat 失败: err=%v", err)
+	}
+}
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -1,10 +1,9 @@
 package sm2
 
 import (
-	"bytes"
 	"crypto/elliptic"
 	"crypto/rand"
-	"encoding/asn1"
+	"crypto/subtle"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
@@ -17,6 +16,8 @@ import (
 	"github.com/t1anchen/gogmlib/utils"
 )
 
+// BitSize 和 KeyBytes 是推荐曲线的长度，其他曲线使用 Curve.ByteSize 和
+// Curve.ScalarSize
 const (
 	BitSize      = 256
 	KeyBytes     = (BitSize + 7) / 8
@@ -30,6 +31,7 @@ type PubKey struct {
 	Curve Curve    `json:"curve"`
 }
 
+// String 调试用的 JSON，不能解析回公钥，交换密钥使用 jwk 子包
 func (pk *PubKey) String() string {
 	b, _ := json.Marshal(pk)
 	return fmt.Sprintf("%s", b)
@@ -41,6 +43,7 @@ type PrivKey struct {
 	Curve Curve    `json:"curve"`
 }
 
+// String 调试用的 JSON，不能解析回私钥，交换密钥使用 jwk 子包
 func (sk *PrivKey) String() string {
 	b, _ := json.Marshal(sk)
 	return fmt.Sprintf("%s", b)
@@ -51,38 +54,16 @@ type Signature struct {
 	R, S *big.Int
 }
 
-// ASN1DERCipher 密文
+// ASN1DERCipher GM/T 0009-2012 7.2 SM2 密文的 ASN.1 结构
 type ASN1DERCipher struct {
 	X, Y *big.Int
 	C3   []byte
 	C2   []byte
 }
 
-// ToUncompressedBytes 公钥未压缩字节流
+// ToUncompressedBytes 公钥未压缩字节流，坐标按曲线的 ByteSize 补齐
 func (pk *PubKey) ToUncompressedBytes() []byte {
-	xBytes := utils.BigIntToBytes(pk.X)
-	yBytes := utils.BigIntToBytes(pk.Y)
-	xLen := len(xBytes)
-	yLen := len(yBytes)
-
-	pkBuf := make([]byte, 1+KeyBytes*2)
-	pkBuf[0] = UnCompressed
-	if xLen > KeyBytes {
-		copy(pkBuf[1:1+KeyBytes], xBytes[xLen-KeyBytes:])
-	} else if xLen < KeyBytes {
-		copy(pkBuf[1+(KeyBytes-xLen):1+KeyBytes], xBytes)
-	} else {
-		copy(pkBuf[1:1+KeyBytes], xBytes)
-	}
-
-	if yLen > KeyBytes {
-		copy(pkBuf[1+KeyBytes:], yBytes[yLen-KeyBytes:])
-	} else if yLen < KeyBytes {
-		copy(pkBuf[1+KeyBytes+(KeyBytes-yLen):], yBytes)
-	} else {
-		copy(pkBuf[1+KeyBytes:], yBytes)
-	}
-	return pkBuf
+	return pk.Curve.marshalUncompressed(pk.X, pk.Y)
 }
 
 // ToBytes 公钥的字节流
@@ -90,22 +71,9 @@ func (pk *PubKey) ToBytes() []byte {
 	return pk.ToUncompressedBytes()[1:]
 }
 
-// ToBytes 私钥的字节流
+// ToBytes 私钥的字节流，按曲线的 ScalarSize 补齐
 func (sk *PrivKey) ToBytes() []byte {
-	dBytes := utils.BigIntToBytes(sk.D)
-	dLen := len(dBytes)
-
-	if dLen > KeyBytes {
-		skBuf := make([]byte, KeyBytes)
-		copy(skBuf, dBytes[dLen-KeyBytes:])
-		return skBuf
-	} else if dLen < KeyBytes {
-		skBuf := make([]byte, KeyBytes)
-		copy(skBuf[KeyBytes-dLen:], dBytes)
-		return skBuf
-	} else {
-		return dBytes
-	}
+	return sk.Curve.scalarBytes(sk.D)
 }
 
 // -----------------------------------------------------------------------------
@@ -117,17 +85,37 @@ var (
 	sm2SignDefaultUserID = utils.HexStringToBytes("31323334353637383132333435363738")
 )
 
-// GenKey 生成密钥对
+var (
+	ErrInvalidSignatureEncoding = errors.New("sm2: invalid signature encoding")
+	ErrVerificationFailed       = errors.New("sm2: signature verification failed")
+	ErrEmptyPlaintext           = errors.New("sm2: plaintext is empty")
+)
+
+// GenKey 生成推荐曲线上的密钥对，私钥在 [1, n-2] 内
 func GenKey(rand io.Reader) (*PrivKey, *PubKey, error) {
-	privFromGoCrypto, xFromGoCrypto, yFromGoCrypto, err := elliptic.GenerateKey(sm2P256, rand)
-	if err != nil {
-		return nil, nil, err
+	return GenKeyWithCurve(sm2P256, rand)
+}
+
+// GenKeyWithCurve 生成 curve 上的密钥对，私钥在 [1, n-2] 内
+func GenKeyWithCurve(curve Curve, rand io.Reader) (*PrivKey, *PubKey, error) {
+	var privKey *PrivKey
+	var xFromGoCrypto, yFromGoCrypto *big.Int
+	for {
+		privFromGoCrypto, x, y, err := elliptic.GenerateKey(curve, rand)
+		if err != nil {
+			return nil, nil, err
+		}
+		privKey = &PrivKey{
+			D:     utils.NewBigIntFromBytes(privFromGoCrypto),
+			Curve: curve}
+		xFromGoCrypto, yFromGoCrypto = x, y
+		// elliptic.GenerateKey 的范围是 [1, n-1]
+		if privKey.Validate() == nil {
+			break
+		}
 	}
-	privKey := &PrivKey{
-		D:     utils.NewBigIntFromBytes(privFromGoCrypto),
-		Curve: sm2P256}
 	pubKey := &PubKey{
-		Curve: sm2P256,
+		Curve: curve,
 		X:     xFromGoCrypto,
 		Y:     yFromGoCrypto}
 	return privKey, pubKey, nil
@@ -167,12 +155,12 @@ func getZ(hashProvider hash.Hash, curve *Curve, pubX *big.Int, pubY *big.Int, us
 		hashProvider.Write(userID)
 	}
 
-	hashProvider.Write(utils.BigIntToBytes(curve.A))
-	hashProvider.Write(utils.BigIntToBytes(curve.B))
-	hashProvider.Write(utils.BigIntToBytes(curve.Gx))
-	hashProvider.Write(utils.BigIntToBytes(curve.Gy))
-	hashProvider.Write(utils.BigIntToBytes(pubX))
-	hashProvider.Write(utils.BigIntToBytes(pubY))
+	hashProvider.Write(curve.fieldBytes(curve.A))
+	hashProvider.Write(curve.fieldBytes(curve.B))
+	hashProvider.Write(curve.fieldBytes(curve.Gx))
+	hashProvider.Write(curve.fieldBytes(curve.Gy))
+	hashProvider.Write(curve.fieldBytes(pubX))
+	hashProvider.Write(curve.fieldBytes(pubY))
 	return hashProvider.Sum(nil)
 }
 
@@ -186,41 +174,58 @@ func genE(hashProvider hash.Hash, curve *Curve, pubX *big.Int, pubY *big.Int, us
 	return utils.NewBigIntFromBytes(eHash)
 }
 
-// SignToASN1DER 生成签名并输出成 ASN.1 DER 格式
-func (sk *PrivKey) SignToASN1DER(userID []byte, msg []byte) ([]byte, error) {
-	r, s, signErr := sk.SignToBigInt(userID, msg)
+// SignToASN1DER 生成签名并输出成 ASN.1 DER 格式，hashFunc 可选，缺省为 SM3
+func (sk *PrivKey) SignToASN1DER(userID []byte, msg []byte, hashFunc ...func() hash.Hash) ([]byte, error) {
+	return sk.SignToASN1DERWithRand(nil, userID, msg, hashFunc...)
+}
+
+// SignToASN1DERWithRand 与 SignToASN1DER 相同，k 从 random 读取，random 为 nil
+// 时使用 crypto/rand.Reader
+func (sk *PrivKey) SignToASN1DERWithRand(random io.Reader, userID []byte, msg []byte, hashFunc ...func() hash.Hash) ([]byte, error) {
+	r, s, signErr := sk.SignToBigIntWithRand(random, userID, msg, hashFunc...)
 	if signErr != nil {
 		return nil, signErr
 	}
 
-	encoded, encErr := asn1.Marshal(Signature{r, s})
-	if encErr != nil {
-		return nil, encErr
-	}
-
-	return encoded, nil
+	return (&Signature{R: r, S: s}).MarshalDER()
+}
 
+// SignToBigInt 生成签名并输出成大数，hashFunc 可选，缺省为 SM3
+func (sk *PrivKey) SignToBigInt(userID []byte, msg []byte, hashFunc ...func() hash.Hash) (r, s *big.Int, err error) {
+	return sk.SignToBigIntWithRand(nil, userID, msg, hashFunc...)
 }
 
-// SignToBigInt 生成签名并输出成大数
-func (sk *PrivKey) SignToBigInt(userID []byte, msg []byte) (r, s *big.Int, err error) {
-	digest := sm3.New()
+// SignToBigIntWithRand 与 SignToBigInt 相同，k 从 random 读取，可以传入经过认证
+// 的 DRBG，random 为 nil 时使用 crypto/rand.Reader
+func (sk *PrivKey) SignToBigIntWithRand(random io.Reader, userID []byte, msg []byte, hashFunc ...func() hash.Hash) (r, s *big.Int, err error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	digest := hashOrDefault(hashFunc)()
 	pubX, pubY := sk.Curve.ScalarBaseMult(utils.BigIntToBytes(sk.D))
 	if userID == nil {
 		userID = sm2SignDefaultUserID
 	}
-	fmt.Printf("userID:%x\nmsg:%x\npubX:%x\npubY:%x\n",
-		userID, msg, pubX.Bytes(), pubY.Bytes())
 	e := genE(digest, &sk.Curve, pubX, pubY, userID, msg)
-	fmt.Printf("e:%x\n", e.Bytes())
+	return sk.signE(randomK(random, sk.Curve.N), e)
+}
+
+// randomK 从 random 读取随机数 k
+func randomK(random io.Reader, upper *big.Int) func() (*big.Int, error) {
+	return func() (*big.Int, error) {
+		return pickK(random, upper)
+	}
+}
 
+// signE 以杂凑值 e 生成签名，nextK 每次调用返回一个新的 k ∈ [1, n-1]
+func (sk *PrivKey) signE(nextK func() (*big.Int, error), e *big.Int) (r, s *big.Int, err error) {
 	bigIntZero := utils.NewBigIntFromZero()
 	bigIntOne := utils.NewBigIntFromOne()
 	for {
 		var k *big.Int
 		var err error
 		for {
-			k, err = pickK(rand.Reader, sk.Curve.N)
+			k, err = nextK()
 			if err != nil {
 				return nil, nil, err
 			}
@@ -235,13 +240,17 @@ func (sk *PrivKey) SignToBigInt(userID []byte, msg []byte) (r, s *big.Int, err e
 			}
 		}
 
-		dPlus1ModN := utils.BigIntAdd(sk.D, bigIntOne)
-		dPlus1ModN = utils.BigIntModInverse(dPlus1ModN, sk.Curve.N)
-		s = utils.BigIntMul(r, sk.D)
-		s = utils.BigIntSub(k, s)
-		s = utils.BigIntMod(s, sk.Curve.N)
-		s = utils.BigIntMul(dPlus1ModN, s)
-		s = utils.BigIntMod(s, sk.Curve.N)
+		if sk.Curve.p256 {
+			s = p256SignS(sk.D, k, r)
+		} else {
+			dPlus1ModN := utils.BigIntAdd(sk.D, bigIntOne)
+			dPlus1ModN = utils.BigIntModInverse(dPlus1ModN, sk.Curve.N)
+			s = utils.BigIntMul(r, sk.D)
+			s = utils.BigIntSub(k, s)
+			s = utils.BigIntMod(s, sk.Curve.N)
+			s = utils.BigIntMul(dPlus1ModN, s)
+			s = utils.BigIntMod(s, sk.Curve.N)
+		}
 
 		if s.Cmp(bigIntZero) != 0 {
 			break
@@ -251,71 +260,115 @@ func (sk *PrivKey) SignToBigInt(userID []byte, msg []byte) (r, s *big.Int, err e
 	return r, s, nil
 }
 
-func (pk *PubKey) VerifyFromBigInt(userID []byte, msg []byte, r, s *big.Int) bool {
-	bigIntOne := utils.NewBigIntFromOne()
-	if r.Cmp(bigIntOne) == -1 || r.Cmp(pk.Curve.N) >= 0 {
-		return false
-	}
-	if s.Cmp(bigIntOne) == -1 || s.Cmp(pk.Curve.N) >= 0 {
-		return false
-	}
+// VerifyFromBigInt 验证签名，userID 为空时使用默认值，hashFunc 可选，缺省为 SM3
+func (pk *PubKey) VerifyFromBigInt(userID []byte, msg []byte, r, s *big.Int, hashFunc ...func() hash.Hash) bool {
+	return pk.CheckSignatureFromBigInt(userID, msg, r, s, hashFunc...) == nil
+}
 
-	digest := sm3.New()
+// CheckSignatureFromBigInt 验证签名，签名无效时返回 ErrInvalidSignatureEncoding
+// 或 ErrVerificationFailed
+func (pk *PubKey) CheckSignatureFromBigInt(userID []byte, msg []byte, r, s *big.Int, hashFunc ...func() hash.Hash) error {
+	digest := hashOrDefault(hashFunc)()
 	if userID == nil {
 		userID = sm2SignDefaultUserID
 	}
 	e := genE(digest, &pk.Curve, pk.X, pk.Y, userID, msg)
+	return pk.verifyE(e, r, s)
+}
+
+// verifyE 以杂凑值 e 验证签名
+func (pk *PubKey) verifyE(e, r, s *big.Int) error {
+	return verifyE(pk.Curve.N, e, r, s, func(sBytes, tBytes []byte) (*big.Int, *big.Int) {
+		return pk.Curve.combinedMult(pk.X, pk.Y, sBytes, tBytes)
+	})
+}
+
+// verifyE GB/T 32918.2-2016 7.1 B1、B2 和 B5-B7，mult 计算 [s]G + [t]P
+func verifyE(n, e, r, s *big.Int, mult func(s, t []byte) (*big.Int, *big.Int)) error {
+	if r == nil || s == nil {
+		return ErrInvalidSignatureEncoding
+	}
+	bigIntOne := utils.NewBigIntFromOne()
+	if r.Cmp(bigIntOne) == -1 || r.Cmp(n) >= 0 {
+		return ErrVerificationFailed
+	}
+	if s.Cmp(bigIntOne) == -1 || s.Cmp(n) >= 0 {
+		return ErrVerificationFailed
+	}
 
 	intZero := utils.NewBigIntFromZero()
 	t := utils.BigIntAdd(r, s)
-	t = utils.BigIntMod(t, pk.Curve.N)
+	t = utils.BigIntMod(t, n)
 	if t.Cmp(intZero) == 0 {
-		return false
+		return ErrVerificationFailed
 	}
 
-	sgx, sgy := pk.Curve.ScalarBaseMult(s.Bytes())
-	tpx, tpy := pk.Curve.ScalarMult(pk.X, pk.Y, t.Bytes())
-	x, y := pk.Curve.Add(sgx, sgy, tpx, tpy)
+	x, y := mult(s.Bytes(), t.Bytes())
 	if IsPointInfinity(x, y) {
-		return false
+		return ErrVerificationFailed
 	}
 
 	expectedR := utils.BigIntAdd(e, x)
-	expectedR = utils.BigIntMod(expectedR, pk.Curve.N)
-	return expectedR.Cmp(r) == 0
+	expectedR = utils.BigIntMod(expectedR, n)
+	if expectedR.Cmp(r) != 0 {
+		return ErrVerificationFailed
+	}
+	return nil
+}
+
+// VerifyFromASN1DER 验证签名，hashFunc 可选，缺省为 SM3
+func (pk *PubKey) VerifyFromASN1DER(userID []byte, msg []byte, sigBytes []byte, hashFunc ...func() hash.Hash) bool {
+	return pk.CheckSignature(userID, msg, sigBytes, hashFunc...) == nil
 }
 
-// VerifyFromASN1DER 验证签名
-func (pk *PubKey) VerifyFromASN1DER(userID []byte, msg []byte, sigBytes []byte) bool {
-	sig := new(Signature)
-	_, err := asn1.Unmarshal(sigBytes, sig)
+// CheckSignature 验证 ASN.1 DER 编码的签名，返回签名无效的原因
+func (pk *PubKey) CheckSignature(userID []byte, msg []byte, sigBytes []byte, hashFunc ...func() hash.Hash) error {
+	r, s, err := parseSignature(sigBytes)
 	if err != nil {
-		fmt.Errorf("%s", err)
-		return false
+		return err
 	}
-	return pk.VerifyFromBigInt(userID, msg, sig.R, sig.S)
+	return pk.CheckSignatureFromBigInt(userID, msg, r, s, hashFunc...)
 }
 
 // -----------------------------------------------------------------------------
 // ietf/draft-shen-sm2-ecdsa-02 6 密钥交换协议
 // -----------------------------------------------------------------------------
 
-func kdf(hashProvider hash.Hash, zX, zY *big.Int, msg []byte) {
+// kdfKey GB/T 32918.3-2016 5.4.3 密钥派生函数 KDF(Z, klen)，klen 以字节为单位
+func kdfKey(z []byte, klen int) []byte {
+	digest := sm3.New()
+	out := make([]byte, 0, klen+digest.Size())
+	var ct [4]byte
+	for i := uint32(1); len(out) < klen; i++ {
+		digest.Reset()
+		digest.Write(z)
+		binary.BigEndian.PutUint32(ct[:], i)
+		digest.Write(ct[:])
+		out = digest.Sum(out)
+	}
+	return out[:klen]
+}
+
+// kdf GB/T 32918.4-2016 6.1 A5 t = KDF(x2 || y2, klen)，并与 msg 异或，x2 和 y2
+// 为定长字节串
+//
+// 按 GB/T 32918.3-2016 5.4.3，每个计数器取杂凑值的全部 v 比特，即 Size()。早期
+// 版本使用 BlockSize()（当时 SM3 错误地返回 16），每个计数器只取 16 字节，生成的
+// 密文与标准和其他实现都不兼容，超过 16 字节的明文也不能由早期版本解密
+func kdf(hashProvider hash.Hash, x2, y2 []byte, msg []byte) {
 	bufSize := 4
-	if bufSize < hashProvider.BlockSize() {
-		bufSize = hashProvider.BlockSize()
+	if bufSize < hashProvider.Size() {
+		bufSize = hashProvider.Size()
 	}
 	buf := make([]byte, bufSize)
 
 	msgLen := len(msg)
-	zXBytes := zX.Bytes()
-	zYBytes := zY.Bytes()
 	offset := 0
 	roundCount := uint32(0)
 	for offset < msgLen {
 		hashProvider.Reset()
-		hashProvider.Write(zXBytes)
-		hashProvider.Write(zYBytes)
+		hashProvider.Write(x2)
+		hashProvider.Write(y2)
 		roundCount++
 		binary.BigEndian.PutUint32(buf, roundCount)
 		hashProvider.Write(buf[:4])
@@ -323,8 +376,8 @@ func kdf(hashProvider hash.Hash, zX, zY *big.Int, msg []byte) {
 		copy(buf[:bufSize], tmp[:bufSize])
 
 		xorLen := msgLen - offset
-		if xorLen > hashProvider.BlockSize() {
-			xorLen = hashProvider.BlockSize()
+		if xorLen > hashProvider.Size() {
+			xorLen = hashProvider.Size()
 		}
 		utils.BytesXor(msg[offset:], buf, xorLen)
 		offset += xorLen
@@ -345,136 +398,116 @@ func isNotEncrypted(encData []byte, in []byte) bool {
 	return true
 }
 
+// Encrypt 用公钥加密，输出 C1C2C3 格式的密文。与 GB/T 32918.4-2016 一致的
+// C1C3C2 格式使用 EncryptWithMode
 func (pk *PubKey) Encrypt(msg []byte) ([]byte, error) {
+	return pk.EncryptWithMode(rand.Reader, msg, C1C2C3)
+}
+
+// EncryptWithMode GB/T 32918.4-2016 6.1 加密算法，mode 指定密文格式
+func (pk *PubKey) EncryptWithMode(random io.Reader, msg []byte, mode CiphertextMode) ([]byte, error) {
+	if !mode.valid() {
+		return nil, ErrInvalidCiphertextMode
+	}
+	// klen = 0 时 t 恒为全 0，A5 无法结束
+	if len(msg) == 0 {
+		return nil, ErrEmptyPlaintext
+	}
 	c2 := make([]byte, len(msg))
-	copy(c2, msg)
-	var c1 []byte
+	var c1, x2, y2 []byte
 	digest := sm3.New()
-	var kPBx, kPBy *big.Int
 	for {
-		k, err := pickK(rand.Reader, pk.Curve.N)
+		// A1 - A3 C1 = [k]G, (x2, y2) = [k]PB
+		k, err := pickK(random, pk.Curve.N)
 		if err != nil {
 			return nil, err
 		}
 		kBytes := k.Bytes()
 		c1x, c1y := pk.Curve.ScalarBaseMult(kBytes)
-		c1 = elliptic.Marshal(pk.Curve, c1x, c1y)
-		kPBx, kPBy = pk.Curve.ScalarMult(pk.X, pk.Y, kBytes)
-		kdf(digest, kPBx, kPBy, c2)
+		c1 = pk.Curve.marshalUncompressed(c1x, c1y)
+		kPBx, kPBy := pk.Curve.ScalarMult(pk.X, pk.Y, kBytes)
+		x2, y2 = pk.Curve.fieldBytes(kPBx), pk.Curve.fieldBytes(kPBy)
 
+		// A5 - A6 C2 = M ^ KDF(x2 || y2, klen)，t 全为 0 时重新选择 k
+		copy(c2, msg)
+		kdf(digest, x2, y2, c2)
 		if !isNotEncrypted(c2, msg) {
 			break
 		}
 	}
 
+	// A7 C3 = Hash(x2 || M || y2)
 	digest.Reset()
-	digest.Write(kPBx.Bytes())
+	digest.Write(x2)
 	digest.Write(msg)
-	digest.Write(kPBy.Bytes())
+	digest.Write(y2)
 	c3 := digest.Sum(nil)
 
-	c1Len := len(c1)
-	c2Len := len(c2)
-	c3Len := len(c3)
-	result := make([]byte, c1Len+c2Len+c3Len)
-	copy(result[:c1Len], c1)
-	copy(result[c1Len:c1Len+c2Len], c2)
-	copy(result[c1Len+c2Len:], c3)
-	return result, nil
-
+	return joinCiphertext(c1, c2, c3, mode), nil
 }
 
-func (sk *PrivKey) Decrypt(in []byte) ([]byte, error) {
-	c1Len := ((sk.Curve.BitSize+7)/8)*2 + 1
-	c1 := make([]byte, c1Len)
-	copy(c1, in[:c1Len])
-	c1x, c1y := elliptic.Unmarshal(sk.Curve, c1)
-	sx, sy := sk.Curve.ScalarMult(c1x, c1y, sm2H.Bytes())
-	if IsPointInfinity(sx, sy) {
-		return nil, errors.New("[h]C1 at infinity")
-	}
-	c1x, c1y = sk.Curve.ScalarMult(c1x, c1y, sk.D.Bytes())
-
-	digest := sm3.New()
-	c3Len := digest.Size()
-	c2Len := len(in) - c1Len - c3Len
-	c2 := make([]byte, c2Len)
-	copy(c2, in[c1Len:c1Len+c2Len])
-	kdf(digest, c1x, c1y, c2)
-
-	digest.Reset()
-	digest.Write(c1x.Bytes())
-	digest.Write(c2)
-	digest.Write(c1y.Bytes())
-	c3 := digest.Sum(nil)
-
-	if !bytes.Equal(c3, in[c1Len+c2Len:]) {
-		return nil, errors.New("invalid cipher text")
+// DecryptWithMode GB/T 32918.4-2016 7.1 解密算法，mode 指定密文格式，C1 可以是
+// 压缩、未压缩或混合形式
+func (sk *PrivKey) DecryptWithMode(in []byte, mode CiphertextMode) ([]byte, error) {
+	c1x, c1y, err := sk.Curve.CiphertextC1(in, mode)
+	if err != nil {
+		return nil, err
 	}
-	return c2, nil
+	// B3 (x2, y2) = [dB]C1
+	x2, y2 := sk.Curve.ScalarMult(c1x, c1y, sk.D.Bytes())
+	return sk.Curve.DecryptWithPoint(in, mode, x2, y2)
 }
 
-func MarshalEncryptedToASN1DER(in []byte) ([]byte, error) {
-	paramLen := utils.BitsLenToBytesLen(sm2P256.Params().BitSize)
-
-	c1x := make([]byte, paramLen)
-	c1y := make([]byte, paramLen)
-	c2Len := len(in) - (1 + paramLen*2) - sm3.DigestSizeInByte
-	c2 := make([]byte, c2Len)
-	c3 := make([]byte, sm3.DigestSizeInByte)
-	pos := 1
-
-	copy(c1x, in[pos:pos+paramLen])
-	pos += paramLen
-
-	copy(c1y, in[pos:pos+paramLen])
-	pos += paramLen
-
-	copy(c2, in[pos:pos+c2Len])
-	pos += c2Len
-
-	copy(c3, in[pos:pos+sm3.DigestSizeInByte])
-
-	nc1x := utils.NewBigIntFromBytes(c1x)
-	nc1y := utils.NewBigIntFromBytes(c1y)
-	result, err := asn1.Marshal(ASN1DERCipher{nc1x, nc1y, c3, c2})
+// CiphertextC1 解密算法 B1 - B2，取出 C1 并验证在曲线上且 [h]C1 不是无穷远点。
+// 私钥不在一处时（如两方协同解密）由调用者计算 [dB]C1，再调用 DecryptWithPoint
+func (c Curve) CiphertextC1(in []byte, mode CiphertextMode) (x, y *big.Int, err error) {
+	c1, _, _, err := c.splitCiphertext(in, mode)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	// B1 取出 C1 并验证在曲线上
+	c1x, c1y, err := c.unmarshalPoint(c1)
+	if err != nil {
+		return nil, nil, err
+	}
+	// B2 S = [h]C1，推荐曲线 h = 1，S 就是 C1
+	sx, sy := c1x, c1y
+	if sm2H.Cmp(utils.NewBigIntFromOne()) != 0 {
+		sx, sy = c.ScalarMult(c1x, c1y, sm2H.Bytes())
+	}
+	if IsPointInfinity(sx, sy) {
+		return nil, nil, ErrInvalidCiphertext
 	}
-	return result, nil
+	return c1x, c1y, nil
 }
 
-func UnmarshalEncryptedFromASN1DER(in []byte) ([]byte, error) {
-	encrypted := new(ASN1DERCipher)
-	_, err := asn1.Unmarshal(in, encrypted)
+// DecryptWithPoint 解密算法 B4 - B6，(x2, y2) = [dB]C1，C3 校验失败时返回
+// ErrInvalidCiphertext
+func (c Curve) DecryptWithPoint(in []byte, mode CiphertextMode, x2, y2 *big.Int) ([]byte, error) {
+	_, c2, c3, err := c.splitCiphertext(in, mode)
 	if err != nil {
 		return nil, err
 	}
+	if x2 == nil || y2 == nil || IsPointInfinity(x2, y2) {
+		return nil, ErrInvalidCiphertext
+	}
+	x2Bytes, y2Bytes := c.fieldBytes(x2), c.fieldBytes(y2)
 
-	c1x := utils.BigIntToBytes(encrypted.X)
-	c1y := utils.BigIntToBytes(encrypted.Y)
-	c1xLen := len(c1x)
-	c1yLen := len(c1y)
-	c2Len := len(encrypted.C2)
-	c3Len := len(encrypted.C3)
-	result := make([]byte, 1+c1xLen+c1yLen+c2Len+c3Len)
-	pos := 0
-
-	result[pos] = UnCompressed
-	pos++
-
-	copy(result[pos:pos+c1xLen], c1x)
-	pos += c1xLen
-
-	copy(result[pos:pos+c1yLen], c1y)
-	pos += c1yLen
-
-	copy(result[pos:pos+c2Len], encrypted.C2)
-	pos += c2Len
-
-	copy(result[pos:pos+c3Len], encrypted.C3)
+	// B4 - B5 M' = C2 ^ KDF(x2 || y2, klen)
+	digest := sm3.New()
+	msg := make([]byte, len(c2))
+	copy(msg, c2)
+	kdf(digest, x2Bytes, y2Bytes, msg)
 
-	return result, nil
+	// B6 u = Hash(x2 || M' || y2)
+	digest.Reset()
+	digest.Write(x2Bytes)
+	digest.Write(msg)
+	digest.Write(y2Bytes)
+	if subtle.ConstantTimeCompare(digest.Sum(nil), c3) != 1 {
+		return nil, ErrInvalidCiphertext
+	}
+	return msg, nil
 }
 
 // -----------------------------------------------------------------------------
@@ -3,9 +3,13 @@ package sm2
 import (
 	"bytes"
 	"crypto/rand"
+	"encoding/asn1"
+	"encoding/hex"
 	"fmt"
+	"math/big"
 	"testing"
 
+	"github.com/t1anchen/gogmlib/sm3"
 	"github.com/t1anchen/gogmlib/utils"
 )
 
@@ -19,7 +23,7 @@ func TestSign(t *testing.T) {
 		D:     utils.NewBigIntFromHexString("5DD701828C424B84C5D56770ECF7C4FE882E654CAC53C7CC89A66B1709068B9D"),
 		Curve: GetSm2P256()}
 	t.Logf("priv.D = %x", sk.D)
-	sig, err := sk.Sign(nil, utils.HexStringToBytes("0102030405060708010203040506070801020304050607080102030405060708"))
+	sig, err := sk.Sign(rand.Reader, utils.HexStringToBytes("0102030405060708010203040506070801020304050607080102030405060708"), nil)
 	if err != nil {
 		t.Errorf("TestSign:Sign:err = %s\n", err)
 		return
@@ -80,7 +84,7 @@ func TestEncrypt(t *testing.T) {
 	}
 	fmt.Printf("cipher text:%x\n", encrypted)
 
-	plain, err := sk.Decrypt(encrypted)
+	plain, err := sk.Decrypt(nil, encrypted, nil)
 	if err != nil {
 		t.Error(err.Error())
 		return
@@ -125,7 +129,7 @@ func TestEncryptWithASN1DER(t *testing.T) {
 		return
 	}
 
-	plainText, err := sk.Decrypt(cipherText)
+	plainText, err := sk.Decrypt(nil, cipherText, nil)
 	if err != nil {
 		t.Error(err.Error())
 		return
@@ -137,3 +141,70 @@ func TestEncryptWithASN1DER(t *testing.T) {
 		return
 	}
 }
+
+func TestCheckSignature(t *testing.T) {
+	sk, pk, _ := GenKey(rand.Reader)
+	msg := []byte("check signature")
+	r, s, err := sk.SignToBigInt(nil, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _ := asn1.Marshal(Signature{r, s})
+	if err := pk.CheckSignature(nil, msg, sig); err != nil {
+		t.Fatalf("TestCheckSignature 失败: %v", err)
+	}
+
+	cases := []struct {
+		sig []byte
+		err error
+	}{
+		{append(append([]byte(nil), sig...), 0), ErrInvalidSignatureEncoding},
+		{sig[:len(sig)-1], ErrInvalidSignatureEncoding},
+		{nil, ErrInvalidSignatureEncoding},
+	}
+	for i, c := range cases {
+		if err := pk.CheckSignature(nil, msg, c.sig); err != c.err {
+			t.Errorf("TestCheckSignature %d 失败\n期望值=%v\n实际值=%v", i, c.err, err)
+		}
+	}
+
+	n := pk.Curve.N
+	for i, rs := range [][2]*big.Int{
+		{nil, s},
+		{r, nil},
+	} {
+		if err := pk.CheckSignatureFromBigInt(nil, msg, rs[0], rs[1]); err != ErrInvalidSignatureEncoding {
+			t.Errorf("TestCheckSignature nil %d 失败: err=%v", i, err)
+		}
+	}
+	for i, rs := range [][2]*big.Int{
+		{big.NewInt(0), s},
+		{r, n},
+		{new(big.Int).Neg(r), s},
+		{r, new(big.Int).Sub(n, r)},
+	} {
+		if err := pk.CheckSignatureFromBigInt(nil, msg, rs[0], rs[1]); err != ErrVerificationFailed {
+			t.Errorf("TestCheckSignature 范围 %d 失败: err=%v", i, err)
+		}
+	}
+	if err := pk.CheckSignature(nil, []byte("other"), sig); err != ErrVerificationFailed {
+		t.Errorf("TestCheckSignature 失败: 篡改消息 err=%v", err)
+	}
+}
+
+// TestKDF 93 字节的密钥流跨 3 个计数器，期望值由 Python 的 hashlib.new("sm3") 按
+// GB/T 32918.3-2016 5.4.3 逐个计数器拼接 SM3(x2 || y2 || ct) 计算
+func TestKDF(t *testing.T) {
+	x2, _ := hex.DecodeString("64D20D27D0632957F8028C1E024F6B02EDF23102A566C932AE8BD613A8E865FE")
+	y2, _ := hex.DecodeString("58D225ECA784AE300A81A2D48281A828E1CEDF11C4219099840265375077BF78")
+	expected := "006e30dae231b071dfad8aa379e90264491603b93fc2d0b2f64c3021e23c6cc8" +
+		"3065830fea992082fb7a8caa831d149a49b9ff1a67ba3954abf530c363ad80ac" +
+		"a0c2654d18991bf1940afdae9e6370c2664c100468208019d5160a0c26"
+	stream := make([]byte, 93)
+	kdf(sm3.New(), x2, y2, stream)
+	if actual := hex.EncodeToString(stream); actual != expected {
+		t.Errorf(`TestKDF 失败
+期望值=%s
+实际值=%s`, expected, actual)
+	}
+}
@@ -0,0 +1,113 @@
+package twoparty
+
+import (
+	"io"
+	"math/big"
+
+	"github.com/t1anchen/gogmlib/sm2"
+)
+
+// -----------------------------------------------------------------------------
+// 协同解密
+//
+//	客户端：验证 C1，ρ ∈ [1, n-1]，T1 = [ρ · d1^-1]C1              --T1-->
+//	服务端：T2 = [d2^-1]T1                                         <--T2--
+//	客户端：(x2, y2) = [ρ^-1]T2 - C1 = [(d1 · d2)^-1 - 1]C1 = [d]C1
+//
+// 然后按 GB/T 32918.4-2016 7.1 B4 - B6 计算明文并校验 C3。密文由普通的
+// PubKey.Encrypt 生成。ρ 使服务端即使见过密文也不能由 T2 自行算出 [d]C1。
+//
+// 双方收到的点都按 GB/T 32918.1-2016 6.2.1 验证后才与自己的秘密相乘，对方发送
+// 不在曲线上或阶不为 n 的点时返回 ErrInvalidPoint，不会泄露 d1 或 d2
+// -----------------------------------------------------------------------------
+
+// DecryptRequest 客户端发给服务端的解密请求
+type DecryptRequest struct {
+	T1 []byte `json:"t1"`
+}
+
+// DecryptResponse 服务端返回的部分解密结果
+type DecryptResponse struct {
+	T2 []byte `json:"t2"`
+}
+
+// DecryptSession 客户端一次解密的状态，只能使用一次
+type DecryptSession struct {
+	key      *ClientKey
+	in       []byte
+	mode     sm2.CiphertextMode
+	c1x, c1y *big.Int
+	rho      *big.Int
+}
+
+// DecryptInit 客户端开始解密 mode 格式的密文，random 为 nil 时使用
+// crypto/rand.Reader
+func (k *ClientKey) DecryptInit(random io.Reader, in []byte, mode sm2.CiphertextMode) (*DecryptSession, *DecryptRequest, error) {
+	if err := validateShare(k.D, k.PubKey); err != nil {
+		return nil, nil, err
+	}
+	curve := k.PubKey.Curve
+	c1x, c1y, err := curve.CiphertextC1(in, mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	rho, err := randScalar(random, curve.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	// T1 = [ρ · d1^-1]C1
+	t := new(big.Int).ModInverse(k.D, curve.N)
+	t.Mul(t, rho)
+	t.Mod(t, curve.N)
+	x, y := curve.ScalarMult(c1x, c1y, t.Bytes())
+	session := &DecryptSession{
+		key:  k,
+		in:   append([]byte(nil), in...),
+		mode: mode,
+		c1x:  c1x,
+		c1y:  c1y,
+		rho:  rho,
+	}
+	return session, &DecryptRequest{T1: marshalPoint(x, y)}, nil
+}
+
+// Decrypt 服务端计算部分解密结果
+func (k *ServerKey) Decrypt(req *DecryptRequest) (*DecryptResponse, error) {
+	if err := validateShare(k.D, k.PubKey); err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, ErrInvalidPoint
+	}
+	t1, err := parsePoint(req.T1)
+	if err != nil {
+		return nil, err
+	}
+	curve := k.PubKey.Curve
+	inv := new(big.Int).ModInverse(k.D, curve.N)
+	x, y := curve.ScalarMult(t1.X, t1.Y, inv.Bytes())
+	return &DecryptResponse{T2: marshalPoint(x, y)}, nil
+}
+
+// Finish 客户端由服务端的部分解密结果计算明文，C3 校验失败时返回
+// sm2.ErrInvalidCiphertext
+func (s *DecryptSession) Finish(resp *DecryptResponse) ([]byte, error) {
+	if s.rho == nil {
+		return nil, ErrSessionUsed
+	}
+	rho := s.rho
+	s.rho = nil
+	if resp == nil {
+		return nil, ErrInvalidPoint
+	}
+	t2, err := parsePoint(resp.T2)
+	if err != nil {
+		return nil, err
+	}
+	curve := s.key.PubKey.Curve
+	// (x2, y2) = [ρ^-1]T2 - C1
+	inv := new(big.Int).ModInverse(rho, curve.N)
+	x, y := curve.ScalarMult(t2.X, t2.Y, inv.Bytes())
+	x2, y2 := curve.Add(x, y, s.c1x, new(big.Int).Sub(curve.P, s.c1y))
+	return curve.DecryptWithPoint(s.in, s.mode, x2, y2)
+}
@@ -0,0 +1,107 @@
+package twoparty
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/sm2"
+)
+
+// coDecrypt 在进程内运行一次协同解密
+func coDecrypt(t *testing.T, client *ClientKey, server *ServerKey, in []byte, mode sm2.CiphertextMode) ([]byte, error) {
+	t.Helper()
+	session, req, err := client.DecryptInit(nil, in, mode)
+	if err != nil {
+		return nil, err
+	}
+	var serverReq DecryptRequest
+	transfer(t, req, &serverReq)
+	resp, err := server.Decrypt(&serverReq)
+	if err != nil {
+		return nil, err
+	}
+	var clientResp DecryptResponse
+	transfer(t, resp, &clientResp)
+	return session.Finish(&clientResp)
+}
+
+func TestCoDecrypt(t *testing.T) {
+	client, server := newKeyPair(t)
+	for _, mode := range []sm2.CiphertextMode{sm2.C1C2C3, sm2.C1C3C2} {
+		for _, n := range []int{1, 32, 33, 100} {
+			msg := make([]byte, n)
+			rand.Read(msg)
+			in, err := client.PubKey.EncryptWithMode(rand.Reader, msg, mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := coDecrypt(t, client, server, in, mode)
+			if err != nil {
+				t.Fatalf("TestCoDecrypt %v 失败: %v", mode, err)
+			}
+			if !bytes.Equal(out, msg) {
+				t.Errorf("TestCoDecrypt %v 失败\n期望值=%x\n实际值=%x", mode, msg, out)
+			}
+		}
+	}
+}
+
+func TestCoDecryptInvalidCiphertext(t *testing.T) {
+	client, server := newKeyPair(t)
+	in, err := client.PubKey.Encrypt([]byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// C3 被篡改
+	tampered := append([]byte(nil), in...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := coDecrypt(t, client, server, tampered, sm2.C1C2C3); err != sm2.ErrInvalidCiphertext {
+		t.Errorf("TestCoDecryptInvalidCiphertext 失败: C3 被篡改时 err=%v", err)
+	}
+	// C1 不在曲线上
+	tampered = append([]byte(nil), in...)
+	tampered[64] ^= 1
+	if _, _, err := client.DecryptInit(nil, tampered, sm2.C1C2C3); err != sm2.ErrPointNotOnCurve {
+		t.Errorf("TestCoDecryptInvalidCiphertext 失败: C1 不在曲线上时 err=%v", err)
+	}
+}
+
+func TestCoDecryptInvalidPoint(t *testing.T) {
+	client, server := newKeyPair(t)
+	in, err := client.PubKey.Encrypt([]byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	curve := sm2.GetSm2P256()
+	notOnCurve := marshalPoint(curve.Gx, new(big.Int).Add(curve.Gy, big.NewInt(1)))
+	// 恶意客户端发送无效点
+	for _, t1 := range [][]byte{nil, {0x00}, notOnCurve} {
+		if _, err := server.Decrypt(&DecryptRequest{T1: t1}); err != ErrInvalidPoint {
+			t.Errorf("TestCoDecryptInvalidPoint 失败: T1=%x err=%v", t1, err)
+		}
+	}
+	// 恶意服务端返回无效点
+	for _, t2 := range [][]byte{nil, {0x00}, notOnCurve} {
+		session, _, err := client.DecryptInit(nil, in, sm2.C1C2C3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := session.Finish(&DecryptResponse{T2: t2}); err != ErrInvalidPoint {
+			t.Errorf("TestCoDecryptInvalidPoint 失败: T2=%x err=%v", t2, err)
+		}
+	}
+	// 服务端返回有效但错误的点
+	session, _, err := client.DecryptInit(nil, in, sm2.C1C2C3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := &DecryptResponse{T2: marshalPoint(curve.Gx, curve.Gy)}
+	if _, err := session.Finish(resp); err != sm2.ErrInvalidCiphertext {
+		t.Errorf("TestCoDecryptInvalidPoint 失败: err=%v", err)
+	}
+	if _, err := session.Finish(resp); err != ErrSessionUsed {
+		t.Errorf("TestCoDecryptInvalidPoint 失败: 重复使用时 err=%v", err)
+	}
+}
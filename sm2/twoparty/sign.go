@@ -0,0 +1,174 @@
+package twoparty
+
+import (
+	"errors"
+	"hash"
+	"io"
+	"math/big"
+
+	"github.com/t1anchen/gogmlib/sm2"
+)
+
+// -----------------------------------------------------------------------------
+// 协同签名
+//
+//	客户端：e = H(Z || M)，k1 ∈ [1, n-1]，Q1 = [k1]G                --Q1, e-->
+//	服务端：k2, k3 ∈ [1, n-1]，(x1, y1) = [k3]Q1 + [k2]G
+//	        r = (e + x1) mod n，s2 = d2 · k3，s3 = d2 · (r + k2)   <--r, s2, s3--
+//	客户端：s = (d1 · k1) · s2 + d1 · s3 - r mod n
+//
+// 记 k = k1 · k3 + k2，则 s = (1 + d)^-1 · (k + r) - r = (1 + d)^-1 · (k - r · d)，
+// 与 GB/T 32918.2-2016 6.1 A6 相同。客户端最后用联合公钥验证签名，服务端返回
+// 错误的 r、s2、s3 时签名不会输出
+// -----------------------------------------------------------------------------
+
+var ErrInvalidSignResponse = errors.New("twoparty: server response does not yield a valid signature")
+
+// SignRequest 客户端发给服务端的签名请求，E 为待签名消息的杂凑值。服务端需要审计
+// 消息内容时，应当自行由消息计算 e 并与 E 比较
+type SignRequest struct {
+	Q1 []byte `json:"q1"`
+	E  []byte `json:"e"`
+}
+
+// SignResponse 服务端返回的部分签名
+type SignResponse struct {
+	R  []byte `json:"r"`
+	S2 []byte `json:"s2"`
+	S3 []byte `json:"s3"`
+}
+
+// SignSession 客户端一次签名的状态，只能使用一次
+type SignSession struct {
+	key *ClientKey
+	e   []byte
+	k1  *big.Int
+}
+
+// SignInit 客户端开始对 msg 签名，userID 为空时使用默认值，hashFunc 可选，
+// 缺省为 SM3，random 为 nil 时使用 crypto/rand.Reader
+func (k *ClientKey) SignInit(random io.Reader, userID []byte, msg []byte, hashFunc ...func() hash.Hash) (*SignSession, *SignRequest, error) {
+	if err := validateShare(k.D, k.PubKey); err != nil {
+		return nil, nil, err
+	}
+	var h func() hash.Hash
+	if len(hashFunc) > 0 {
+		h = hashFunc[0]
+	}
+	e := sm2.ComputeDigest(k.PubKey, userID, msg, h)
+	return k.SignDigestInit(random, e)
+}
+
+// SignDigestInit 客户端开始对已经计算好的杂凑值 e 签名
+func (k *ClientKey) SignDigestInit(random io.Reader, e []byte) (*SignSession, *SignRequest, error) {
+	if err := validateShare(k.D, k.PubKey); err != nil {
+		return nil, nil, err
+	}
+	if len(e) == 0 {
+		return nil, nil, sm2.ErrInvalidDigestLength
+	}
+	curve := k.PubKey.Curve
+	k1, err := randScalar(random, curve.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	x, y := curve.ScalarBaseMult(k1.Bytes())
+	e = append([]byte(nil), e...)
+	session := &SignSession{key: k, e: e, k1: k1}
+	req := &SignRequest{Q1: marshalPoint(x, y), E: append([]byte(nil), e...)}
+	return session, req, nil
+}
+
+// Sign 服务端计算部分签名
+func (k *ServerKey) Sign(random io.Reader, req *SignRequest) (*SignResponse, error) {
+	if err := validateShare(k.D, k.PubKey); err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, ErrInvalidPoint
+	}
+	if len(req.E) == 0 {
+		return nil, sm2.ErrInvalidDigestLength
+	}
+	q1, err := parsePoint(req.Q1)
+	if err != nil {
+		return nil, err
+	}
+	curve := k.PubKey.Curve
+	n := curve.N
+	e := new(big.Int).SetBytes(req.E)
+	for {
+		k2, err := randScalar(random, n)
+		if err != nil {
+			return nil, err
+		}
+		k3, err := randScalar(random, n)
+		if err != nil {
+			return nil, err
+		}
+		// (x1, y1) = [k3]Q1 + [k2]G
+		x, y := curve.ScalarMult(q1.X, q1.Y, k3.Bytes())
+		gx, gy := curve.ScalarBaseMult(k2.Bytes())
+		x1, _ := curve.Add(x, y, gx, gy)
+
+		r := new(big.Int).Add(e, x1)
+		r.Mod(r, n)
+		if r.Sign() == 0 {
+			continue
+		}
+		s2 := new(big.Int).Mul(k.D, k3)
+		s2.Mod(s2, n)
+		s3 := new(big.Int).Add(r, k2)
+		s3.Mul(s3, k.D)
+		s3.Mod(s3, n)
+		if s3.Sign() == 0 {
+			continue
+		}
+		return &SignResponse{R: marshalScalar(r), S2: marshalScalar(s2), S3: marshalScalar(s3)}, nil
+	}
+}
+
+// Finish 客户端由服务端的部分签名计算 ASN.1 DER 编码的签名，并用联合公钥验证
+func (s *SignSession) Finish(resp *SignResponse) ([]byte, error) {
+	if s.k1 == nil {
+		return nil, ErrSessionUsed
+	}
+	// 无论成功与否 k1 都只使用一次，重复使用会泄露 d1
+	k1 := s.k1
+	s.k1 = nil
+	if resp == nil {
+		return nil, ErrInvalidScalar
+	}
+	pub := s.key.PubKey
+	n := pub.Curve.N
+	r, err := parseScalar(resp.R, n)
+	if err != nil {
+		return nil, err
+	}
+	s2, err := parseScalar(resp.S2, n)
+	if err != nil {
+		return nil, err
+	}
+	s3, err := parseScalar(resp.S3, n)
+	if err != nil {
+		return nil, err
+	}
+
+	// s = (d1 · k1) · s2 + d1 · s3 - r mod n
+	d1 := s.key.D
+	sig := new(big.Int).Mul(d1, k1)
+	sig.Mul(sig, s2)
+	t := new(big.Int).Mul(d1, s3)
+	sig.Add(sig, t)
+	sig.Sub(sig, r)
+	sig.Mod(sig, n)
+
+	der, err := (&sm2.Signature{R: r, S: sig}).MarshalDER()
+	if err != nil {
+		return nil, err
+	}
+	if pub.CheckDigestSignature(s.e, der) != nil {
+		return nil, ErrInvalidSignResponse
+	}
+	return der, nil
+}
@@ -0,0 +1,124 @@
+package twoparty
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+// coSign 在进程内运行一次协同签名
+func coSign(t *testing.T, client *ClientKey, server *ServerKey, userID, msg []byte) []byte {
+	t.Helper()
+	session, req, err := client.SignInit(nil, userID, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var serverReq SignRequest
+	transfer(t, req, &serverReq)
+	resp, err := server.Sign(nil, &serverReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var clientResp SignResponse
+	transfer(t, resp, &clientResp)
+	sig, err := session.Finish(&clientResp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func TestCoSign(t *testing.T) {
+	client, server := newKeyPair(t)
+	userID := []byte("ALICE123@YAHOO.COM")
+	for i := 0; i < 16; i++ {
+		msg := []byte{byte(i), 'm', 's', 'g'}
+		sig := coSign(t, client, server, userID, msg)
+		if !client.PubKey.Verify(userID, msg, sig) {
+			t.Fatalf("TestCoSign 失败: 第 %d 个签名未通过验证 sig=%x", i, sig)
+		}
+		if client.PubKey.Verify(nil, msg, sig) {
+			t.Fatalf("TestCoSign 失败: 用户身份标识不同时签名通过验证")
+		}
+	}
+}
+
+func TestCoSignHash(t *testing.T) {
+	client, server := newKeyPair(t)
+	msg := []byte("message digest")
+	session, req, err := client.SignInit(nil, nil, msg, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := server.Sign(nil, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := session.Finish(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !client.PubKey.Verify(nil, msg, sig, sha256.New) {
+		t.Errorf("TestCoSignHash 失败: sig=%x", sig)
+	}
+}
+
+func TestCoSignSessionUsed(t *testing.T) {
+	client, server := newKeyPair(t)
+	session, req, err := client.SignInit(nil, nil, []byte("msg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := server.Sign(nil, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Finish(resp); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Finish(resp); err != ErrSessionUsed {
+		t.Errorf("TestCoSignSessionUsed 失败: err=%v", err)
+	}
+}
+
+func TestCoSignInvalidResponse(t *testing.T) {
+	client, server := newKeyPair(t)
+	n := client.PubKey.Curve.N
+	tests := []struct {
+		name   string
+		modify func(resp *SignResponse)
+		err    error
+	}{
+		{"r 被篡改", func(resp *SignResponse) { resp.R[31] ^= 1 }, ErrInvalidSignResponse},
+		{"s2 被篡改", func(resp *SignResponse) { resp.S2[31] ^= 1 }, ErrInvalidSignResponse},
+		{"s3 被篡改", func(resp *SignResponse) { resp.S3[31] ^= 1 }, ErrInvalidSignResponse},
+		{"s2 为 0", func(resp *SignResponse) { resp.S2 = make([]byte, 32) }, ErrInvalidScalar},
+		{"s3 为 n", func(resp *SignResponse) { resp.S3 = marshalScalar(n) }, ErrInvalidScalar},
+		{"r 长度错误", func(resp *SignResponse) { resp.R = resp.R[1:] }, ErrInvalidScalar},
+	}
+	for _, tt := range tests {
+		session, req, err := client.SignInit(nil, nil, []byte("msg"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := server.Sign(nil, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.modify(resp)
+		if _, err := session.Finish(resp); err != tt.err {
+			t.Errorf("TestCoSignInvalidResponse %s 失败\n期望值=%v\n实际值=%v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func TestCoSignInvalidRequest(t *testing.T) {
+	client, server := newKeyPair(t)
+	_, req, err := client.SignInit(nil, nil, []byte("msg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Q1[64] ^= 1
+	if _, err := server.Sign(nil, req); err != ErrInvalidPoint {
+		t.Errorf("TestCoSignInvalidRequest 失败: err=%v", err)
+	}
+}
@@ -0,0 +1,313 @@
+// Package twoparty 实现 SM2 两方协同签名和协同解密：私钥 d 拆分为客户端（设备）
+// 持有的 d1 和服务端持有的 d2，满足 (1 + d)^-1 = d1 · d2 mod n，任何一方都不能
+// 单独签名或解密，生成的签名用普通的 sm2.PubKey 验证，解密的密文由普通的
+// sm2.PubKey 加密
+package twoparty
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/t1anchen/gogmlib/sm2"
+	"github.com/t1anchen/gogmlib/sm3"
+)
+
+// -----------------------------------------------------------------------------
+// 协同密钥生成
+//
+//	客户端：d1 ∈ [1, n-1]，P1 = [d1^-1]G                     --P1-->
+//	服务端：d2 ∈ [1, n-1]，P = [d2^-1]P1 - G，证明 π          <--P, π--
+//	客户端：验证 π
+//
+// P = [(d1 · d2)^-1 - 1]G，即私钥 d = (d1 · d2)^-1 - 1 对应的公钥，d 不在任何
+// 一方出现。服务端可以不按协议计算，直接返回自己知道私钥的 P，所以同时返回
+// Schnorr 证明 π，证明知道 w = d2^-1 满足 P + G = [w]P1：
+//
+//	K = [k]P1，c = SM3(tag || P1 || P || K) mod n，s = (k + c · w) mod n
+//
+// 客户端验证 [s]P1 = K + [c](P + G)。不知道 d1 时无法为自选的 P 构造 w，P 因此
+// 一定包含客户端的 d1。各消息的点使用未压缩形式，标量使用 32 字节大端定长编码，
+// 消息结构的字段都是字节串，可以直接用 encoding/json 等序列化后传递
+// -----------------------------------------------------------------------------
+
+var (
+	ErrInvalidPoint    = errors.New("twoparty: invalid point from peer")
+	ErrInvalidScalar   = errors.New("twoparty: invalid scalar from peer")
+	ErrInvalidKeyShare = errors.New("twoparty: invalid key share")
+	ErrKeyGenState     = errors.New("twoparty: key generation is not finished")
+	ErrSessionUsed     = errors.New("twoparty: session has already been used")
+	ErrInvalidProof    = errors.New("twoparty: invalid proof of key share")
+)
+
+// keyGenProofTag 密钥生成证明的域分隔标签
+var keyGenProofTag = []byte("SM2 two-party key generation")
+
+// KeyGenRequest 客户端发给服务端的密钥生成消息
+type KeyGenRequest struct {
+	P1 []byte `json:"p1"`
+}
+
+// KeyGenResponse 服务端返回的联合公钥和知道 d2^-1 的证明 (K, s)
+type KeyGenResponse struct {
+	PubKey []byte `json:"pub"`
+	ProofK []byte `json:"proof_k"`
+	ProofS []byte `json:"proof_s"`
+}
+
+// ClientKey 客户端持有的私钥分量 d1 和联合公钥，JSON 编码中公钥为未压缩形式
+// 的字节串
+type ClientKey struct {
+	D      *big.Int
+	PubKey *sm2.PubKey
+}
+
+// ServerKey 服务端持有的私钥分量 d2 和联合公钥，JSON 编码中公钥为未压缩形式
+// 的字节串
+type ServerKey struct {
+	D      *big.Int
+	PubKey *sm2.PubKey
+}
+
+// NewClientKey 客户端生成 d1 和发给服务端的 P1，收到响应后调用 FinishKeyGen，
+// random 为 nil 时使用 crypto/rand.Reader
+func NewClientKey(random io.Reader) (*ClientKey, *KeyGenRequest, error) {
+	curve := sm2.GetSm2P256()
+	d1, err := randScalar(random, curve.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	inv := new(big.Int).ModInverse(d1, curve.N)
+	x, y := curve.ScalarBaseMult(inv.Bytes())
+	return &ClientKey{D: d1}, &KeyGenRequest{P1: marshalPoint(x, y)}, nil
+}
+
+// FinishKeyGen 客户端验证服务端的证明后保存联合公钥，证明无效时返回
+// ErrInvalidProof
+func (k *ClientKey) FinishKeyGen(resp *KeyGenResponse) error {
+	if resp == nil {
+		return ErrInvalidPoint
+	}
+	pub, err := parsePoint(resp.PubKey)
+	if err != nil {
+		return err
+	}
+	if err := validateShare(k.D, pub); err != nil {
+		return err
+	}
+	proofK, err := parsePoint(resp.ProofK)
+	if err != nil {
+		return err
+	}
+	curve := pub.Curve
+	s, err := parseScalar(resp.ProofS, curve.N)
+	if err != nil {
+		return err
+	}
+
+	// [s]P1 = K + [c](P + G)
+	inv := new(big.Int).ModInverse(k.D, curve.N)
+	p1x, p1y := curve.ScalarBaseMult(inv.Bytes())
+	qx, qy := curve.Add(pub.X, pub.Y, curve.Gx, curve.Gy)
+	c := keyGenChallenge(curve, p1x, p1y, pub, proofK)
+	lx, ly := curve.ScalarMult(p1x, p1y, s.Bytes())
+	rx, ry := curve.ScalarMult(qx, qy, c.Bytes())
+	rx, ry = curve.Add(proofK.X, proofK.Y, rx, ry)
+	if lx.Cmp(rx) != 0 || ly.Cmp(ry) != 0 {
+		return ErrInvalidProof
+	}
+	k.PubKey = pub
+	return nil
+}
+
+// NewServerKey 服务端生成 d2，由 P1 计算联合公钥 P
+func NewServerKey(random io.Reader, req *KeyGenRequest) (*ServerKey, *KeyGenResponse, error) {
+	if req == nil {
+		return nil, nil, ErrInvalidPoint
+	}
+	p1, err := parsePoint(req.P1)
+	if err != nil {
+		return nil, nil, err
+	}
+	curve := sm2.GetSm2P256()
+	for {
+		d2, err := randScalar(random, curve.N)
+		if err != nil {
+			return nil, nil, err
+		}
+		inv := new(big.Int).ModInverse(d2, curve.N)
+		x, y := curve.ScalarMult(p1.X, p1.Y, inv.Bytes())
+		// P = [d2^-1]P1 + (-G)
+		negGy := new(big.Int).Sub(curve.P, curve.Gy)
+		x, y = curve.Add(x, y, curve.Gx, negGy)
+		pub := &sm2.PubKey{X: x, Y: y, Curve: curve}
+		// P = O 时 d = 0，P = -G 时 d = n - 1，都不在 [1, n-2] 内，重新选择 d2
+		if pub.Validate() != nil || (x.Cmp(curve.Gx) == 0 && y.Cmp(negGy) == 0) {
+			continue
+		}
+
+		// 证明知道 w = d2^-1 满足 P + G = [w]P1
+		k, err := randScalar(random, curve.N)
+		if err != nil {
+			return nil, nil, err
+		}
+		kx, ky := curve.ScalarMult(p1.X, p1.Y, k.Bytes())
+		proofK := &sm2.PubKey{X: kx, Y: ky, Curve: curve}
+		c := keyGenChallenge(curve, p1.X, p1.Y, pub, proofK)
+		s := c.Mul(c, inv)
+		s.Add(s, k)
+		s.Mod(s, curve.N)
+		if s.Sign() == 0 {
+			continue
+		}
+		return &ServerKey{D: d2, PubKey: pub}, &KeyGenResponse{
+			PubKey: pub.ToUncompressedBytes(),
+			ProofK: proofK.ToUncompressedBytes(),
+			ProofS: marshalScalar(s),
+		}, nil
+	}
+}
+
+// keyGenChallenge c = SM3(tag || P1 || P || K) mod n
+func keyGenChallenge(curve sm2.Curve, p1x, p1y *big.Int, pub, proofK *sm2.PubKey) *big.Int {
+	digest := sm3.New()
+	digest.Write(keyGenProofTag)
+	digest.Write(marshalPoint(p1x, p1y))
+	digest.Write(pub.ToUncompressedBytes())
+	digest.Write(proofK.ToUncompressedBytes())
+	c := new(big.Int).SetBytes(digest.Sum(nil))
+	return c.Mod(c, curve.N)
+}
+
+// -----------------------------------------------------------------------------
+// 密钥分量的 JSON 编码
+//
+// 联合公钥编码为未压缩形式的字节串，不包含曲线参数，解析时按推荐曲线验证
+// -----------------------------------------------------------------------------
+
+type clientKeyJSON struct {
+	D      *big.Int `json:"d1"`
+	PubKey []byte   `json:"pub,omitempty"`
+}
+
+type serverKeyJSON struct {
+	D      *big.Int `json:"d2"`
+	PubKey []byte   `json:"pub,omitempty"`
+}
+
+// MarshalJSON 编码为 {"d1": ..., "pub": ...}
+func (k *ClientKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(clientKeyJSON{D: k.D, PubKey: pubKeyBytes(k.PubKey)})
+}
+
+// UnmarshalJSON 解析 MarshalJSON 的输出
+func (k *ClientKey) UnmarshalJSON(data []byte) error {
+	var v clientKeyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	pub, err := parsePubKeyBytes(v.PubKey)
+	if err != nil {
+		return err
+	}
+	k.D, k.PubKey = v.D, pub
+	return nil
+}
+
+// MarshalJSON 编码为 {"d2": ..., "pub": ...}
+func (k *ServerKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(serverKeyJSON{D: k.D, PubKey: pubKeyBytes(k.PubKey)})
+}
+
+// UnmarshalJSON 解析 MarshalJSON 的输出
+func (k *ServerKey) UnmarshalJSON(data []byte) error {
+	var v serverKeyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	pub, err := parsePubKeyBytes(v.PubKey)
+	if err != nil {
+		return err
+	}
+	k.D, k.PubKey = v.D, pub
+	return nil
+}
+
+func pubKeyBytes(pub *sm2.PubKey) []byte {
+	if pub == nil {
+		return nil
+	}
+	return pub.ToUncompressedBytes()
+}
+
+// parsePubKeyBytes 密钥生成未完成时没有联合公钥
+func parsePubKeyBytes(data []byte) (*sm2.PubKey, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return parsePoint(data)
+}
+
+// validateShare 检查私钥分量在 [1, n-1] 内，且已经有联合公钥
+func validateShare(d *big.Int, pub *sm2.PubKey) error {
+	if pub == nil {
+		return ErrKeyGenState
+	}
+	n := pub.Curve.N
+	if d == nil || d.Sign() <= 0 || d.Cmp(n) >= 0 {
+		return ErrInvalidKeyShare
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// 编码和随机数
+// -----------------------------------------------------------------------------
+
+// randScalar 返回 [1, n-1] 内的随机数
+func randScalar(random io.Reader, n *big.Int) (*big.Int, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	k, err := rand.Int(random, new(big.Int).Sub(n, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return k.Add(k, big.NewInt(1)), nil
+}
+
+func marshalPoint(x, y *big.Int) []byte {
+	return (&sm2.PubKey{X: x, Y: y, Curve: sm2.GetSm2P256()}).ToUncompressedBytes()
+}
+
+// parsePoint 解析对方发来的点，并按 GB/T 32918.1-2016 6.2.1 验证，防止无效曲线
+// 攻击和小子群攻击
+func parsePoint(data []byte) (*sm2.PubKey, error) {
+	pub, err := sm2.ParsePubKey(data)
+	if err != nil {
+		return nil, ErrInvalidPoint
+	}
+	return pub, nil
+}
+
+// marshalScalar 32 字节大端定长编码
+func marshalScalar(k *big.Int) []byte {
+	out := make([]byte, sm2.KeyBytes)
+	b := k.Bytes()
+	copy(out[len(out)-len(b):], b)
+	return out
+}
+
+// parseScalar 解析 [1, n-1] 内的定长标量
+func parseScalar(data []byte, n *big.Int) (*big.Int, error) {
+	if len(data) != sm2.KeyBytes {
+		return nil, ErrInvalidScalar
+	}
+	k := new(big.Int).SetBytes(data)
+	if k.Sign() == 0 || k.Cmp(n) >= 0 {
+		return nil, ErrInvalidScalar
+	}
+	return k, nil
+}
@@ -0,0 +1,176 @@
+package twoparty
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/sm2"
+)
+
+// transfer 模拟网络传输，消息经过 JSON 序列化和反序列化
+func transfer(t *testing.T, in interface{}, out interface{}) {
+	t.Helper()
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newKeyPair 在进程内运行协同密钥生成
+func newKeyPair(t *testing.T) (*ClientKey, *ServerKey) {
+	t.Helper()
+	client, req, err := NewClientKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var serverReq KeyGenRequest
+	transfer(t, req, &serverReq)
+	server, resp, err := NewServerKey(nil, &serverReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var clientResp KeyGenResponse
+	transfer(t, resp, &clientResp)
+	if err := client.FinishKeyGen(&clientResp); err != nil {
+		t.Fatal(err)
+	}
+	return client, server
+}
+
+func TestKeyGen(t *testing.T) {
+	client, server := newKeyPair(t)
+	if !client.PubKey.Equal(server.PubKey) {
+		t.Fatalf("TestKeyGen 失败: 双方的联合公钥不同")
+	}
+	// d = (d1 · d2)^-1 - 1
+	n := client.PubKey.Curve.N
+	d := new(big.Int).Mul(client.D, server.D)
+	d.ModInverse(d, n)
+	d.Sub(d, big.NewInt(1))
+	sk := &sm2.PrivKey{D: d, Curve: client.PubKey.Curve}
+	if err := sk.Validate(); err != nil {
+		t.Fatalf("TestKeyGen 失败: %v", err)
+	}
+	if !sk.GenPubKey().Equal(client.PubKey) {
+		t.Errorf("TestKeyGen 失败\n期望值=%x\n实际值=%x",
+			sk.GenPubKey().ToUncompressedBytes(), client.PubKey.ToUncompressedBytes())
+	}
+}
+
+func TestKeyGenInvalidPoint(t *testing.T) {
+	curve := sm2.GetSm2P256()
+	notOnCurve := marshalPoint(curve.Gx, new(big.Int).Add(curve.Gy, big.NewInt(1)))
+	for _, p1 := range [][]byte{nil, {0x00}, notOnCurve, make([]byte, 65)} {
+		if _, _, err := NewServerKey(nil, &KeyGenRequest{P1: p1}); err != ErrInvalidPoint {
+			t.Errorf("TestKeyGenInvalidPoint 失败: P1=%x err=%v", p1, err)
+		}
+	}
+	client, _, err := NewClientKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.FinishKeyGen(&KeyGenResponse{PubKey: notOnCurve}); err != ErrInvalidPoint {
+		t.Errorf("TestKeyGenInvalidPoint 失败: err=%v", err)
+	}
+	if _, _, err := client.SignInit(nil, nil, []byte("msg")); err != ErrKeyGenState {
+		t.Errorf("TestKeyGenInvalidPoint 失败: 密钥生成未完成时 err=%v", err)
+	}
+}
+
+// TestKeyGenProof 服务端不能用自己知道私钥的公钥替换联合公钥
+func TestKeyGenProof(t *testing.T) {
+	client, req, err := NewClientKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, otherReq, _ := NewClientKey(nil)
+	_, resp, err := NewServerKey(nil, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherResp, _ := NewServerKey(nil, otherReq)
+
+	// 恶意服务端返回自己的公钥，证明以 G 为底
+	sk, pk, _ := sm2.GenKey(rand.Reader)
+	curve := sk.Curve
+	k, _ := randScalar(nil, curve.N)
+	kx, ky := curve.ScalarBaseMult(k.Bytes())
+	proofK := &sm2.PubKey{X: kx, Y: ky, Curve: curve}
+	p1, _ := parsePoint(req.P1)
+	c := keyGenChallenge(curve, p1.X, p1.Y, pk, proofK)
+	w := new(big.Int).Add(sk.D, big.NewInt(1))
+	s := c.Mul(c, w).Add(c, k)
+	s.Mod(s, curve.N)
+	forged := &KeyGenResponse{PubKey: pk.ToUncompressedBytes(), ProofK: proofK.ToUncompressedBytes(), ProofS: marshalScalar(s)}
+
+	tests := []struct {
+		name string
+		resp *KeyGenResponse
+		err  error
+	}{
+		{"伪造的公钥", forged, ErrInvalidProof},
+		{"其他客户端的响应", otherResp, ErrInvalidProof},
+		{"替换公钥", &KeyGenResponse{PubKey: pk.ToUncompressedBytes(), ProofK: resp.ProofK, ProofS: resp.ProofS}, ErrInvalidProof},
+		{"缺少证明", &KeyGenResponse{PubKey: resp.PubKey}, ErrInvalidPoint},
+		{"s 为零", &KeyGenResponse{PubKey: resp.PubKey, ProofK: resp.ProofK, ProofS: make([]byte, 32)}, ErrInvalidScalar},
+	}
+	for _, tt := range tests {
+		if err := client.FinishKeyGen(tt.resp); err != tt.err {
+			t.Errorf("TestKeyGenProof %s 失败\n期望值=%v\n实际值=%v", tt.name, tt.err, err)
+		}
+		if client.PubKey != nil {
+			t.Fatalf("TestKeyGenProof %s 失败: 保存了未通过验证的公钥", tt.name)
+		}
+	}
+	if err := client.FinishKeyGen(resp); err != nil {
+		t.Errorf("TestKeyGenProof 失败: %v", err)
+	}
+	if err := other.FinishKeyGen(otherResp); err != nil {
+		t.Errorf("TestKeyGenProof 失败: %v", err)
+	}
+}
+
+// TestKeyJSON 密钥分量的 JSON 编码只包含标量和未压缩公钥，解析后可以继续签名
+func TestKeyJSON(t *testing.T) {
+	client, server := newKeyPair(t)
+	data, err := json.Marshal(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]json.RawMessage
+	json.Unmarshal(data, &fields)
+	expected := `"` + base64.StdEncoding.EncodeToString(client.PubKey.ToUncompressedBytes()) + `"`
+	if len(fields) != 2 || string(fields["pub"]) != expected || fields["d1"] == nil {
+		t.Errorf("TestKeyJSON 失败\n期望值=%s\n实际值=%s", expected, data)
+	}
+
+	var client2 ClientKey
+	var server2 ServerKey
+	transfer(t, client, &client2)
+	transfer(t, server, &server2)
+	if client2.D.Cmp(client.D) != 0 || server2.D.Cmp(server.D) != 0 ||
+		!client2.PubKey.Equal(client.PubKey) || !server2.PubKey.Equal(server.PubKey) {
+		t.Fatalf("TestKeyJSON 失败\n期望值=%s\n实际值=%+v", data, client2)
+	}
+	msg := []byte("message digest")
+	if sig := coSign(t, &client2, &server2, nil, msg); !client.PubKey.Verify(nil, msg, sig) {
+		t.Error("TestKeyJSON 失败: 解析后的密钥签名未通过验证")
+	}
+
+	// 密钥生成未完成时没有公钥
+	pending, _, _ := NewClientKey(nil)
+	var pending2 ClientKey
+	transfer(t, pending, &pending2)
+	if pending2.PubKey != nil || pending2.D.Cmp(pending.D) != 0 {
+		t.Errorf("TestKeyJSON 失败: 未完成的密钥 %+v", pending2)
+	}
+	if err := json.Unmarshal([]byte(`{"d1":1,"pub":"AAAA"}`), &pending2); err != ErrInvalidPoint {
+		t.Errorf("TestKeyJSON 失败\n期望值=%v\n实际值=%v", ErrInvalidPoint, err)
+	}
+}
@@ -0,0 +1,59 @@
+package sm2
+
+import (
+	"errors"
+	"math/big"
+)
+
+// -----------------------------------------------------------------------------
+// GB/T 32918.1-2016 6 密钥对的生成与公钥的验证
+//
+// 从证书、第三方或密钥交换对方得到的公钥在使用前都应当验证，本库的解析函数
+// 返回的公钥均已通过 Validate
+// -----------------------------------------------------------------------------
+
+var (
+	ErrPublicKeyInfinity    = errors.New("sm2: public key is the point at infinity")
+	ErrPublicKeyOutOfRange  = errors.New("sm2: public key coordinate is not a field element")
+	ErrPublicKeyWrongOrder  = errors.New("sm2: public key does not have order n")
+	ErrPrivateKeyOutOfRange = errors.New("sm2: private key is not in [1, n-2]")
+)
+
+// Validate 6.2.1 Fp 上椭圆曲线公钥的验证
+func (pk *PubKey) Validate() error {
+	return pk.Curve.validatePoint(pk.X, pk.Y)
+}
+
+func (c Curve) validatePoint(x, y *big.Int) error {
+	if x == nil || y == nil {
+		return ErrPublicKeyOutOfRange
+	}
+	// a) P ≠ O
+	if IsPointInfinity(x, y) {
+		return ErrPublicKeyInfinity
+	}
+	// b) xP、yP 是 [0, p-1] 中的整数
+	if x.Sign() < 0 || x.Cmp(c.P) >= 0 || y.Sign() < 0 || y.Cmp(c.P) >= 0 {
+		return ErrPublicKeyOutOfRange
+	}
+	// c) yP^2 ≡ xP^3 + a·xP + b (mod p)
+	if !c.IsOnCurve(x, y) {
+		return ErrPointNotOnCurve
+	}
+	// d) [n]P = O
+	if nx, ny := c.ScalarMult(x, y, c.N.Bytes()); !IsPointInfinity(nx, ny) {
+		return ErrPublicKeyWrongOrder
+	}
+	return nil
+}
+
+// Validate 6.1 私钥 d 应在 [1, n-2] 内
+func (sk *PrivKey) Validate() error {
+	if sk.D == nil || sk.D.Sign() <= 0 {
+		return ErrPrivateKeyOutOfRange
+	}
+	if new(big.Int).Add(sk.D, big.NewInt(1)).Cmp(sk.Curve.N) >= 0 {
+		return ErrPrivateKeyOutOfRange
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package sm2
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// toyCurve y^2 = x^3 + x + 1 (mod 23)，共 28 个点，G = (5, 4) 的阶 n = 7，
+// 余因子 h = 4
+func toyCurve() *Curve {
+	return Init(big.NewInt(1), big.NewInt(1), &elliptic.CurveParams{
+		P:       big.NewInt(23),
+		N:       big.NewInt(7),
+		B:       big.NewInt(1),
+		Gx:      big.NewInt(5),
+		Gy:      big.NewInt(4),
+		BitSize: 5,
+		Name:    "toy",
+	})
+}
+
+func TestPubKeyValidate(t *testing.T) {
+	_, pk, _ := GenKey(rand.Reader)
+	if err := pk.Validate(); err != nil {
+		t.Fatalf("TestPubKeyValidate 失败: %v", err)
+	}
+
+	curve := pk.Curve
+	toy := *toyCurve()
+	cases := []struct {
+		key *PubKey
+		err error
+	}{
+		{&PubKey{X: nil, Y: pk.Y, Curve: curve}, ErrPublicKeyOutOfRange},
+		{&PubKey{X: big.NewInt(0), Y: big.NewInt(0), Curve: curve}, ErrPublicKeyInfinity},
+		{&PubKey{X: new(big.Int).Add(pk.X, curve.P), Y: pk.Y, Curve: curve}, ErrPublicKeyOutOfRange},
+		{&PubKey{X: pk.X, Y: new(big.Int).Neg(pk.Y), Curve: curve}, ErrPublicKeyOutOfRange},
+		{&PubKey{X: pk.X, Y: new(big.Int).Add(pk.Y, big.NewInt(1)), Curve: curve}, ErrPointNotOnCurve},
+		{&PubKey{X: big.NewInt(5), Y: big.NewInt(19), Curve: toy}, nil},
+		// (0, 1) 的阶为 28
+		{&PubKey{X: big.NewInt(0), Y: big.NewInt(1), Curve: toy}, ErrPublicKeyWrongOrder},
+	}
+	for i, c := range cases {
+		if err := c.key.Validate(); err != c.err {
+			t.Errorf("TestPubKeyValidate %d 失败\n期望值=%v\n实际值=%v", i, c.err, err)
+		}
+	}
+}
+
+func TestPrivKeyValidate(t *testing.T) {
+	curve := *InitWithRecommendedParams()
+	n := curve.N
+	cases := []struct {
+		d   *big.Int
+		err error
+	}{
+		{big.NewInt(1), nil},
+		{new(big.Int).Sub(n, big.NewInt(2)), nil},
+		{nil, ErrPrivateKeyOutOfRange},
+		{big.NewInt(0), ErrPrivateKeyOutOfRange},
+		{big.NewInt(-1), ErrPrivateKeyOutOfRange},
+		{new(big.Int).Sub(n, big.NewInt(1)), ErrPrivateKeyOutOfRange},
+		{n, ErrPrivateKeyOutOfRange},
+	}
+	for i, c := range cases {
+		sk := &PrivKey{D: c.d, Curve: curve}
+		if err := sk.Validate(); err != c.err {
+			t.Errorf("TestPrivKeyValidate %d 失败\n期望值=%v\n实际值=%v", i, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/t1anchen/gogmlib/utils"
+)
+
+func TestVerifier(t *testing.T) {
+	userID := []byte("ALICE123@YAHOO.COM")
+	sk, pk, _ := GenKey(rand.Reader)
+	custom := &PrivKey{
+		D:     utils.NewBigIntFromHexString("6FCBA2EF9AE0AB902BC3BDE3FF915D44BA4CC78F88E2F8E7F8996D3B8CCEEDEE"),
+		Curve: exampleCurve()}
+
+	for _, key := range []*PrivKey{sk, custom} {
+		pub := key.GenPubKey()
+		v := pub.Precompute(userID)
+		msg := []byte("message digest")
+		r, s, err := key.SignToBigInt(userID, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !v.VerifyFromBigInt(msg, r, s) {
+			t.Errorf("TestVerifier 失败: %s", key.Curve.Name)
+		}
+		if v.VerifyFromBigInt([]byte("message"), r, s) {
+			t.Errorf("TestVerifier 失败: %s 接受了错误的消息", key.Curve.Name)
+		}
+		if v.VerifyFromBigInt(msg, s, r) {
+			t.Errorf("TestVerifier 失败: %s 接受了错误的签名", key.Curve.Name)
+		}
+		if pub.Precompute(nil).VerifyFromBigInt(msg, r, s) {
+			t.Errorf("TestVerifier 失败: %s 接受了错误的用户身份标识", key.Curve.Name)
+		}
+	}
+
+	der, _ := sk.SignToASN1DER(nil, []byte("der"))
+	if !pk.Precompute(nil).VerifyFromASN1DER([]byte("der"), der) {
+		t.Error("TestVerifier 失败: VerifyFromASN1DER")
+	}
+}
+
+func TestVerifierConcurrent(t *testing.T) {
+	sk, pk, _ := GenKey(rand.Reader)
+	v := pk.Precompute(nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			msg := []byte{byte(i)}
+			r, s, _ := sk.SignToBigInt(nil, msg)
+			if !v.VerifyFromBigInt(msg, r, s) {
+				t.Errorf("TestVerifierConcurrent 失败: %d", i)
+			}
+			if v.VerifyFromBigInt(msg, r, new(big.Int).Add(s, big.NewInt(1))) {
+				t.Errorf("TestVerifierConcurrent 失败: %d 接受了错误的签名", i)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func BenchmarkVerifier(b *testing.B) {
+	sk, pk, _ := GenKey(rand.Reader)
+	msg := []byte("benchmark")
+	r, s, _ := sk.SignToBigInt(nil, msg)
+	b.Run("precomputed", func(b *testing.B) {
+		v := pk.Precompute(nil)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			v.VerifyFromBigInt(msg, r, s)
+		}
+	})
+	b.Run("pubkey", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pk.VerifyFromBigInt(nil, msg, r, s)
+		}
+	})
+	b.Run("precompute", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pk.Precompute(nil)
+		}
+	})
+}
@@ -28,7 +28,7 @@ func (ctx *Context) Sum(inputStream []byte) []byte {
 
 // Size 实现 Hash 接口中的 Size 函数
 func (ctx *Context) Size() int {
-	return 32
+	return DigestSizeInByte
 }
 
 // BlockSize 实现 Hash 接口中的 BlockSize 函数
@@ -1,7 +1,10 @@
 package sm3
 
 import (
+	"crypto/hmac"
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/t1anchen/gogmlib/utils"
@@ -50,3 +53,23 @@ func TestSm3ApiExample2(t *testing.T) {
 实际值=%s`, expectedStr, actualStr)
 	}
 }
+
+// TestHMAC HMAC 按 BlockSize() 填充密钥，期望值由 Python 的 hmac.new(key, msg, "sm3")
+// 计算，第二个密钥长于分组，需要先杂凑
+func TestHMAC(t *testing.T) {
+	cases := []struct {
+		key, msg, expected string
+	}{
+		{"key", "The quick brown fox jumps over the lazy dog",
+			"bd4a34077888162b210645b8ebf74b9af357303789357a27c7fc457244ebd398"},
+		{strings.Repeat("k", 100), "abc",
+			"2d87dd3ffa1452e8e40d9123a02824fb7dd98ae4a52683287245f1736dc610ef"},
+	}
+	for _, c := range cases {
+		mac := hmac.New(New, []byte(c.key))
+		mac.Write([]byte(c.msg))
+		if actual := hex.EncodeToString(mac.Sum(nil)); actual != c.expected {
+			t.Errorf("TestHMAC 失败\n期望值=%s\n实际值=%s", c.expected, actual)
+		}
+	}
+}
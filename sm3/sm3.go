@@ -9,9 +9,14 @@ import (
 	"github.com/t1anchen/gogmlib/utils"
 )
 
+// BlockSizeInByte 为 GB/T 32905-2016 5.2 的 512 比特分组。早期版本误写为 16
+// （分组的字数），BlockSize() 因此返回 16，HMAC-SM3 等按 hash.Hash.BlockSize()
+// 填充密钥的构造都会算错
 const (
-	BlockSizeInByte  = 16
+	BlockSizeInByte  = 64
 	DigestSizeInByte = 32
+
+	blockSizeInWord = BlockSizeInByte / 4
 )
 
 // -----------------------------------------------------------------------------
@@ -245,14 +250,14 @@ func (ctx *Context) processWord(wordBuf []byte, offset int32) {
 }
 
 func (ctx *Context) processLength(bitLength int64) {
-	if ctx.xOff > (BlockSizeInByte - 2) {
+	if ctx.xOff > (blockSizeInWord - 2) {
 		ctx.buffer[ctx.xOff] = 0
 		ctx.xOff++
 
 		ctx.processBlock()
 	}
 
-	for ; ctx.xOff < (BlockSizeInByte - 2); ctx.xOff++ {
+	for ; ctx.xOff < (blockSizeInWord - 2); ctx.xOff++ {
 		ctx.buffer[ctx.xOff] = 0
 	}
 
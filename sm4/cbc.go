@@ -0,0 +1,74 @@
+package sm4
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+)
+
+// -----------------------------------------------------------------------------
+// CBC 工作模式
+//
+// GB/T 17964-2021 中的密码分组链接模式，填充使用 RFC 5652 6.3 (PKCS#7)，
+// 与 PBES2 等使用 SM4-CBC 的场合一致
+// -----------------------------------------------------------------------------
+
+var (
+	ErrInvalidIV      = errors.New("sm4: invalid IV length")
+	ErrInvalidPadding = errors.New("sm4: invalid padding")
+)
+
+// EncryptCBC 使用 PKCS#7 填充后以 CBC 模式加密，iv 为 16 字节
+func EncryptCBC(key, iv, plaintext []byte) ([]byte, error) {
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != BlockSizeInByte {
+		return nil, ErrInvalidIV
+	}
+	padLen := BlockSizeInByte - len(plaintext)%BlockSizeInByte
+	out := make([]byte, len(plaintext)+padLen)
+	copy(out, plaintext)
+	for i := len(plaintext); i < len(out); i++ {
+		out[i] = byte(padLen)
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, out)
+	return out, nil
+}
+
+// DecryptCBC 以 CBC 模式解密并去除 PKCS#7 填充
+func DecryptCBC(key, iv, ciphertext []byte) ([]byte, error) {
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != BlockSizeInByte {
+		return nil, ErrInvalidIV
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%BlockSizeInByte != 0 {
+		return nil, errInputNotFullBlock
+	}
+	out := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, ciphertext)
+
+	padLen, ok := checkPadding(out)
+	if ok != 1 {
+		return nil, ErrInvalidPadding
+	}
+	return out[:len(out)-padLen], nil
+}
+
+// checkPadding 检查最后一个分组的填充，检查过程不依赖填充的值
+func checkPadding(b []byte) (int, int) {
+	last := b[len(b)-BlockSizeInByte:]
+	padLen := int(last[BlockSizeInByte-1])
+	ok := subtle.ConstantTimeLessOrEq(1, padLen) & subtle.ConstantTimeLessOrEq(padLen, BlockSizeInByte)
+	for i := 0; i < BlockSizeInByte; i++ {
+		// 位于填充范围内的字节必须等于 padLen
+		inPad := subtle.ConstantTimeLessOrEq(BlockSizeInByte-padLen, i)
+		eq := subtle.ConstantTimeByteEq(last[i], byte(padLen))
+		ok &= eq | (inPad ^ 1)
+	}
+	return padLen, ok
+}
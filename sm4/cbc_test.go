@@ -0,0 +1,68 @@
+package sm4
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// 由 openssl enc -sm4-cbc -K 0123456789abcdeffedcba9876543210
+// -iv 000102030405060708090a0b0c0d0e0f 生成
+var cbcTestCases = []struct {
+	plaintext  string
+	ciphertext string
+}{
+	{"", "4b910651754b5553f10cfa0c8a09e9e5"},
+	{"0123456789abcdef", "9d193c43fdc9ac44b40c27629ea9df0c8dce12d6419f61023c46b703dbd1bd2d"},
+	{"0123456789abcdef0123456789abcdefXYZ",
+		"9d193c43fdc9ac44b40c27629ea9df0cb8e075fe283adf662aefc549b2aa23683939f92e950c3568c937c3b72b15f29d"},
+}
+
+func TestCBC(t *testing.T) {
+	key, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	iv, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	for _, c := range cbcTestCases {
+		actual, err := EncryptCBC(key, iv, []byte(c.plaintext))
+		if err != nil || hex.EncodeToString(actual) != c.ciphertext {
+			t.Errorf("TestCBC 加密失败\n期望值=%s\n实际值=%x\n错误=%v", c.ciphertext, actual, err)
+			continue
+		}
+		plaintext, err := DecryptCBC(key, iv, actual)
+		if err != nil || string(plaintext) != c.plaintext {
+			t.Errorf("TestCBC 解密失败\n期望值=%s\n实际值=%s\n错误=%v", c.plaintext, plaintext, err)
+		}
+	}
+}
+
+func TestCBCInvalidInput(t *testing.T) {
+	key := make([]byte, KeySizeInByte)
+	iv := make([]byte, BlockSizeInByte)
+	if _, err := EncryptCBC(key, iv[:8], nil); err != ErrInvalidIV {
+		t.Errorf("TestCBCInvalidInput 失败: IV 长度错误未报错, err=%v", err)
+	}
+	if _, err := DecryptCBC(key, iv, make([]byte, 15)); err == nil {
+		t.Error("TestCBCInvalidInput 失败: 密文不是整分组未报错")
+	}
+
+	// 篡改填充：最后一个字节为 0 或大于分组长度，或者填充字节不一致
+	ciphertext, _ := EncryptCBC(key, iv, []byte("0123456789"))
+	for _, pad := range [][]byte{
+		bytes.Repeat([]byte{0}, 16),
+		append(make([]byte, 15), 17),
+		append(bytes.Repeat([]byte{3}, 14), 4, 3),
+	} {
+		block, _ := NewCipher(key)
+		// 构造解密结果为 pad 的密文：C = E(pad ^ IV)
+		tampered := make([]byte, BlockSizeInByte)
+		for i := range tampered {
+			tampered[i] = pad[i] ^ iv[i]
+		}
+		block.Encrypt(tampered, tampered)
+		if _, err := DecryptCBC(key, iv, tampered); err != ErrInvalidPadding {
+			t.Errorf("TestCBCInvalidInput 失败: 填充 %x 未报错, err=%v", pad, err)
+		}
+	}
+	if _, err := DecryptCBC(key, iv, ciphertext); err != nil {
+		t.Errorf("TestCBCInvalidInput 失败: %v", err)
+	}
+}
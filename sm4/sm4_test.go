@@ -1,7 +1,10 @@
 package sm4
 
 import (
+	"bytes"
 	"testing"
+
+	"github.com/t1anchen/gogmlib/utils"
 )
 
 var (
@@ -18,3 +21,60 @@ func TestContextToString(t *testing.T) {
 实际值=%s`, expected, actual)
 	}
 }
+
+// -----------------------------------------------------------------------------
+// GB/T 32907 附录A 运算示例
+// -----------------------------------------------------------------------------
+
+var (
+	exampleKey   = utils.HexStringToBytes("0123456789abcdeffedcba9876543210")
+	examplePlain = utils.HexStringToBytes("0123456789abcdeffedcba9876543210")
+)
+
+// TestEncryptExample1 A.1 对一组明文用密钥加密一次
+func TestEncryptExample1(t *testing.T) {
+	c, err := NewCipher(exampleKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := make([]byte, BlockSizeInByte)
+	c.Encrypt(actual, examplePlain)
+	expected := utils.HexStringToBytes("681edf34d206965e86b3e94f536e4246")
+	if !bytes.Equal(actual, expected) {
+		t.Errorf(`TestEncryptExample1失败
+期望值=%x
+实际值=%x`, expected, actual)
+	}
+
+	c.Decrypt(actual, actual)
+	if !bytes.Equal(actual, examplePlain) {
+		t.Errorf(`TestEncryptExample1 解密失败
+期望值=%x
+实际值=%x`, examplePlain, actual)
+	}
+}
+
+// TestEncryptExample2 A.2 用同一密钥对一组明文反复加密 1000000 次
+func TestEncryptExample2(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping 1000000 rounds in short mode")
+	}
+	c, _ := NewCipher(exampleKey)
+	actual := make([]byte, BlockSizeInByte)
+	copy(actual, examplePlain)
+	for i := 0; i < 1000000; i++ {
+		c.Encrypt(actual, actual)
+	}
+	expected := utils.HexStringToBytes("595298c7c6fd271f0402f804c33d3f66")
+	if !bytes.Equal(actual, expected) {
+		t.Errorf(`TestEncryptExample2失败
+期望值=%x
+实际值=%x`, expected, actual)
+	}
+}
+
+func TestNewCipherKeySize(t *testing.T) {
+	if _, err := NewCipher(make([]byte, 15)); err == nil {
+		t.Error("TestNewCipherKeySize 失败: 15 字节密钥未报错")
+	}
+}